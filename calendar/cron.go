@@ -0,0 +1,151 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CronPrefix is the prefix of a schedule written as a cron expression, like "cron: */15 2-5 * * 1-5"
+const CronPrefix = "cron:"
+
+var (
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+
+	cronMonths = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+)
+
+// IsCron returns true when the input is a cron expression ("cron:" prefix) or a cron macro like "@daily"
+func IsCron(input string) bool {
+	input = strings.TrimSpace(input)
+	return strings.HasPrefix(input, CronPrefix) || strings.HasPrefix(input, "@")
+}
+
+// ParseCron parses a cron expression with 5 fields (minute, hour, day of month, month and day of week)
+// or a cron macro like "@daily". The expression can also start with the "cron:" prefix.
+//
+// When both the day of month and the day of week are set, the event runs when both match
+// (and not when either matches like cron does).
+func (e *Event) ParseCron(expression string) error {
+	if e.input == "" {
+		e.input = expression
+	}
+	expression = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(expression), CronPrefix))
+	if strings.HasPrefix(expression, "@") {
+		macro, found := cronMacros[strings.ToLower(expression)]
+		if !found {
+			return fmt.Errorf("unsupported cron macro %q", expression)
+		}
+		expression = macro
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return fmt.Errorf("expected 5 fields in cron expression but found %d: %q", len(fields), expression)
+	}
+
+	err := e.Second.AddValue(0)
+	if err != nil {
+		return err
+	}
+
+	eventFields := []struct {
+		value *Value
+		names []string
+		first int
+	}{
+		{e.Minute, nil, 0},
+		{e.Hour, nil, 0},
+		{e.Day, nil, 0},
+		{e.Month, cronMonths, 1},
+		{e.WeekDay, shortWeekDay[:daysPerWeek], 0},
+	}
+	for index, eventField := range eventFields {
+		err := parseCronField(fields[index], eventField.value, eventField.names, eventField.first)
+		if err != nil {
+			return fmt.Errorf("error parsing %q: %w", fields[index], err)
+		}
+	}
+	return nil
+}
+
+// parseCronSchedule parses a cron expression written in a schedule
+func (e *Event) parseCronSchedule(expression string) error {
+	err := e.ParseCron(expression)
+	if err != nil {
+		return err
+	}
+	if e.Day.HasValue() && e.WeekDay.HasValue() {
+		// cron runs when either the day of month or the day of week matches, which has no equivalent in a calendar event
+		return errors.New("cron expressions restricting both the day of month and the day of week are not supported")
+	}
+	return nil
+}
+
+// parseCronField parses one field of a cron expression: "*", values, ranges "a-b" and repetitions "*/n" or "a-b/n".
+// Names (like "jan" or "mon") can be used instead of numbers, the first name having the value of first.
+func parseCronField(field string, value *Value, names []string, first int) error {
+	if field == "*" {
+		return nil
+	}
+	maxValue := value.maxRange
+	if value.definedType == TypeWeekDay {
+		// 7 is also sunday, but repetitions stop at saturday
+		maxValue = daysPerWeek - 1
+	}
+	for part := range strings.SplitSeq(field, ",") {
+		base, repetition, hasRepetition := strings.Cut(part, "/")
+		step := 1
+		if hasRepetition {
+			var err error
+			step, err = strconv.Atoi(repetition)
+			if err != nil || step <= 0 {
+				return fmt.Errorf("invalid repetition %q", repetition)
+			}
+		}
+		start, end := value.minRange, maxValue
+		if base != "*" {
+			from, to, isRange := strings.Cut(base, "-")
+			var err error
+			start, err = parseCronValue(from, names, first)
+			if err != nil {
+				return err
+			}
+			switch {
+			case isRange:
+				end, err = parseCronValue(to, names, first)
+				if err != nil {
+					return err
+				}
+			case !hasRepetition:
+				end = start
+			}
+		}
+		if start > end {
+			return fmt.Errorf("invalid range %q", base)
+		}
+		for current := start; current <= end; current += step {
+			err := value.AddValue(current)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func parseCronValue(input string, names []string, first int) (int, error) {
+	if index := slices.Index(names, strings.ToLower(input)); index >= 0 {
+		return index + first, nil
+	}
+	return strconv.Atoi(input)
+}
@@ -0,0 +1,90 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCron(t *testing.T) {
+	assert.True(t, IsCron("cron: * * * * *"))
+	assert.True(t, IsCron(" cron:0 2 * * *"))
+	assert.True(t, IsCron("@daily"))
+	assert.False(t, IsCron("daily"))
+	assert.False(t, IsCron("*-*-* 02:00"))
+}
+
+func TestParseCronSchedule(t *testing.T) {
+	testData := []struct{ input, expected string }{
+		{"cron: */15 2-5 * * 1-5", "Mon..Fri *-*-* 02..05:00,15,30,45:00"},
+		{"cron:0 2 * * *", "*-*-* 02:00:00"},
+		{"cron: 30 1 1,15 * *", "*-*-01,15 01:30:00"},
+		{"cron: 0 0 1 jan,jul *", "*-01,07-01 00:00:00"},
+		{"cron: 0 22 * * sun", "Sun *-*-* 22:00:00"},
+		{"cron: 0 22 * * 7", "Sun *-*-* 22:00:00"},
+		{"cron: 0 22 * * MON-FRI", "Mon..Fri *-*-* 22:00:00"},
+		{"cron: 0 */6 * * *", "*-*-* 00,06,12,18:00:00"},
+		{"cron: 5-20/5 * * * *", "*-*-* *:05,10,15,20:00"},
+		{"cron: 0 3 * * */2", "Sun,Tue,Thu,Sat *-*-* 03:00:00"},
+		{"cron: 0 2 * * * UTC", "*-*-* 02:00:00 UTC"},
+		{"cron: @daily", "*-*-* 00:00:00"},
+		{"@yearly", "*-01-01 00:00:00"},
+		{"@annually", "*-01-01 00:00:00"},
+		{"@monthly", "*-*-01 00:00:00"},
+		{"@weekly", "Sun *-*-* 00:00:00"},
+		{"@daily", "*-*-* 00:00:00"},
+		{"@midnight", "*-*-* 00:00:00"},
+		{"@hourly", "*-*-* *:00:00"},
+		{"@daily Europe/Paris", "*-*-* 00:00:00 Europe/Paris"},
+	}
+
+	for _, testItem := range testData {
+		t.Run(testItem.input, func(t *testing.T) {
+			event := NewEvent()
+			err := event.Parse(testItem.input)
+			require.NoError(t, err)
+			assert.Equal(t, testItem.expected, event.String())
+			assert.Equal(t, testItem.input, event.Input())
+		})
+	}
+}
+
+func TestParseInvalidCronSchedule(t *testing.T) {
+	testData := []string{
+		"cron:",
+		"cron: * * * *",
+		"cron: * * * * * *",
+		"cron: 60 * * * *",
+		"cron: * 24 * * *",
+		"cron: * * 0 * *",
+		"cron: * * * 13 *",
+		"cron: * * * * 8",
+		"cron: 5-1 * * * *",
+		"cron: */0 * * * *",
+		"cron: , * * * *",
+		"cron: 0 0 1 * mon",
+		"cron: 0 0 * * moon",
+		"@reboot",
+		"@every",
+	}
+
+	for _, testItem := range testData {
+		t.Run(testItem, func(t *testing.T) {
+			event := NewEvent()
+			err := event.Parse(testItem)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNextCronSchedule(t *testing.T) {
+	// the base time is the example in the Go documentation https://golang.org/pkg/time/
+	ref, err := time.Parse(time.ANSIC, "Mon Jan 2 15:04:05 2006")
+	require.NoError(t, err)
+
+	event := NewEvent()
+	require.NoError(t, event.Parse("cron: */15 2-5 * * 1-5"))
+	assert.Equal(t, mustParseTime("2006-01-03 02:00:00"), event.Next(ref))
+}
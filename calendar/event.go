@@ -13,14 +13,15 @@ import (
 // Event represents a calendar event.
 // It can be one specific point in time, or a recurring event
 type Event struct {
-	input   string
-	WeekDay *Value
-	Year    *Value
-	Month   *Value
-	Day     *Value
-	Hour    *Value
-	Minute  *Value
-	Second  *Value
+	input    string
+	WeekDay  *Value
+	Year     *Value
+	Month    *Value
+	Day      *Value
+	Hour     *Value
+	Minute   *Value
+	Second   *Value
+	Location *time.Location // nil for the local time
 }
 
 // NewEvent instantiates a new event with all its default values
@@ -52,14 +53,24 @@ func (e *Event) String() string {
 	if e.WeekDay.HasValue() {
 		output += numbersToWeekdays(e.WeekDay.String()) + " "
 	}
-	output += e.Year.String() + "-" +
-		e.Month.String() + "-" +
+	output += e.dateTimeString()
+	if e.Location != nil {
+		output += " " + e.Location.String()
+	}
+	return output
+}
+
+func (e *Event) dateTimeString() string {
+	daySeparator := "-"
+	if e.Day.IsReverse() {
+		daySeparator = "~"
+	}
+	return e.Year.String() + "-" +
+		e.Month.String() + daySeparator +
 		e.Day.String() + " " +
 		e.Hour.String() + ":" +
 		e.Minute.String() + ":" +
 		e.Second.String()
-
-	return output
 }
 
 // Parse a string into an event
@@ -68,6 +79,11 @@ func (e *Event) Parse(input string) error {
 	if input == "" {
 		return errors.New("calendar event cannot be an empty string")
 	}
+	input, e.Location = parseLocation(input)
+
+	if IsCron(input) {
+		return e.parseCronSchedule(input)
+	}
 
 	// check for a keyword
 	for keyword, setValues := range specialKeywords {
@@ -93,8 +109,15 @@ func (e *Event) Parse(input string) error {
 	return errors.New("calendar event doesn't match any well known pattern")
 }
 
-// Next returns the next schedule for this event
+// Next returns the next schedule for this event.
+// When the event has a location, the returned time is in this location.
+//
+// Around daylight saving time changes, an event at a specific hour within the skipped hour runs at the end of the change,
+// and an event at a specific hour within the repeated hour only runs once (like cron does).
 func (e *Event) Next(from time.Time) time.Time {
+	if e.Location != nil {
+		from = from.In(e.Location)
+	}
 	// start from time and increment of 1 minute each time
 	next := from.Truncate(time.Minute) // truncate all the seconds
 	// if we're already partway through a minute, skip to the next minute
@@ -104,10 +127,17 @@ func (e *Event) Next(from time.Time) time.Time {
 	}
 	// should stop in 2 years time to avoid an infinite loop
 	endYear := from.Year() + 2
+	previousOffset := zoneOffset(next.Add(-time.Minute))
 	for next.Year() <= endYear {
-		if e.match(next) {
+		offset := zoneOffset(next)
+		if offset > previousOffset && e.Hour.HasValue() && e.matchSkipped(next.Add(-time.Minute), offset-previousOffset) {
+			// the clock moved forward over the event
+			return next
+		}
+		if e.match(next) && !(e.Hour.HasValue() && isRepeated(next)) {
 			return next
 		}
+		previousOffset = offset
 		// increment 1 minute
 		next = next.Add(time.Minute)
 	}
@@ -122,9 +152,14 @@ func (e *Event) AsTime() (time.Time, bool) {
 		e.Year.HasSingleValue() &&
 		e.Month.HasSingleValue() &&
 		e.Day.HasSingleValue() &&
+		!e.Day.IsReverse() &&
 		e.Hour.HasSingleValue() &&
 		e.Minute.HasSingleValue() {
-		event, err := time.Parse("2006-01-02 15:04:05", e.String())
+		location := time.UTC
+		if e.Location != nil {
+			location = e.Location
+		}
+		event, err := time.ParseInLocation("2006-01-02 15:04:05", e.dateTimeString(), location)
 		return event, err == nil
 	}
 	return time.Now(), false
@@ -183,13 +218,18 @@ func (e *Event) IsMonthly() bool {
 
 // match returns true if the time in parameter would trigger the event
 func (e *Event) match(currentTime time.Time) bool {
+	day := currentTime.Day()
+	if e.Day.IsReverse() {
+		// 1 is the last day of the month
+		day = daysInMonth(currentTime) - day + 1
+	}
 	values := []struct {
 		ref     *Value
 		current int
 	}{
 		{e.Year, currentTime.Year()},
 		{e.Month, int(currentTime.Month())},
-		{e.Day, currentTime.Day()},
+		{e.Day, day},
 		{e.WeekDay, int(currentTime.Weekday())},
 		{e.Hour, currentTime.Hour()},
 		{e.Minute, currentTime.Minute()},
@@ -225,3 +265,8 @@ func capitalize(value string) string {
 	value = strings.ToUpper(value[0:1]) + value[1:]
 	return value
 }
+
+// daysInMonth returns the number of days in the month of t
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
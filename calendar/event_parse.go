@@ -7,10 +7,10 @@ import (
 )
 
 const (
-	unit        = "[0-9*.,]+"
-	weekday     = "([a-zA-Z0-9*.,]+)"
-	datePattern = "(" + unit + "-|)(" + unit + ")-(" + unit + ")" // year or nothing then month then day
-	timePattern = "(" + unit + "):(" + unit + ")(:" + unit + "|)" // hour, minute then second or nothing
+	unit        = "[0-9*.,/]+"
+	weekday     = "([a-zA-Z0-9*.,/]+)"
+	datePattern = "(" + unit + "-|)(" + unit + ")([-~]" + unit + ")" // year or nothing then month then day (or ~ for the last days)
+	timePattern = "(" + unit + "):(" + unit + ")(:" + unit + "|)"    // hour, minute then second or nothing
 )
 
 type parseFunc func(e *Event, match []string) error
@@ -66,7 +66,9 @@ func parseMonth(index int) parseFunc {
 
 func parseDay(index int) parseFunc {
 	return func(e *Event, match []string) error {
-		err := e.Day.Parse(match[index])
+		// "~" counts the days backwards from the end of the month
+		e.Day.reverse = strings.HasPrefix(match[index], "~")
+		err := e.Day.Parse(match[index][1:])
 		if err != nil {
 			return fmt.Errorf("cannot parse day: %w", err)
 		}
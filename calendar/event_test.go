@@ -45,7 +45,7 @@ func TestEventParse(t *testing.T) {
 		{"Mon,Fri *-*-3,1,2 *:30:45", "Mon,Fri *-*-01..03 *:30:45"},
 		{"12,14,13,12:20,10,30", "*-*-* 12..14:10,20,30:00"},
 		{"12..14:10,20,30", "*-*-* 12..14:10,20,30:00"},
-		// {"mon,fri *-1/2-1,3 *:30:45", "Mon,Fri *-01/2-01,03 *:30:45"},
+		{"mon,fri *-1/2-1,3 *:30:45", "Mon,Fri *-01,03,05,07,09,11-01,03 *:30:45"},
 		{"03-05 08:05:40", "*-03-05 08:05:40"},
 		{"08:05:40", "*-*-* 08:05:40"},
 		{"05:40", "*-*-* 05:40:00"},
@@ -54,18 +54,27 @@ func TestEventParse(t *testing.T) {
 		{"2003-03-05 05:40", "2003-03-05 05:40:00"},
 		// {"05:40:23.4200004/3.1700005", "*-*-* 05:40:23.420000/3.170001"},
 		{"2003-02..04-05", "2003-02..04-05 00:00:00"},
-		// {"2003-03-05 05:40 UTC", "2003-03-05 05:40:00 UTC"},
+		{"2003-03-05 05:40 UTC", "2003-03-05 05:40:00 UTC"},
 		{"2003-03-05", "2003-03-05 00:00:00"},
 		{"03-05", "*-03-05 00:00:00"},
 		{"hourly", "*-*-* *:00:00"},
 		{"daily", "*-*-* 00:00:00"},
-		// {"daily UTC", "*-*-* 00:00:00 UTC"},
+		{"daily UTC", "*-*-* 00:00:00 UTC"},
 		{"monthly", "*-*-01 00:00:00"},
 		{"weekly", "Mon *-*-* 00:00:00"},
-		// {"weekly Pacific/Auckland", "Mon *-*-* 00:00:00 Pacific/Auckland"},
+		{"weekly Pacific/Auckland", "Mon *-*-* 00:00:00 Pacific/Auckland"},
+		{"Mon..Fri 8:30 America/New_York", "Mon..Fri *-*-* 08:30:00 America/New_York"},
 		{"yearly", "*-01-01 00:00:00"},
 		{"annually", "*-01-01 00:00:00"},
-		// {"*:2/3", "*-*-* *:02/3:00"},
+		{"*:2/3", "*-*-* *:02,05,08,11,14,17,20,23,26,29,32,35,38,41,44,47,50,53,56,59:00"},
+		{"*:0/15", "*-*-* *:00,15,30,45:00"},
+		{"*:10..40/10", "*-*-* *:10,20,30,40:00"},
+		{"*-*-1/2", "*-*-01,03,05,07,09,11,13,15,17,19,21,23,25,27,29,31 00:00:00"},
+		{"0/6:00", "*-*-* 00,06,12,18:00:00"},
+		{"*-*~01", "*-*~01 00:00:00"},
+		{"*-02~03", "*-02~03 00:00:00"},
+		{"Mon *-05~07/1", "Mon *-05~01..07 00:00:00"},
+		{"*-*~1,2 23:00", "*-*~01,02 23:00:00"},
 		{"mon..sun", "Mon..Sun *-*-* 00:00:00"},
 		{"sun..mon", "Sun,Mon *-*-* 00:00:00"},
 	}
@@ -96,6 +105,10 @@ func TestParseInvalidEvents(t *testing.T) {
 		"1:99",
 		"24:2",
 		"1:2:60",
+		"*:0/0",
+		"*:0/a",
+		"*:60/5",
+		"*-*~32",
 	}
 
 	for _, testItem := range testData {
@@ -178,6 +191,12 @@ func TestNextTrigger(t *testing.T) {
 		{"11:*:*", "2006-01-03 11:00:00", ref},
 		{"tue", "2006-01-03 00:00:00", ref},
 		{"2003-*-*", "0001-01-01 00:00:00", ref},
+		{"*:0/20", "2006-01-02 15:20:00", ref},
+		{"*-*-2/10", "2006-01-12 00:00:00", ref},
+		{"*-*~01", "2006-01-31 00:00:00", ref},
+		{"*-02~01", "2006-02-28 00:00:00", ref},
+		{"Mon *-05~07/1", "2006-05-29 00:00:00", ref},
+		{"Fri *-*~07/1", "2006-01-27 00:00:00", ref},
 	}
 
 	for _, testItem := range testData {
@@ -262,6 +281,23 @@ func TestEventsInBetweenTwoDates(t *testing.T) {
 				mustParseTime("2006-01-02 16:00:00"),
 			},
 		},
+		{
+			"*:0/20",
+			1 * time.Hour,
+			[]time.Time{
+				mustParseTime("2006-01-02 15:20:00"),
+				mustParseTime("2006-01-02 15:40:00"),
+				mustParseTime("2006-01-02 16:00:00"),
+			},
+		},
+		{
+			"*-*~01 12:00",
+			60 * 24 * time.Hour,
+			[]time.Time{
+				mustParseTime("2006-01-31 12:00:00"),
+				mustParseTime("2006-02-28 12:00:00"),
+			},
+		},
 	}
 
 	// the base time is the example in the Go documentation https://golang.org/pkg/time/
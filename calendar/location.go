@@ -0,0 +1,210 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	minutesPerHour = 60
+	minutesPerDay  = 24 * minutesPerHour
+	daysPerWeek    = 7
+)
+
+// parseLocation removes the timezone at the end of the input, like "UTC" or "Europe/Paris"
+func parseLocation(input string) (string, *time.Location) {
+	fields := strings.Fields(input)
+	if len(fields) < 2 {
+		return input, nil
+	}
+	name := fields[len(fields)-1]
+	if name == "Local" || !unicode.IsLetter(rune(name[0])) {
+		return input, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return input, nil
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(input), name)), location
+}
+
+func zoneOffset(t time.Time) int {
+	_, offset := t.Zone()
+	return offset
+}
+
+// isRepeated returns true when the wall clock of t already happened before a daylight saving time change
+func isRepeated(t time.Time) bool {
+	before := zoneOffset(t.Add(-3 * time.Hour))
+	delta := before - zoneOffset(t)
+	if delta <= 0 {
+		return false
+	}
+	return zoneOffset(t.Add(-time.Duration(delta)*time.Second)) == before
+}
+
+// matchSkipped returns true when the event matches one of the wall clock minutes skipped after previous,
+// when the clock moves forward of skipped seconds
+func (e *Event) matchSkipped(previous time.Time, skipped int) bool {
+	wall := time.Date(previous.Year(), previous.Month(), previous.Day(), previous.Hour(), previous.Minute(), 0, 0, time.UTC)
+	for minute := 1; minute <= skipped/60; minute++ {
+		if e.match(wall.Add(time.Duration(minute) * time.Minute)) {
+			return true
+		}
+	}
+	return false
+}
+
+// OffsetChanges returns true when the difference between the timezone of the event and the location
+// changes within a year from the time in parameter, because the daylight saving time rules are different
+func (e *Event) OffsetChanges(location *time.Location, from time.Time) bool {
+	if e.Location == nil {
+		return false
+	}
+	difference := zoneOffset(from.In(location)) - zoneOffset(from.In(e.Location))
+	for day := 1; day <= 366; day++ {
+		at := from.AddDate(0, 0, day)
+		if zoneOffset(at.In(location))-zoneOffset(at.In(e.Location)) != difference {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertTo returns the events equivalent to this event in the location, using the difference between
+// the two timezones at the time in parameter. Converting an event can produce more than one event, when the
+// change of timezone moves some of the activations to another day.
+//
+// An error is returned when the event cannot be converted: when activations move to another day
+// and the event runs on specific days of the month.
+func (e *Event) ConvertTo(location *time.Location, at time.Time) ([]*Event, error) {
+	if e.Location == nil {
+		return []*Event{e}, nil
+	}
+	target := location
+	if location == time.Local {
+		target = nil
+	}
+	difference := (zoneOffset(at.In(location)) - zoneOffset(at.In(e.Location))) / 60
+	if difference == 0 {
+		event := e.clone()
+		event.Location = target
+		return []*Event{event}, nil
+	}
+
+	hours := e.Hour.GetRangeValues()
+	if !e.Hour.HasValue() {
+		hours = e.Hour.allValues()
+	}
+	minutes := e.Minute.GetRangeValues()
+	if !e.Minute.HasValue() {
+		minutes = e.Minute.allValues()
+	}
+	dayDependent := e.Year.HasValue() || e.Month.HasValue() || e.Day.HasValue() || e.WeekDay.HasValue()
+
+	// group the minutes per day shift and per hour in the new location
+	type key struct{ dayShift, hour int }
+	groups := make(map[key][]int)
+	for _, hour := range hours {
+		for _, minute := range minutes {
+			total := hour*minutesPerHour + minute + difference
+			dayShift := 0
+			if dayDependent {
+				dayShift = floorDiv(total, minutesPerDay)
+			}
+			total = modulo(total, minutesPerDay)
+			k := key{dayShift, total / minutesPerHour}
+			groups[k] = append(groups[k], total%minutesPerHour)
+		}
+	}
+
+	// then group the hours with the same minutes
+	type hoursKey struct {
+		dayShift int
+		minutes  string
+	}
+	hoursGroups := make(map[hoursKey][]int)
+	minutesGroups := make(map[hoursKey][]int)
+	for k, minutes := range groups {
+		slices.Sort(minutes)
+		hk := hoursKey{k.dayShift, fmt.Sprint(minutes)}
+		hoursGroups[hk] = append(hoursGroups[hk], k.hour)
+		minutesGroups[hk] = minutes
+	}
+
+	keys := make([]hoursKey, 0, len(hoursGroups))
+	for hk := range hoursGroups {
+		keys = append(keys, hk)
+	}
+	slices.SortFunc(keys, func(a, b hoursKey) int {
+		if a.dayShift != b.dayShift {
+			return a.dayShift - b.dayShift
+		}
+		return slices.Min(hoursGroups[a]) - slices.Min(hoursGroups[b])
+	})
+
+	events := make([]*Event, 0, len(keys))
+	for _, hk := range keys {
+		if hk.dayShift != 0 && (e.Year.HasValue() || e.Month.HasValue() || e.Day.HasValue()) {
+			return nil, errors.New("cannot convert an event running on specific days of the month to another timezone, when the conversion changes the day")
+		}
+		event := e.clone()
+		event.Location = target
+		event.Hour = newValueFrom(TypeHour, hoursGroups[hk], !e.Hour.HasValue())
+		event.Minute = newValueFrom(TypeMinute, minutesGroups[hk], !e.Minute.HasValue())
+		if hk.dayShift != 0 {
+			weekdays := make([]int, 0, daysPerWeek)
+			for _, weekday := range e.WeekDay.GetRangeValues() {
+				weekday = modulo(weekday+hk.dayShift, daysPerWeek)
+				if !slices.Contains(weekdays, weekday) {
+					weekdays = append(weekdays, weekday)
+				}
+			}
+			event.WeekDay = newValueFrom(TypeWeekDay, weekdays, false)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (e *Event) clone() *Event {
+	return &Event{
+		input:    e.input,
+		WeekDay:  e.WeekDay.clone(),
+		Year:     e.Year.clone(),
+		Month:    e.Month.clone(),
+		Day:      e.Day.clone(),
+		Hour:     e.Hour.clone(),
+		Minute:   e.Minute.clone(),
+		Second:   e.Second.clone(),
+		Location: e.Location,
+	}
+}
+
+// newValueFrom creates a value with all the values in parameter.
+// When all the possible values are set and allMeansAny is true, the value is left empty (any value)
+func newValueFrom(t TypeValue, values []int, allMeansAny bool) *Value {
+	value := NewValueFromType(t)
+	if allMeansAny && len(values) == len(value.allValues()) {
+		return value
+	}
+	for _, v := range values {
+		value.MustAddValue(v)
+	}
+	return value
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+func modulo(a, b int) int {
+	return ((a % b) + b) % b
+}
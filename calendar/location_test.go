@@ -0,0 +1,181 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return location
+}
+
+func TestParseLocation(t *testing.T) {
+	testData := []struct {
+		input, expected, location string
+	}{
+		{"daily", "daily", ""},
+		{"daily UTC", "daily", "UTC"},
+		{"Mon..Fri 08:00 Europe/Paris", "Mon..Fri 08:00", "Europe/Paris"},
+		{"Mon..Fri 08:00", "Mon..Fri 08:00", ""},
+		{"2003-03-05 05:40 Local", "2003-03-05 05:40 Local", ""},
+		{"2003-03-05 05:40 Nowhere/Unknown", "2003-03-05 05:40 Nowhere/Unknown", ""},
+	}
+
+	for _, testItem := range testData {
+		t.Run(testItem.input, func(t *testing.T) {
+			input, location := parseLocation(testItem.input)
+			assert.Equal(t, testItem.expected, input)
+			if testItem.location == "" {
+				assert.Nil(t, location)
+			} else {
+				require.NotNil(t, location)
+				assert.Equal(t, testItem.location, location.String())
+			}
+		})
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	paris := mustLoadLocation(t, "Europe/Paris")
+
+	testData := []struct {
+		event    string
+		from     string // UTC
+		expected string // in the location of the event
+	}{
+		{"08:00 Europe/Paris", "2024-01-15 06:30:00", "2024-01-15 08:00:00 +0100 CET"},
+		{"08:00 Europe/Paris", "2024-07-15 06:30:00", "2024-07-16 08:00:00 +0200 CEST"},
+		{"08:00 UTC", "2024-07-15 06:30:00", "2024-07-15 08:00:00 +0000 UTC"},
+		// the clock moves forward from 02:00 to 03:00
+		{"02:30 Europe/Paris", "2024-03-30 12:00:00", "2024-03-31 03:00:00 +0200 CEST"},
+		{"02:30 Europe/Paris", "2024-03-31 02:00:00", "2024-04-01 02:30:00 +0200 CEST"},
+		// the clock moves back from 03:00 to 02:00
+		{"02:30 Europe/Paris", "2024-10-26 12:00:00", "2024-10-27 02:30:00 +0200 CEST"},
+		{"02:30 Europe/Paris", "2024-10-27 00:45:00", "2024-10-28 02:30:00 +0100 CET"},
+		{"*:15 Europe/Paris", "2024-10-27 00:30:00", "2024-10-27 02:15:00 +0100 CET"},
+	}
+
+	for _, testItem := range testData {
+		t.Run(testItem.event+" from "+testItem.from, func(t *testing.T) {
+			event := NewEvent()
+			require.NoError(t, event.Parse(testItem.event))
+			from := mustParseTime(testItem.from)
+			next := event.Next(from)
+			assert.Equal(t, testItem.expected, next.Format("2006-01-02 15:04:05 -0700 MST"))
+		})
+	}
+
+	t.Run("without location", func(t *testing.T) {
+		event := NewEvent()
+		require.NoError(t, event.Parse("02:30"))
+		from := time.Date(2024, 3, 30, 12, 0, 0, 0, paris)
+		assert.Equal(t, "2024-03-31 03:00:00 +0200 CEST", event.Next(from).Format("2006-01-02 15:04:05 -0700 MST"))
+	})
+}
+
+func TestAsTimeInLocation(t *testing.T) {
+	event := NewEvent()
+	require.NoError(t, event.Parse("2024-07-01 10:00 Europe/Paris"))
+	at, ok := event.AsTime()
+	require.True(t, ok)
+	assert.Equal(t, "2024-07-01 08:00:00", at.UTC().Format("2006-01-02 15:04:05"))
+}
+
+func TestConvertTo(t *testing.T) {
+	utc := time.UTC
+	winter := mustParseTime("2024-01-15 12:00:00")
+
+	testData := []struct {
+		event    string
+		location *time.Location
+		expected []string
+	}{
+		{"08:00", utc, []string{"*-*-* 08:00:00"}},
+		{"08:00 Europe/Paris", utc, []string{"*-*-* 07:00:00 UTC"}},
+		{"*:30 Europe/Paris", utc, []string{"*-*-* *:30:00 UTC"}},
+		{"08:00 Asia/Kolkata", utc, []string{"*-*-* 02:30:00 UTC"}},
+		{"00,12:15 Europe/Paris", utc, []string{"*-*-* 11,23:15:00 UTC"}},
+		{"Mon..Fri 08:00 Europe/Paris", utc, []string{"Mon..Fri *-*-* 07:00:00 UTC"}},
+		{"Mon,Fri 00:30,45 Europe/Paris", utc, []string{"Sun,Thu *-*-* 23:30,45:00 UTC"}},
+		{"Mon 00,12:00 Europe/Paris", utc, []string{"Sun *-*-* 23:00:00 UTC", "Mon *-*-* 11:00:00 UTC"}},
+		{"Sun 23:00 America/New_York", utc, []string{"Mon *-*-* 04:00:00 UTC"}},
+		{"*-*-01 08:00 Europe/Paris", utc, []string{"*-*-01 07:00:00 UTC"}},
+		{"08:00 UTC", mustLoadLocation(t, "Europe/Paris"), []string{"*-*-* 09:00:00 Europe/Paris"}},
+	}
+
+	for _, testItem := range testData {
+		t.Run(testItem.event, func(t *testing.T) {
+			event := NewEvent()
+			require.NoError(t, event.Parse(testItem.event))
+			events, err := event.ConvertTo(testItem.location, winter)
+			require.NoError(t, err)
+			converted := make([]string, len(events))
+			for i, e := range events {
+				converted[i] = e.String()
+			}
+			assert.Equal(t, testItem.expected, converted)
+
+			// all the activations of the converted events are the same
+			from := winter
+			for range 10 {
+				next := event.Next(from)
+				nextConverted := time.Time{}
+				for _, e := range events {
+					if n := e.Next(from.In(testItem.location)); nextConverted.IsZero() || n.Before(nextConverted) {
+						nextConverted = n
+					}
+				}
+				if next.IsZero() || next.After(winter.AddDate(0, 1, 0)) {
+					break
+				}
+				assert.True(t, next.Equal(nextConverted), "expected %s but found %s", next, nextConverted)
+				from = next.Add(time.Minute)
+			}
+		})
+	}
+}
+
+func TestConvertToLocal(t *testing.T) {
+	event := NewEvent()
+	require.NoError(t, event.Parse("08:00 UTC"))
+	events, err := event.ConvertTo(time.Local, time.Now())
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Nil(t, events[0].Location)
+}
+
+func TestCannotConvertDayOfMonth(t *testing.T) {
+	event := NewEvent()
+	require.NoError(t, event.Parse("*-*-01 00:30 Europe/Paris"))
+	_, err := event.ConvertTo(time.UTC, mustParseTime("2024-01-15 12:00:00"))
+	assert.Error(t, err)
+}
+
+func TestOffsetChanges(t *testing.T) {
+	from := mustParseTime("2024-01-15 12:00:00")
+	testData := []struct {
+		event    string
+		location *time.Location
+		expected bool
+	}{
+		{"08:00", time.UTC, false},
+		{"08:00 Asia/Tokyo", time.UTC, false},
+		{"08:00 Europe/Paris", time.UTC, true},
+		{"08:00 Europe/Paris", mustLoadLocation(t, "Europe/Berlin"), false},
+		{"08:00 Europe/Paris", mustLoadLocation(t, "America/New_York"), true},
+	}
+
+	for _, testItem := range testData {
+		t.Run(testItem.event+" in "+testItem.location.String(), func(t *testing.T) {
+			event := NewEvent()
+			require.NoError(t, event.Parse(testItem.event))
+			assert.Equal(t, testItem.expected, event.OffsetChanges(testItem.location, from))
+		})
+	}
+}
@@ -40,6 +40,7 @@ type Value struct {
 	rangeValues    []bool
 	minRange       int
 	maxRange       int
+	reverse        bool // values counted from the end of the range (last days of the month)
 }
 
 // NewValue creates a new value
@@ -123,6 +124,11 @@ func (v *Value) HasLongContiguousRange() bool {
 	return false
 }
 
+// IsReverse is true when the values are counted backwards from the end (1 being the last day of the month)
+func (v *Value) IsReverse() bool {
+	return v.reverse
+}
+
 // GetType returns the defined type
 func (v *Value) GetType() TypeValue {
 	return v.definedType
@@ -245,6 +251,21 @@ func (v *Value) GetRanges() []Range {
 	return ranges
 }
 
+// allValues returns all the possible values
+func (v *Value) allValues() []int {
+	values := make([]int, 0, v.maxRange-v.minRange+1)
+	for value := v.minRange; value <= v.maxRange; value++ {
+		values = append(values, value)
+	}
+	return values
+}
+
+func (v *Value) clone() *Value {
+	clone := *v
+	clone.rangeValues = slices.Clone(v.rangeValues)
+	return &clone
+}
+
 // IsInRange check the parameter is in range of Value
 func (v *Value) IsInRange(ref int) bool {
 	return slices.Contains(v.GetRangeValues(), ref)
@@ -300,22 +321,11 @@ func (v *Value) Parse(input string, postProcess ...postProcessFunc) error {
 }
 
 func (v *Value) parseUnit(input string, postProcess ...postProcessFunc) error {
+	if base, repetition, found := strings.Cut(input, "/"); found {
+		return v.parseRepetition(base, repetition, postProcess...)
+	}
 	if strings.Contains(input, "..") {
-		// this is a range
-		var start, end int
-		parsed, err := fmt.Sscanf(input, "%d..%d", &start, &end)
-		if err != nil {
-			return err
-		}
-		if parsed != 2 {
-			return fmt.Errorf("cannot parse range '%s'", input)
-		}
-		// run post-processing functions before adding the value
-		start, err = runPostProcess(start, postProcess)
-		if err != nil {
-			return err
-		}
-		end, err = runPostProcess(end, postProcess)
+		start, end, err := parseRange(input, postProcess...)
 		if err != nil {
 			return err
 		}
@@ -343,6 +353,76 @@ func (v *Value) parseUnit(input string, postProcess ...postProcessFunc) error {
 	return nil
 }
 
+// parseRepetition adds the values from base, repeated every step: "0/15" is 0, 15, 30 and 45 for minutes.
+// The base can be a single value, a range, or "*" for all values.
+// Values counted backwards from the end are repeated towards the end: "~07/1" is the last 7 days of the month.
+func (v *Value) parseRepetition(base, repetition string, postProcess ...postProcessFunc) error {
+	step, err := parseInt(repetition)
+	if err != nil {
+		return fmt.Errorf("cannot parse repetition '%s': %w", repetition, err)
+	}
+	if step <= 0 {
+		return fmt.Errorf("invalid repetition '%s': must be greater than zero", repetition)
+	}
+	start, end := v.minRange, v.maxRange
+	switch {
+	case base == "*":
+	case strings.Contains(base, ".."):
+		start, end, err = parseRange(base, postProcess...)
+	default:
+		start, err = parseInt(base)
+		if err == nil {
+			start, err = runPostProcess(start, postProcess)
+		}
+		if v.reverse {
+			end = v.minRange
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err = v.checkValue(start); err != nil {
+		return err
+	}
+	if v.reverse && end > start {
+		start, end = end, start
+	}
+	if v.reverse {
+		for value := start; value >= end; value -= step {
+			if err = v.AddValue(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for value := start; value <= end; value += step {
+		if err = v.AddValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseRange(input string, postProcess ...postProcessFunc) (start, end int, err error) {
+	parsed, err := fmt.Sscanf(input, "%d..%d", &start, &end)
+	if err != nil {
+		return 0, 0, err
+	}
+	if parsed != 2 {
+		return 0, 0, fmt.Errorf("cannot parse range '%s'", input)
+	}
+	// run post-processing functions before adding the value
+	start, err = runPostProcess(start, postProcess)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err = runPostProcess(end, postProcess)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
 func parseInt(input string) (int, error) {
 	i, err := strconv.ParseInt(input, 10, 32)
 	return int(i), err
@@ -153,6 +153,10 @@ func TestParseString(t *testing.T) {
 		{1, 12, "1..3", "01..03"},
 		{1, 12, "1..3,5..6,10..12", "01..03,05..06,10..12"},
 		{1, 12, "1..3,5..5,10..12", "01..03,05,10..12"},
+		{1, 12, "1/3", "01,04,07,10"},
+		{1, 12, "*/4", "01,05,09"},
+		{1, 12, "2..8/2", "02,04,06,08"},
+		{1, 12, "1/3,12", "01,04,07,10,12"},
 	}
 
 	for _, testItem := range testData {
@@ -175,6 +179,10 @@ func TestParseStringError(t *testing.T) {
 		{1, 12, "..1"},
 		{1, 12, "0..10"},
 		{1, 12, "1..13"},
+		{1, 12, "1/0"},
+		{1, 12, "1/-1"},
+		{1, 12, "0/2"},
+		{1, 12, "1/"},
 	}
 
 	for _, testItem := range testData {
@@ -38,6 +38,10 @@ func init() {
 	// own commands have no profile section, prevent their definition
 	for _, command := range ownCommands.All() {
 		config.ExcludeProfileSection(command.name)
+		// except for the commands that can run on schedule
+		if command.schedulable {
+			config.AddOwnCommandSection(command.name)
+		}
 	}
 }
 
@@ -100,7 +104,7 @@ func getOwnCommands() []ownCommand {
 		{
 			name:              "schedule",
 			description:       "schedule jobs from a profile or group (or of all profiles and groups)",
-			longDescription:   "The \"schedule\" command registers declared schedules of the selected profile or group (or of all profiles and groups) as scheduled jobs within the scheduling service of the operating system",
+			longDescription:   "The \"schedule\" command registers declared schedules of the selected profile or group (or of all profiles and groups) as scheduled jobs within the scheduling service of the operating system.\n\nWith the \"--sync\" flag, the scheduled jobs are compared with the configuration: jobs no longer declared are removed, and missing or changed jobs are (re)created.\n\nWith the \"--dry-run\" flag, nothing is installed: the files that would be written are displayed as a diff against the files currently installed",
 			action:            createSchedule,
 			needConfiguration: true,
 			hide:              false,
@@ -109,6 +113,8 @@ func getOwnCommands() []ownCommand {
 				"--start":    "start the job after installing (systemd/launch only)",
 				"--reload":   "force a systemctl daemon-reload after setting up the files (systemd only, available since v0.32.0)",
 				"--all":      "add all scheduled jobs of all profiles and groups",
+				"--sync":     "compare the scheduled jobs with the configuration, then remove orphaned jobs, and create missing or changed jobs",
+				"--dry-run":  "display the unit files or crontab lines that would be installed, as a diff against the ones currently installed (systemd/crontab only)",
 			},
 		},
 		{
@@ -123,11 +129,43 @@ func getOwnCommands() []ownCommand {
 		{
 			name:              "status",
 			description:       "display the status of scheduled jobs of a profile or group (or of all profiles and groups)",
-			longDescription:   "The \"status\" command prints all declared schedules of the selected profile or group (or of all profiles and groups) and shows the status of related scheduled jobs in the scheduling service of the operating system",
+			longDescription:   "The \"status\" command prints all declared schedules of the selected profile or group (or of all profiles and groups) and shows the status of related scheduled jobs in the scheduling service of the operating system.\n\nWith the \"--drift\" flag, it only reports the scheduled jobs out of sync with the configuration, and exits with an error when there are any",
 			action:            statusSchedule,
 			needConfiguration: true,
 			hide:              false,
-			flags:             map[string]string{"--all": "display the status of all scheduled jobs of all profiles and groups"},
+			flags: map[string]string{
+				"--all":   "display the status of all scheduled jobs of all profiles and groups",
+				"--drift": "only display the differences between the scheduled jobs and the configuration (fails when a job is out of sync). The files of the jobs are only compared with systemd and crond",
+			},
+		},
+		{
+			name:              "calendar",
+			description:       "list the next runs of the scheduled jobs of a profile or group (or of all profiles and groups)",
+			longDescription:   "The \"calendar\" command lists the next runs of the schedules declared in the configuration, for the profile or group in argument, e.g. \"resticprofile calendar home\" (or for all profiles and groups when no argument is given), without installing anything.\n\nJobs using the same repository or lock file and starting within the \"--window\" duration of each other (1 hour by default) are flagged as overlapping, and a warning is displayed.",
+			action:            calendarCommand,
+			needConfiguration: true,
+			hide:              false,
+			noProfile:         true,
+			flags: map[string]string{
+				"--count N":         "number of runs to list (default 10)",
+				"--from date":       "list the runs from this date (\"2006-01-02\", \"2006-01-02 15:04\" or RFC 3339) instead of now",
+				"--json":            "display the runs in JSON format",
+				"--window duration": "jobs starting within this duration of each other may run at the same time (default 1h, 0 only flags jobs starting at the same time)",
+			},
+		},
+		{
+			name:              "locks",
+			description:       "display the lock files of all profiles (and remove stale locks)",
+			longDescription:   "The \"locks\" command lists the lock files of all profiles declared in the configuration, and of the repositories they use, with the user, host, process and command holding each lock. When \"max-concurrent-runs\" is set, it also lists who holds the slots of the concurrent runs. Locks of a process no longer running, or created on another host, are flagged as stale.\n\nStale locks are removed with the \"--remove\" flag. A lock is never removed when its process is still running on this host.",
+			action:            locksCommand,
+			needConfiguration: true,
+			hide:              false,
+			noProfile:         true,
+			schedulable:       true,
+			flags: map[string]string{
+				"--remove": "remove the stale locks (the process holding the lock is no longer running)",
+				"--force":  "with --remove: also remove the locks created on another host",
+			},
 		},
 		{
 			name:              "run-schedule",
@@ -141,6 +179,17 @@ func getOwnCommands() []ownCommand {
 			noProfile:         true,
 		},
 		// hidden commands
+		{
+			name:              "notify-failure",
+			description:       "sends the send-after-fail hooks of a failed scheduled job. This command should only be called by the scheduling service",
+			longDescription:   "The \"notify-failure\" command is started by the systemd \"OnFailure\" unit of a scheduled job. It sends the \"send-after-fail\" hooks of the profile (or of all the profiles of the group) with an excerpt of the journal of the failed run. The name in parameter is <command>@<profile-or-group-name>.",
+			pre:               preRunSchedule,
+			action:            notifyFailure,
+			needConfiguration: true,
+			hide:              true,
+			hideInCompletion:  true,
+			noProfile:         true,
+		},
 		{
 			name:              "complete",
 			description:       "create commandline completion results based on given args",
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/calendar"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/util/ansi"
+)
+
+const (
+	defaultCalendarCount  = 10
+	defaultCalendarWindow = time.Hour
+)
+
+// calendarRun is one upcoming run of a scheduled job
+type calendarRun struct {
+	Time     time.Time `json:"time"`
+	Profile  string    `json:"profile"`
+	Command  string    `json:"command"`
+	Schedule string    `json:"schedule"`
+	Overlaps []string  `json:"overlaps,omitempty"`
+
+	resources []string // repositories and lock files used by the job
+}
+
+// calendarOverlap is a pair of runs using the same repository or lock file, the second one starting within the window of the first one
+type calendarOverlap struct {
+	first, second calendarRun
+}
+
+func (r calendarRun) job() string {
+	return r.Command + "@" + r.Profile
+}
+
+// calendarFlags are the flags of the calendar command
+type calendarFlags struct {
+	name   string
+	count  int
+	from   time.Time
+	window time.Duration
+	json   bool
+}
+
+func parseCalendarFlags(args []string, now time.Time) (calendarFlags, error) {
+	flags := calendarFlags{count: defaultCalendarCount, from: now, window: defaultCalendarWindow}
+	for index := 0; index < len(args); index++ {
+		switch arg := args[index]; arg {
+		case "--json":
+			flags.json = true
+		case "--count", "--from", "--window":
+			if index+1 >= len(args) {
+				return flags, fmt.Errorf("missing value after %s", arg)
+			}
+			index++
+			var err error
+			switch arg {
+			case "--count":
+				flags.count, err = strconv.Atoi(args[index])
+				if err == nil && flags.count <= 0 {
+					err = fmt.Errorf("must be greater than zero")
+				}
+			case "--from":
+				flags.from, err = parseCalendarDate(args[index])
+			case "--window":
+				flags.window, err = time.ParseDuration(args[index])
+				if err == nil && flags.window < 0 {
+					err = fmt.Errorf("cannot be negative")
+				}
+			}
+			if err != nil {
+				return flags, fmt.Errorf("invalid value %q for %s: %w", args[index], arg, err)
+			}
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return flags, fmt.Errorf("unknown flag %q", arg)
+			}
+			if flags.name != "" {
+				return flags, fmt.Errorf("only one profile or group can be selected, found %q and %q", flags.name, arg)
+			}
+			flags.name = arg
+		}
+	}
+	return flags, nil
+}
+
+// parseCalendarDate parses a date and optional time in local time, or a date in RFC 3339 format
+func parseCalendarDate(input string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05"} {
+		if at, err := time.ParseInLocation(layout, input, time.Local); err == nil {
+			return at, nil
+		}
+	}
+	return time.Parse(time.RFC3339, input)
+}
+
+// calendarCommand lists the next runs of the scheduled jobs of a profile or group (or of all profiles and groups)
+func calendarCommand(ctx commandContext) error {
+	c := ctx.config
+	flags, err := parseCalendarFlags(ctx.request.arguments, time.Now())
+	if err != nil {
+		return err
+	}
+
+	defer c.DisplayConfigurationIssues()
+
+	runs, err := getCalendarRuns(c, flags)
+	if err != nil {
+		return err
+	}
+	overlaps := findOverlappingRuns(runs, flags.window)
+
+	if flags.json {
+		encoder := json.NewEncoder(ctx.terminal)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(runs)
+	}
+
+	if len(runs) == 0 {
+		ctx.terminal.Println("No scheduled run")
+		return nil
+	}
+	out, closer := displayWriter(ctx.terminal)
+	out("%s (from %s):\n", ansi.Bold(fmt.Sprintf("Next %d runs", len(runs))), flags.from.Format("Mon 2006-01-02 15:04 MST"))
+	for _, run := range runs {
+		// the last cell is always written, to keep the columns aligned
+		overlaps := ""
+		if len(run.Overlaps) > 0 {
+			overlaps = ansi.Yellow("overlaps " + strings.Join(run.Overlaps, ", "))
+		}
+		out("\t%s\t%s\t%s\t%s\n", run.Time.In(flags.from.Location()).Format("Mon 2006-01-02 15:04 MST"), run.job(), run.Schedule, overlaps)
+	}
+	out("\n")
+	closer()
+
+	for _, warning := range overlapWarnings(overlaps, flags.from.Location()) {
+		clog.Warning(warning)
+	}
+	return nil
+}
+
+// overlapWarnings returns one warning per pair of overlapping schedules, with the first time they overlap
+func overlapWarnings(overlaps []calendarOverlap, location *time.Location) []string {
+	type overlapKey struct {
+		first, firstSchedule, second, secondSchedule string
+		delay                                        time.Duration
+	}
+	keys := make([]overlapKey, 0, len(overlaps))
+	occurrences := make(map[overlapKey][]time.Time, len(overlaps))
+	for _, overlap := range overlaps {
+		first, second := overlap.first, overlap.second
+		if first.Time.Equal(second.Time) && second.job() < first.job() {
+			first, second = second, first
+		}
+		key := overlapKey{first.job(), first.Schedule, second.job(), second.Schedule, second.Time.Sub(first.Time)}
+		if _, found := occurrences[key]; !found {
+			keys = append(keys, key)
+		}
+		occurrences[key] = append(occurrences[key], first.Time)
+	}
+
+	const timeFormat = "Mon 2006-01-02 15:04"
+	warnings := make([]string, 0, len(keys))
+	for _, key := range keys {
+		times := occurrences[key]
+		when := times[0].In(location).Format(timeFormat)
+		if len(times) > 1 {
+			when += fmt.Sprintf(" and %d more times", len(times)-1)
+		}
+		if key.delay == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s and %s start at the same time (%s) using the same repository or lock",
+				key.first, key.second, when))
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s starts %s after %s (%s) which may still be running, using the same repository or lock",
+			key.second, key.delay, key.first, when))
+	}
+	return warnings
+}
+
+// getCalendarRuns returns the next runs of the scheduled jobs, sorted by time
+func getCalendarRuns(c *config.Config, flags calendarFlags) ([]calendarRun, error) {
+	names := []string{flags.name}
+	if flags.name == "" {
+		names = slices.Concat(c.GetProfileNames(), c.GetGroupNames())
+		slices.Sort(names)
+	}
+
+	runs := make([]calendarRun, 0, flags.count)
+	for _, name := range names {
+		_, jobs, _, err := getScheduleJobs(c, name)
+		if err == nil && flags.name != "" {
+			err = requireScheduleJobs(jobs, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range jobs {
+			origin := job.ScheduleOrigin()
+			resources := getScheduleResources(c, origin)
+			for _, schedule := range job.Schedules {
+				event := calendar.NewEvent()
+				if err = event.Parse(schedule); err != nil {
+					return nil, fmt.Errorf("%s: schedule %q: %w", origin, schedule, err)
+				}
+				next := flags.from
+				for range flags.count {
+					next = event.Next(next)
+					if next.IsZero() {
+						break
+					}
+					run := calendarRun{Time: next, Profile: origin.Name, Command: origin.Command, Schedule: schedule, resources: resources}
+					// the same job can be scheduled more than once at the same time
+					if !slices.ContainsFunc(runs, func(other calendarRun) bool { return other.Time.Equal(run.Time) && other.job() == run.job() }) {
+						runs = append(runs, run)
+					}
+					next = next.Add(time.Minute)
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(runs, func(a, b calendarRun) int {
+		if compare := a.Time.Compare(b.Time); compare != 0 {
+			return compare
+		}
+		return strings.Compare(a.job(), b.job())
+	})
+	if len(runs) > flags.count {
+		runs = runs[:flags.count]
+	}
+	return runs, nil
+}
+
+// getScheduleResources returns the repositories and lock files used by the profiles of a scheduled job
+func getScheduleResources(c *config.Config, origin config.ScheduleConfigOrigin) []string {
+	profileNames := []string{origin.Name}
+	if origin.Type == config.ScheduleOriginGroup {
+		if group, err := c.GetProfileGroup(origin.Name); err == nil {
+			profileNames = group.Profiles
+		}
+	}
+	resources := make([]string, 0, len(profileNames)*2)
+	for _, profileName := range profileNames {
+		profile, err := c.GetProfile(profileName)
+		if err != nil || profile == nil {
+			continue
+		}
+		if repository := profile.GetNormalizedRepository(); repository != "" {
+			resources = append(resources, "repository "+repository)
+		}
+		if profile.Lock != "" {
+			resources = append(resources, "lock "+profile.Lock)
+		}
+	}
+	return resources
+}
+
+// findOverlappingRuns flags the jobs using the same repository or lock file and starting at the same time, or less than
+// the window after each other (the duration of a run is unknown: the window is an estimate). The runs must be sorted by time.
+func findOverlappingRuns(runs []calendarRun, window time.Duration) []calendarOverlap {
+	var overlaps []calendarOverlap
+	for i := range runs {
+		for j := i + 1; j < len(runs) && (runs[j].Time.Equal(runs[i].Time) || runs[j].Time.Sub(runs[i].Time) < window); j++ {
+			if runs[i].job() == runs[j].job() {
+				continue
+			}
+			if slices.ContainsFunc(runs[i].resources, func(resource string) bool { return slices.Contains(runs[j].resources, resource) }) {
+				if !slices.Contains(runs[i].Overlaps, runs[j].job()) {
+					runs[i].Overlaps = append(runs[i].Overlaps, runs[j].job())
+				}
+				if !slices.Contains(runs[j].Overlaps, runs[i].job()) {
+					runs[j].Overlaps = append(runs[j].Overlaps, runs[i].job())
+				}
+				overlaps = append(overlaps, calendarOverlap{first: runs[i], second: runs[j]})
+			}
+		}
+	}
+	return overlaps
+}
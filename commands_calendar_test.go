@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCalendarFlags(t *testing.T) {
+	now := time.Now()
+
+	flags, err := parseCalendarFlags(nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, calendarFlags{count: defaultCalendarCount, from: now, window: defaultCalendarWindow}, flags)
+
+	flags, err = parseCalendarFlags([]string{"home", "--count", "3", "--from", "2024-03-01 10:30", "--window", "30m", "--json"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "home", flags.name)
+	assert.Equal(t, 3, flags.count)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 30, 0, 0, time.Local), flags.from)
+	assert.Equal(t, 30*time.Minute, flags.window)
+	assert.True(t, flags.json)
+
+	flags, err = parseCalendarFlags([]string{"--from", "2024-03-01T10:30:00Z"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC), flags.from)
+
+	for _, args := range [][]string{
+		{"--count"},
+		{"--count", "0"},
+		{"--count", "many"},
+		{"--from", "yesterday"},
+		{"--window", "long"},
+		{"--window", "-1h"},
+		{"--unknown"},
+		{"home", "work"},
+	} {
+		_, err = parseCalendarFlags(args, now)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestCalendarCommand(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString(`
+version: "1"
+home:
+  repository: "local:/backup/home"
+  backup:
+    schedule: "*-*-* 02:00"
+  check:
+    schedule: "Mon 02:00"
+work:
+  repository: "local:/backup/work"
+  backup:
+    schedule: "cron: 0 */6 * * *"
+other:
+  repository: "local:/backup/other"
+`), "yaml")
+	require.NoError(t, err)
+
+	run := func(args ...string) string {
+		buffer := &bytes.Buffer{}
+		err := calendarCommand(commandContext{
+			Context: Context{
+				config:   cfg,
+				terminal: term.NewTerminal(term.WithStdout(buffer)),
+				request:  Request{arguments: args},
+			},
+		})
+		require.NoError(t, err)
+		return buffer.String()
+	}
+
+	// 2024-01-01 is a monday
+	output := run("--from", "2024-01-01", "--count", "4", "--json")
+	runs := []calendarRun{}
+	require.NoError(t, json.Unmarshal([]byte(output), &runs))
+	require.Len(t, runs, 4)
+
+	expected := []struct {
+		at, job  string
+		overlaps []string
+	}{
+		{"2024-01-01 00:00", "backup@work", nil},
+		{"2024-01-01 02:00", "backup@home", []string{"check@home"}},
+		{"2024-01-01 02:00", "check@home", []string{"backup@home"}},
+		{"2024-01-01 06:00", "backup@work", nil},
+	}
+	for index, run := range runs {
+		assert.Equal(t, expected[index].at, run.Time.Local().Format("2006-01-02 15:04"))
+		assert.Equal(t, expected[index].job, run.Command+"@"+run.Profile)
+		assert.Equal(t, expected[index].overlaps, run.Overlaps)
+	}
+
+	output = run("home", "--from", "2024-01-02", "--count", "2")
+	assert.Contains(t, output, "Next 2 runs")
+	assert.Regexp(t, `Tue 2024-01-02 02:00 \S+\s+backup@home\s+\*-\*-\* 02:00`, output)
+	assert.Regexp(t, `Wed 2024-01-03 02:00 \S+\s+backup@home`, output)
+	assert.NotContains(t, output, "work")
+}
+
+func TestFindOverlappingRuns(t *testing.T) {
+	at := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	newRuns := func() []calendarRun {
+		return []calendarRun{
+			{Time: at, Profile: "home", Command: "backup", resources: []string{"repository local:/backup/home"}},
+			{Time: at, Profile: "home", Command: "check", resources: []string{"repository local:/backup/home"}},
+			{Time: at.Add(30 * time.Minute), Profile: "work", Command: "backup", resources: []string{"repository local:/backup/work"}},
+			{Time: at.Add(45 * time.Minute), Profile: "home", Command: "prune", resources: []string{"repository local:/backup/home"}},
+			{Time: at.Add(3 * time.Hour), Profile: "home", Command: "forget", resources: []string{"repository local:/backup/home"}},
+		}
+	}
+
+	testCases := []struct {
+		window   time.Duration
+		overlaps [][]string
+		pairs    int
+	}{
+		{
+			window:   0,
+			overlaps: [][]string{{"check@home"}, {"backup@home"}, nil, nil, nil},
+			pairs:    1,
+		},
+		{
+			// runs exactly the window apart don't overlap
+			window:   45 * time.Minute,
+			overlaps: [][]string{{"check@home"}, {"backup@home"}, nil, nil, nil},
+			pairs:    1,
+		},
+		{
+			window:   time.Hour,
+			overlaps: [][]string{{"check@home", "prune@home"}, {"backup@home", "prune@home"}, nil, {"backup@home", "check@home"}, nil},
+			pairs:    3,
+		},
+		{
+			window:   3 * time.Hour,
+			overlaps: [][]string{{"check@home", "prune@home"}, {"backup@home", "prune@home"}, nil, {"backup@home", "check@home", "forget@home"}, {"prune@home"}},
+			pairs:    4,
+		},
+		{
+			window:   4 * time.Hour,
+			overlaps: [][]string{{"check@home", "prune@home", "forget@home"}, {"backup@home", "prune@home", "forget@home"}, nil, {"backup@home", "check@home", "forget@home"}, {"backup@home", "check@home", "prune@home"}},
+			pairs:    6,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.window.String(), func(t *testing.T) {
+			runs := newRuns()
+			overlaps := findOverlappingRuns(runs, testCase.window)
+			assert.Len(t, overlaps, testCase.pairs)
+			for index, run := range runs {
+				assert.Equal(t, testCase.overlaps[index], run.Overlaps, run.job())
+			}
+		})
+	}
+}
+
+func TestOverlapWarnings(t *testing.T) {
+	at := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	runs := make([]calendarRun, 0, 12)
+	for day := range 4 {
+		start := at.AddDate(0, 0, day)
+		runs = append(runs,
+			calendarRun{Time: start, Profile: "home", Command: "check", Schedule: "*-*-* 02:00", resources: []string{"repository local:/backup/home"}},
+			calendarRun{Time: start, Profile: "home", Command: "backup", Schedule: "*-*-* 02:00", resources: []string{"repository local:/backup/home"}},
+			calendarRun{Time: start.Add(30 * time.Minute), Profile: "home", Command: "prune", Schedule: "*-*-* 02:30", resources: []string{"repository local:/backup/home"}},
+		)
+	}
+	runs = append(runs, calendarRun{Time: at.AddDate(0, 0, 4), Profile: "home", Command: "backup", Schedule: "*-*-* 02:00", resources: []string{"repository local:/backup/home"}})
+
+	warnings := overlapWarnings(findOverlappingRuns(runs, time.Hour), time.UTC)
+	assert.Equal(t, []string{
+		"backup@home and check@home start at the same time (Mon 2024-01-01 02:00 and 3 more times) using the same repository or lock",
+		"prune@home starts 30m0s after check@home (Mon 2024-01-01 02:00 and 3 more times) which may still be running, using the same repository or lock",
+		"prune@home starts 30m0s after backup@home (Mon 2024-01-01 02:00 and 3 more times) which may still be running, using the same repository or lock",
+	}, warnings)
+}
+
+func TestCalendarCommandWithoutSchedule(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString(`
+version: "1"
+other:
+  repository: "local:/backup/other"
+`), "yaml")
+	require.NoError(t, err)
+
+	ctx := commandContext{
+		Context: Context{
+			config:   cfg,
+			terminal: term.NewTerminal(),
+			request:  Request{arguments: []string{"other"}},
+		},
+	}
+	assert.Error(t, calendarCommand(ctx))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/hook"
+)
+
+const journalExcerptLines = "100"
+
+// readJournal returns the journal entries of a systemd unit invocation (can be replaced in tests)
+var readJournal = func(invocationID string) (string, error) {
+	if invocationID == "" {
+		return "", nil
+	}
+	output, err := exec.Command("journalctl", "--no-pager", "--lines", journalExcerptLines, "_SYSTEMD_INVOCATION_ID="+invocationID).Output()
+	return string(output), err
+}
+
+// notifyFailure sends the "send-after-fail" hooks of a scheduled job that failed.
+// It's started by the systemd "OnFailure" unit, with the details of the failed unit in the MONITOR_* environment variables.
+func notifyFailure(ctx commandContext) error {
+	profileNames := []string{ctx.request.profile}
+	if !ctx.config.HasProfile(ctx.request.profile) {
+		group, err := ctx.config.GetProfileGroup(ctx.request.profile)
+		if err != nil || group == nil {
+			return fmt.Errorf("cannot load group '%s': %w", ctx.request.profile, err)
+		}
+		profileNames = group.Profiles
+	}
+
+	journal, err := readJournal(os.Getenv(constants.EnvMonitorInvocationID))
+	if err != nil {
+		clog.Warningf("cannot read the journal of the failed unit: %s", err)
+	}
+	errorContext := hook.ErrorContext{
+		Message:  failureMessage(ctx.request.schedule),
+		ExitCode: os.Getenv(constants.EnvMonitorExitStatus),
+	}
+	sender := hook.NewSender(
+		ctx.global.CACertificates,
+		"resticprofile/"+version,
+		ctx.global.SenderTimeout,
+		ctx.flags.dryRun,
+	)
+
+	for _, profileName := range profileNames {
+		profile, err := ctx.config.GetProfile(profileName)
+		if err != nil || profile == nil {
+			return fmt.Errorf("cannot load profile '%s': %w", profileName, err)
+		}
+		setLogContext(profile.Name, ctx.request.group, ctx.command)
+		if ctx.scheduleEntry != nil {
+			profile.AddEnvironment(ctx.scheduleEntry.CommandEnv)
+		}
+		sendFailure(sender, profile, ctx.command, hook.Context{
+			ProfileName:    profile.Name,
+			ProfileCommand: ctx.command,
+			Error:          errorContext,
+			Journal:        journal,
+		})
+	}
+	return nil
+}
+
+func sendFailure(sender *hook.Sender, profile *config.Profile, command string, context hook.Context) {
+	sections := profile.GetMonitoringSections(command).SendAfterFail
+	if len(sections) == 0 {
+		clog.Debugf("no \"send-after-fail\" defined for %s in profile '%s'", command, profile.Name)
+		return
+	}
+	for i, section := range sections {
+		clog.Debugf("starting \"send-after-fail\" from %s %d/%d", command, i+1, len(sections))
+		err := sender.Send(section, context, profile.GetEnvironment(true))
+		if err != nil {
+			clog.Warningf("\"send-after-fail\" returned an error: %s", err.Error())
+		}
+	}
+}
+
+// failureMessage describes the failure from the variables set by systemd
+func failureMessage(scheduleName string) string {
+	unit := os.Getenv(constants.EnvMonitorUnit)
+	if unit == "" {
+		unit = scheduleName
+	}
+	details := make([]string, 0, 3)
+	for _, name := range []string{constants.EnvMonitorServiceResult, constants.EnvMonitorExitCode, constants.EnvMonitorExitStatus} {
+		if value := os.Getenv(name); value != "" {
+			details = append(details, value)
+		}
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("scheduled job %q failed", unit)
+	}
+	return fmt.Sprintf("scheduled job %q failed: %s", unit, strings.Join(details, ", "))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureMessage(t *testing.T) {
+	t.Setenv(constants.EnvMonitorUnit, "")
+	t.Setenv(constants.EnvMonitorServiceResult, "")
+	t.Setenv(constants.EnvMonitorExitCode, "")
+	t.Setenv(constants.EnvMonitorExitStatus, "")
+	assert.Equal(t, `scheduled job "backup@default" failed`, failureMessage("backup@default"))
+
+	t.Setenv(constants.EnvMonitorUnit, "resticprofile-backup@profile-default.service")
+	t.Setenv(constants.EnvMonitorServiceResult, "exit-code")
+	t.Setenv(constants.EnvMonitorExitCode, "exited")
+	t.Setenv(constants.EnvMonitorExitStatus, "1")
+	assert.Equal(t, `scheduled job "resticprofile-backup@profile-default.service" failed: exit-code, exited, 1`, failureMessage("backup@default"))
+}
+
+func TestNotifyFailure(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies[r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+
+	template := filepath.Join(t.TempDir(), "body.txt")
+	require.NoError(t, os.WriteFile(template, []byte("{{ .ProfileName }} {{ .ProfileCommand }}: {{ .Error.Message }} ({{ .Error.ExitCode }})\n{{ .Journal }}"), 0o600))
+
+	cfg, err := config.Load(bytes.NewBufferString(fmt.Sprintf(`
+version = "2"
+
+[groups.all]
+profiles = ["first", "second"]
+
+[profiles.first.backup]
+send-after-fail = { url = "%[1]s/first", body-template = %[2]q }
+
+[profiles.second.backup]
+send-after-fail = { url = "%[1]s/second", body-template = %[2]q }
+
+[profiles.second.check]
+send-after-fail = { url = "%[1]s/check", body-template = %[2]q }
+`, server.URL, filepath.ToSlash(template))), "toml")
+	require.NoError(t, err)
+
+	defaultReadJournal := readJournal
+	readJournal = func(invocationID string) (string, error) {
+		return "journal of " + invocationID, nil
+	}
+	defer func() { readJournal = defaultReadJournal }()
+
+	t.Setenv(constants.EnvMonitorUnit, "resticprofile-backup@profile-all.service")
+	t.Setenv(constants.EnvMonitorServiceResult, "exit-code")
+	t.Setenv(constants.EnvMonitorExitCode, "exited")
+	t.Setenv(constants.EnvMonitorExitStatus, "3")
+	t.Setenv(constants.EnvMonitorInvocationID, "abcdef")
+
+	ctx := &Context{
+		request: Request{command: "notify-failure", arguments: []string{"backup@all"}},
+		config:  cfg,
+		global:  &config.Global{SenderTimeout: time.Second},
+	}
+	require.NoError(t, preRunSchedule(ctx))
+	require.NoError(t, notifyFailure(commandContext{Context: *ctx}))
+
+	message := `"resticprofile-backup@profile-all.service" failed: exit-code, exited, 3 (3)`
+	assert.Equal(t, map[string]string{
+		"/first":  "first backup: scheduled job " + message + "\njournal of abcdef",
+		"/second": "second backup: scheduled job " + message + "\njournal of abcdef",
+	}, bodies)
+}
+
+func TestHasFailureUnit(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString(`
+version = "2"
+
+[groups.stop]
+profiles = ["first"]
+schedules = { backup = { at = "daily", systemd-on-failure = true } }
+
+[groups.continue]
+profiles = ["first"]
+continue-on-error = true
+schedules = { backup = { at = "daily", systemd-on-failure = true } }
+
+[profiles.first.backup]
+schedule = "daily"
+schedule-systemd-on-failure = true
+
+[profiles.second.backup]
+schedule = "daily"
+`), "toml")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		schedule     string
+		invocationID string
+		expected     bool
+	}{
+		{schedule: "backup@first", invocationID: "abcdef", expected: true},
+		{schedule: "backup@first", invocationID: ""},
+		{schedule: "backup@second", invocationID: "abcdef"},
+		{schedule: "backup@stop", invocationID: "abcdef", expected: true},
+		{schedule: "backup@continue", invocationID: "abcdef"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.schedule+testCase.invocationID, func(t *testing.T) {
+			t.Setenv(constants.EnvInvocationID, testCase.invocationID)
+			ctx := &Context{
+				request: Request{command: "run-schedule", arguments: []string{testCase.schedule}},
+				config:  cfg,
+				global:  config.NewGlobal(),
+			}
+			require.NoError(t, preRunSchedule(ctx))
+			require.NotNil(t, ctx.schedule)
+			assert.Equal(t, testCase.expected, ctx.failureUnit)
+		})
+	}
+}
+
+func TestSendAfterFailWithFailureUnit(t *testing.T) {
+	sections := []config.SendMonitoringSection{{URL: config.NewConfidentialValue("http://localhost:0/")}}
+	for _, failureUnit := range []bool{false, true} {
+		t.Run(fmt.Sprintf("%v", failureUnit), func(t *testing.T) {
+			ctx := &Context{
+				profile:     config.NewProfile(nil, "name"),
+				global:      &config.Global{SenderTimeout: time.Second},
+				terminal:    term.NewTerminal(),
+				failureUnit: failureUnit,
+			}
+			recorder := &hookRecorder{}
+			wrapper := newResticWrapper(ctx)
+			wrapper.addProgress(recorder)
+			wrapper.sendAfterFail(config.SendMonitoringSections{SendAfterFail: sections}, "backup", errors.New("failed"))
+			if failureUnit {
+				assert.Empty(t, recorder.hooks)
+			} else {
+				require.Len(t, recorder.hooks, 1)
+				assert.Equal(t, "send-after-fail", recorder.hooks[0].Type)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/lock"
+	"github.com/creativeprojects/resticprofile/util/ansi"
+)
+
+// profileLock is the lock file of a profile, a repository, or a slot counting the concurrent runs
+type profileLock struct {
+	name     string
+	kind     string // "profile", "repository" or "slot"
+	filename string
+	info     *lock.Info // nil when the lock file doesn't exist
+	stale    string     // reason why the lock looks abandoned
+	err      error
+}
+
+// getProfileLocks returns the lock files of all profiles declared in the configuration
+func getProfileLocks(ctx commandContext) []profileLock {
+	names := ctx.config.GetProfileNames()
+	sort.Strings(names)
+	locks := make([]profileLock, 0, len(names))
+	for _, name := range names {
+		profile, err := ctx.config.GetProfile(name)
+		if err != nil {
+			locks = append(locks, profileLock{name: name, kind: "profile", err: err})
+			continue
+		}
+		if profile.Lock == "" {
+			continue
+		}
+		locks = append(locks, readProfileLock(profileLock{name: name, kind: "profile", filename: profile.Lock}))
+	}
+	return locks
+}
+
+// getSlotLocks returns the lock files of the slots counting the concurrent runs, when "max-concurrent-runs" is set
+func getSlotLocks(ctx commandContext) []profileLock {
+	if ctx.global == nil || ctx.global.MaxConcurrentRuns <= 0 {
+		return nil
+	}
+	semaphore := lock.NewSemaphore(ctx.global.GetConcurrentRunsDir(), ctx.global.MaxConcurrentRuns)
+	locks := make([]profileLock, 0, semaphore.Slots)
+	for slot := 0; slot < semaphore.Slots; slot++ {
+		locks = append(locks, readProfileLock(profileLock{name: fmt.Sprintf("slot %d", slot), kind: "slot", filename: semaphore.SlotFile(slot)}))
+	}
+	return locks
+}
+
+// getRepositoryLocks returns the lock files of the repositories used by the profiles
+func getRepositoryLocks(ctx commandContext) []profileLock {
+	if ctx.global == nil {
+		return nil
+	}
+	names := ctx.config.GetProfileNames()
+	sort.Strings(names)
+	locks := make([]profileLock, 0, len(names))
+	for _, name := range names {
+		profile, err := ctx.config.GetProfile(name)
+		if err != nil {
+			continue
+		}
+		filename := profile.GetRepositoryLockFile(ctx.global.GetRepositoryLockDir())
+		if filename == "" || slices.ContainsFunc(locks, func(entry profileLock) bool { return entry.filename == filename }) {
+			continue
+		}
+		locks = append(locks, readProfileLock(profileLock{name: profile.GetNormalizedRepository(), kind: "repository", filename: filename}))
+	}
+	return locks
+}
+
+func readProfileLock(entry profileLock) profileLock {
+	var err error
+	entry.info, err = lock.ReadInfo(entry.filename)
+	if err == nil {
+		entry.stale = entry.info.StaleReason()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		entry.err = err
+	}
+	return entry
+}
+
+// subject describes the lock in log messages
+func (l profileLock) subject() string {
+	if l.kind == "slot" {
+		return "concurrent runs " + l.name
+	}
+	return fmt.Sprintf("%s %q", l.kind, l.name)
+}
+
+// locksCommand lists the lock files of all profiles, and removes stale locks with "--remove"
+func locksCommand(ctx commandContext) error {
+	args := ctx.request.arguments
+	remove := slices.Contains(args, "--remove")
+	otherHost := slices.Contains(args, "--force")
+
+	defer ctx.config.DisplayConfigurationIssues()
+
+	locks := getProfileLocks(ctx)
+	repositories := getRepositoryLocks(ctx)
+	slots := getSlotLocks(ctx)
+	if len(locks) == 0 && len(repositories) == 0 && len(slots) == 0 {
+		ctx.terminal.Println("No profile is using a lock file")
+		return nil
+	}
+
+	out, closer := displayWriter(ctx.terminal)
+	if len(locks) > 0 {
+		out("%s (profile, state, lock file, owner):\n", ansi.Bold("Profile locks"))
+		for _, entry := range locks {
+			out("\t%s:\t%s\t%s\t%s\n", entry.name, lockState(entry), entry.filename, lockOwner(entry.info))
+		}
+		out("\n")
+	}
+	if len(repositories) > 0 {
+		out("%s (repository, state, lock file, owner):\n", ansi.Bold("Repository locks"))
+		for _, entry := range repositories {
+			out("\t%s:\t%s\t%s\t%s\n", entry.name, lockState(entry), entry.filename, lockOwner(entry.info))
+		}
+		out("\n")
+	}
+	if len(slots) > 0 {
+		out("%s (max-concurrent-runs = %d):\n", ansi.Bold("Concurrent runs"), len(slots))
+		for _, entry := range slots {
+			out("\t%s:\t%s\t%s\t%s\n", entry.name, lockState(entry), entry.filename, lockOwner(entry.info))
+		}
+		out("\n")
+	}
+	closer()
+
+	if !remove {
+		return nil
+	}
+	var errs []error
+	for _, entry := range slices.Concat(locks, repositories, slots) {
+		if entry.info == nil || entry.stale == "" {
+			continue
+		}
+		reason, err := lock.RemoveIfStale(entry.filename, otherHost)
+		if err != nil {
+			if entry.info.IsOtherHost() && !otherHost {
+				clog.Warningf("%s: lock %s was created on another host, use --force to remove it", entry.subject(), entry.filename)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s: cannot remove lock %s: %w", entry.subject(), entry.filename, err))
+			continue
+		}
+		clog.Infof("%s: removed stale lock %s (%s)", entry.subject(), entry.filename, reason)
+	}
+	return errors.Join(errs...)
+}
+
+func lockState(entry profileLock) string {
+	switch {
+	case entry.err != nil:
+		return ansi.Red(fmt.Sprintf("error: %s", entry.err))
+	case entry.info == nil:
+		return "unlocked"
+	case entry.stale != "":
+		return ansi.Yellow(fmt.Sprintf("stale: %s", entry.stale))
+	default:
+		return ansi.Green("locked")
+	}
+}
+
+func lockOwner(info *lock.Info) string {
+	if info == nil {
+		return ""
+	}
+	owner := info.Who()
+	if info.Command != "" {
+		owner = fmt.Sprintf("%s running %q", owner, info.Command)
+	}
+	if info.PID > 0 {
+		owner += fmt.Sprintf(" (pid %d", info.PID)
+		if len(info.ChildPIDs) > 0 {
+			owner += fmt.Sprintf(", restic pid %d", info.ChildPIDs[len(info.ChildPIDs)-1])
+		}
+		owner += fmt.Sprintf(", for %s)", time.Since(info.Started).Truncate(time.Second))
+	}
+	return owner
+}
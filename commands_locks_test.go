@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/lock"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocksCommand(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+	cfg, err := config.Load(bytes.NewBufferString(fmt.Sprintf(`
+[free]
+lock = "%[1]s/free.lock"
+[nolock]
+[running]
+lock = "%[1]s/running.lock"
+[stale]
+lock = "%[1]s/stale.lock"
+`, dir)), "toml")
+	require.NoError(t, err)
+
+	running := lock.NewLock(filepath.Join(dir, "running.lock")).WithOwner("running", "backup")
+	require.True(t, running.TryAcquire())
+	defer running.Release()
+
+	// PID of a process that is no longer running
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	staleLock := filepath.Join(dir, "stale.lock")
+	require.NoError(t, os.WriteFile(staleLock, fmt.Appendf(nil, `{"user":"user","host":%q,"pid":%d,"command":"check"}`, hostname, cmd.Process.Pid), 0o600))
+
+	run := func(args ...string) string {
+		buffer := &bytes.Buffer{}
+		err := locksCommand(commandContext{
+			Context: Context{
+				config:   cfg,
+				terminal: term.NewTerminal(term.WithStdout(buffer)),
+				request:  Request{arguments: args},
+			},
+		})
+		assert.NoError(t, err)
+		return buffer.String()
+	}
+
+	output := run()
+	assert.Regexp(t, `free:\s+unlocked\s+`+regexp.QuoteMeta(dir)+`/free.lock`, output)
+	assert.NotContains(t, output, "nolock")
+	assert.Regexp(t, `running:\s+locked\s+`+regexp.QuoteMeta(dir)+`/running.lock\s+.+ running "backup" \(pid \d+`, output)
+	assert.Regexp(t, `stale:\s+stale: process is not running\s+`+regexp.QuoteMeta(dir)+`/stale.lock`, output)
+	assert.FileExists(t, staleLock)
+
+	run("--remove")
+	assert.NoFileExists(t, staleLock)
+	assert.FileExists(t, filepath.Join(dir, "running.lock"))
+}
+
+func TestLocksCommandWithRepositoriesAndConcurrentRuns(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+	cfg, err := config.Load(bytes.NewBufferString(`
+[first]
+repository = "rest:http://server:8000/repo"
+[second]
+repository = "rest:http://Server:8000/repo/"
+[third]
+`), "toml")
+	require.NoError(t, err)
+	global := config.NewGlobal()
+	global.MaxConcurrentRuns = 2
+	global.ConcurrentRunsDir = dir
+	global.RepositoryLockDir = dir
+
+	slot, err := lock.NewSemaphore(dir, 2).WithOwner("running", "backup").TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, slot)
+	defer slot.Release()
+
+	buffer := &bytes.Buffer{}
+	err = locksCommand(commandContext{
+		Context: Context{
+			config:   cfg,
+			global:   global,
+			terminal: term.NewTerminal(term.WithStdout(buffer)),
+		},
+	})
+	require.NoError(t, err)
+	output := buffer.String()
+	assert.NotContains(t, output, "Profile locks")
+	assert.Regexp(t, `Repository locks.+\n\s+rest:http://server:8000/repo:\s+unlocked\s+`+regexp.QuoteMeta(dir)+`/repository-[0-9a-f]+\.lock\s*\n\n`, output)
+	assert.Contains(t, output, "max-concurrent-runs = 2")
+	assert.Regexp(t, `slot 0:\s+locked\s+`+regexp.QuoteMeta(dir)+`/slot-0.lock\s+.+ running "backup" \(pid \d+`, output)
+	assert.Regexp(t, `slot 1:\s+unlocked\s+`+regexp.QuoteMeta(dir)+`/slot-1.lock`, output)
+}
+
+func TestScheduledLocksCommand(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+	cfg, err := config.Load(bytes.NewBufferString(fmt.Sprintf(`
+[maintenance]
+lock = "%[1]s/maintenance.lock"
+[maintenance.locks]
+remove = true
+schedule = "daily"
+[stale]
+lock = "%[1]s/stale.lock"
+`, dir)), "toml")
+	require.NoError(t, err)
+
+	// PID of a process that is no longer running
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	staleLock := filepath.Join(dir, "stale.lock")
+	require.NoError(t, os.WriteFile(staleLock, fmt.Appendf(nil, `{"user":"user","host":%q,"pid":%d,"command":"check"}`, hostname, cmd.Process.Pid), 0o600))
+
+	buffer := &bytes.Buffer{}
+	ctx := commandContext{
+		Context: Context{
+			config:   cfg,
+			global:   config.NewGlobal(),
+			terminal: term.NewTerminal(term.WithStdout(buffer)),
+			request:  Request{arguments: []string{"locks@maintenance"}},
+		},
+	}
+	require.NoError(t, preRunSchedule(&ctx.Context))
+	require.NotNil(t, ctx.schedule)
+	require.NoError(t, runSchedule(ctx))
+
+	// the command runs with the lock of its profile, and the flags of its section
+	assert.Regexp(t, `maintenance:\s+locked\s+`+regexp.QuoteMeta(dir)+`/maintenance.lock\s+.+ running "locks"`, buffer.String())
+	assert.NoFileExists(t, staleLock)
+	assert.NoFileExists(t, filepath.Join(dir, "maintenance.lock"))
+}
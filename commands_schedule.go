@@ -3,16 +3,24 @@ package main
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"maps"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/calendar"
 	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/envscanner"
+	"github.com/creativeprojects/resticprofile/lock"
 	"github.com/creativeprojects/resticprofile/schedule"
+	"github.com/creativeprojects/resticprofile/shell"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -24,62 +32,65 @@ const (
 // createSchedule command
 func createSchedule(ctx commandContext) error {
 	c := ctx.config
-	request := ctx.request
 	args := ctx.request.arguments
 
 	defer c.DisplayConfigurationIssues()
 
-	type profileJobs struct {
-		schedulerConfig schedule.SchedulerConfig
-		name            string
-		jobs            []*config.Schedule
+	// Step 1: Collect all jobs of all selected profiles
+	allJobs, err := collectScheduleJobs(ctx)
+	if err != nil {
+		return err
 	}
 
-	allJobs := make([]profileJobs, 0, 1)
+	if ctx.flags.dryRun || slices.Contains(args, "--dry-run") {
+		// display what would be installed, without touching the scheduler
+		return previewJobs(allJobs, ctx.terminal.Stdout())
+	}
 
-	// Step 1: Collect all jobs of all selected profiles
+	if slices.Contains(args, "--sync") {
+		return syncSchedules(ctx, allJobs)
+	}
+
+	// Step 2: Schedule all collected jobs
+	for _, j := range allJobs {
+		err := scheduleJobs(schedule.NewHandler(j.schedulerConfig), j.jobs)
+		if err != nil {
+			return retryElevated(err, ctx.flags)
+		}
+	}
+
+	return nil
+}
+
+type profileJobs struct {
+	schedulerConfig schedule.SchedulerConfig
+	name            string
+	jobs            []*config.Schedule
+}
+
+// collectScheduleJobs returns the jobs of all the selected profiles and groups, ready to be scheduled
+func collectScheduleJobs(ctx commandContext) ([]profileJobs, error) {
+	c := ctx.config
+	request := ctx.request
+	args := ctx.request.arguments
+
+	allJobs := make([]profileJobs, 0, 1)
 	for _, profileName := range selectProfilesAndGroups(c, request.profile, args) {
-		scheduler, jobs, _, err := getScheduleJobs(c, profileName)
+		scheduler, jobs, schedulable, err := getScheduleJobs(c, profileName)
 		if err == nil {
 			err = requireScheduleJobs(jobs, profileName)
 
 			// Skip profile with no schedules when "--all" option is set.
-			if err != nil && slices.Contains(args, "--all") {
+			// A profile with no schedule may have orphaned jobs to remove when "--sync" option is set.
+			if err != nil && (slices.Contains(args, "--all") || slices.Contains(args, "--sync")) {
 				continue
 			}
 		}
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		// Automatically scan the profile's configuration for any ".Env.VAR" references.
-		// This allows users to reference environment variables in their profiles and have them
-		// automatically injected into the scheduled job's environment without extra configuration.
-		varsToCapture, err := envscanner.ScanForEnvVariables(c.GetConfigFile(), profileName)
-		if err != nil {
-			// If scanning fails (e.g., file not found, invalid YAML), we log a warning but
-			// do not block the scheduling process. The schedule will be created without the
-			// automatically detected variables.
-			clog.Warningf("could not scan for environment variables in profile %s: %v", profileName, err)
-		}
-
-		if len(varsToCapture) > 0 {
-			clog.Infof("capturing environment variables for %s: %v", profileName, varsToCapture)
-			// Create a list of "KEY=VALUE" strings for the variables found.
-			capturedEnv := make([]string, 0, len(varsToCapture))
-			for _, varName := range varsToCapture {
-				// Look up the value of each variable from the current process's environment.
-				if value, ok := os.LookupEnv(varName); ok {
-					capturedEnv = append(capturedEnv, fmt.Sprintf("%s=%s", varName, value))
-				}
-			}
-
-			// Inject the captured environment variables into every job associated with this profile.
-			// This ensures that when the scheduled job runs, it has the necessary environment.
-			for id := range jobs {
-				jobs[id].Environment = append(jobs[id].Environment, capturedEnv...)
-			}
-		}
+		captureEnvironment(c.GetConfigFile(), profileName, jobs)
 
 		// add the no-start flag to all the jobs
 		if slices.Contains(args, "--no-start") {
@@ -93,18 +104,43 @@ func createSchedule(ctx commandContext) error {
 			}
 		}
 
+		warnSandboxPaths(c, scheduler, schedulable, jobs)
+
 		allJobs = append(allJobs, profileJobs{schedulerConfig: scheduler, name: profileName, jobs: jobs})
 	}
+	return allJobs, nil
+}
 
-	// Step 2: Schedule all collected jobs
-	for _, j := range allJobs {
-		err := scheduleJobs(schedule.NewHandler(j.schedulerConfig), j.jobs)
-		if err != nil {
-			return retryElevated(err, ctx.flags)
-		}
+// captureEnvironment adds the environment variables referenced in the configuration of the profile to the environment of the jobs
+func captureEnvironment(configFile, profileName string, jobs []*config.Schedule) {
+	// Automatically scan the profile's configuration for any ".Env.VAR" references.
+	// This allows users to reference environment variables in their profiles and have them
+	// automatically injected into the scheduled job's environment without extra configuration.
+	varsToCapture, err := envscanner.ScanForEnvVariables(configFile, profileName)
+	if err != nil {
+		// If scanning fails (e.g., file not found, invalid YAML), we log a warning but
+		// do not block the scheduling process. The schedule will be created without the
+		// automatically detected variables.
+		clog.Warningf("could not scan for environment variables in profile %s: %v", profileName, err)
 	}
 
-	return nil
+	if len(varsToCapture) > 0 {
+		clog.Infof("capturing environment variables for %s: %v", profileName, varsToCapture)
+		// Create a list of "KEY=VALUE" strings for the variables found.
+		capturedEnv := make([]string, 0, len(varsToCapture))
+		for _, varName := range varsToCapture {
+			// Look up the value of each variable from the current process's environment.
+			if value, ok := os.LookupEnv(varName); ok {
+				capturedEnv = append(capturedEnv, fmt.Sprintf("%s=%s", varName, value))
+			}
+		}
+
+		// Inject the captured environment variables into every job associated with this profile.
+		// This ensures that when the scheduled job runs, it has the necessary environment.
+		for id := range jobs {
+			jobs[id].Environment = append(jobs[id].Environment, capturedEnv...)
+		}
+	}
 }
 
 // removeSchedule command
@@ -145,6 +181,10 @@ func statusSchedule(ctx commandContext) error {
 
 	defer c.DisplayConfigurationIssues()
 
+	if slices.Contains(args, "--drift") {
+		return driftSchedule(ctx)
+	}
+
 	if slices.Contains(args, "--legacy") {
 		clog.Warning(legacyFlagWarning)
 		// single profile or group
@@ -304,7 +344,7 @@ func getRemovableScheduleJobs(c *config.Config, profileName string) (schedule.Sc
 
 func preRunSchedule(ctx *Context) error {
 	if len(ctx.request.arguments) < 1 {
-		return errors.New("run-schedule command expects one argument: schedule name")
+		return fmt.Errorf("%s command expects one argument: schedule name", ctx.request.command)
 	}
 	scheduleName := ctx.request.arguments[0]
 	commandName, profileName, ok := strings.Cut(scheduleName, "@")
@@ -313,7 +353,8 @@ func preRunSchedule(ctx *Context) error {
 	}
 	ctx.request.profile = profileName
 	ctx.request.schedule = scheduleName
-	ctx.command = commandName
+	// a named schedule is "command.name"
+	ctx.command, _, _ = strings.Cut(commandName, constants.ScheduleNameSeparator)
 	// remove the parameter from the arguments
 	ctx.request.arguments = ctx.request.arguments[1:]
 
@@ -335,19 +376,37 @@ func preRunSchedule(ctx *Context) error {
 		return fmt.Errorf("profile or group %q: %w", profileName, config.ErrNotFound)
 	}
 	// get the list of all scheduled commands to find the current command
-	if ctx.schedule, ok = schedulable.Schedules()[ctx.command]; ok {
+	if ctx.schedule, ok = schedulable.Schedules()[commandName]; ok {
+		ctx.scheduleEntry = ctx.schedule
 		clog.Debugf("preparing scheduled %s %q", schedulable.Kind(), ctx.request.schedule)
 		prepareScheduledProfile(ctx)
+		ctx.failureUnit = hasFailureUnit(ctx, schedulable)
 	}
 	return nil
 }
 
+// hasFailureUnit returns true when the run is started by a systemd unit notifying its failure with the OnFailure unit:
+// a failure of the run always fails the unit, except in a group continuing on error
+func hasFailureUnit(ctx *Context, schedulable config.Schedulable) bool {
+	if !ctx.schedule.SystemdOnFailure.IsTrue() || os.Getenv(constants.EnvInvocationID) == "" {
+		return false
+	}
+	if group, ok := schedulable.(*config.Group); ok {
+		continueOnError := group.ContinueOnError.IsTrue() || (ctx.global != nil && ctx.global.GroupContinueOnError && group.ContinueOnError.IsUndefined())
+		return !continueOnError
+	}
+	return true
+}
+
 func prepareScheduledProfile(ctx *Context) {
 	s := ctx.schedule
 	// log file
 	if len(s.Log) > 0 {
 		ctx.logTarget = s.Log
 	}
+	if len(s.LogFormat) > 0 {
+		ctx.logFormat = s.LogFormat
+	}
 	if len(s.CommandOutput) > 0 {
 		ctx.commandOutput = s.CommandOutput
 	}
@@ -368,13 +427,157 @@ func prepareScheduledProfile(ctx *Context) {
 }
 
 func runSchedule(cmdCtx commandContext) error {
-	err := startProfileOrGroup(&cmdCtx.Context, runProfile)
+	if cmdCtx.flags.catchUp {
+		missed, err := catchUpScheduledRun(&cmdCtx.Context, time.Now())
+		if err != nil || !missed {
+			return err
+		}
+	}
+	// record the run before the random delay, so the catch-up entry doesn't start it a second time
+	recordScheduledRun(&cmdCtx.Context, time.Now())
+	err := delayScheduledRun(&cmdCtx.Context)
+	if err != nil {
+		return err
+	}
+	if command := ownCommands.find(cmdCtx.command); command != nil && command.schedulable {
+		return runScheduledOwnCommand(&cmdCtx.Context, command)
+	}
+	err = startProfileOrGroup(&cmdCtx.Context, runProfile)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// delayScheduledRun waits for a random delay before starting the schedule, when "randomized-delay" is set
+// and the scheduler hasn't already delayed the run (systemd)
+func delayScheduledRun(ctx *Context) error {
+	if ctx.schedule == nil || ctx.flags.noRandomDelay {
+		return nil
+	}
+	hostname, _ := os.Hostname()
+	delay := ctx.schedule.GetStartDelay(hostname)
+	if delay <= 0 {
+		return nil
+	}
+	clog.Infof("delaying the start of %q by %s", ctx.request.schedule, delay.Round(time.Second))
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGABRT)
+	defer signal.Stop(sigChan)
+	return interruptibleSleep(delay, sigChan)
+}
+
+// runScheduledOwnCommand runs a resticprofile command on schedule, with the flags of its section in the profile.
+// The command runs with the lock of the profile, the same as a restic command.
+func runScheduledOwnCommand(ctx *Context, command *ownCommand) error {
+	var profile *config.Profile
+	flags := shell.NewArgs()
+	if ctx.config.HasProfile(ctx.request.profile) {
+		var err error
+		profile, err = ctx.config.GetProfile(ctx.request.profile)
+		if err != nil || profile == nil {
+			return fmt.Errorf("cannot load profile '%s': %w", ctx.request.profile, err)
+		}
+		flags = profile.GetOwnCommandFlags(command.name)
+		setLogContext(profile.Name, "", command.name)
+	} else {
+		setLogContext("", ctx.request.profile, command.name)
+	}
+	if ctx.scheduleEntry != nil {
+		ctx.scheduleEntry.AddCommandFlags(flags)
+	}
+	ctx.request.arguments = append(flags.GetAll(), ctx.request.arguments...)
+	clog.Debugf("running scheduled command %q with arguments %q", command.name, ctx.request.arguments)
+
+	run := func(_ lock.SetPID) error {
+		return command.action(commandContext{ownCommands: ownCommands, Context: *ctx})
+	}
+	if profile == nil || ctx.noLock {
+		return run(nil)
+	}
+	var lockWait *time.Duration
+	if ctx.lockWait > 0 {
+		lockWait = &ctx.lockWait
+	}
+	return lockRun(profile.Lock, profile.Name, command.name, false, profile.ForceLock, lockWait, ctx.sigChan, run)
+}
+
+// scheduleStateFile returns the file keeping the time of the last run of the schedule, or an empty string
+// when the schedule doesn't catch up missed runs
+func scheduleStateFile(ctx *Context) string {
+	if ctx.schedule == nil || !ctx.schedule.CatchUp.IsTrue() || ctx.global == nil {
+		return ""
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(ctx.schedule.GetId()))
+	return filepath.Join(ctx.global.GetScheduleStateDir(), fmt.Sprintf("%s.%016x.last", ctx.request.schedule, hash.Sum64()))
+}
+
+// recordScheduledRun saves the start time of the schedule when it catches up missed runs
+func recordScheduledRun(ctx *Context, start time.Time) {
+	filename := scheduleStateFile(ctx)
+	if filename == "" {
+		return
+	}
+	err := os.MkdirAll(filepath.Dir(filename), 0o700)
+	if err == nil {
+		err = os.WriteFile(filename, []byte(start.Format(time.RFC3339)+"\n"), 0o600)
+	}
+	if err != nil {
+		clog.Warningf("cannot save the time of the run of %q: %s", ctx.request.schedule, err)
+	}
+}
+
+// catchUpScheduledRun returns true when a run of the schedule was missed since the last recorded run.
+// When no run was ever recorded, the current time is saved as the reference for the next checks.
+func catchUpScheduledRun(ctx *Context, now time.Time) (bool, error) {
+	filename := scheduleStateFile(ctx)
+	if filename == "" {
+		clog.Debugf("schedule %q doesn't catch up missed runs", ctx.request.schedule)
+		return false, nil
+	}
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		clog.Debugf("no previous run of %q recorded yet", ctx.request.schedule)
+		recordScheduledRun(ctx, now)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot read the time of the last run of %q: %w", ctx.request.schedule, err)
+	}
+	lastRun, err := time.Parse(time.RFC3339, strings.TrimSpace(string(content)))
+	if err != nil {
+		return false, fmt.Errorf("invalid time of the last run of %q in %q: %w", ctx.request.schedule, filename, err)
+	}
+	missed, err := missedScheduledRun(ctx.schedule.Schedules, lastRun, now)
+	if err != nil {
+		return false, err
+	}
+	if missed {
+		clog.Infof("catching up the missed run of %q (last run %s)", ctx.request.schedule, lastRun.Local().Format(time.DateTime))
+	} else {
+		clog.Debugf("no missed run of %q since %s", ctx.request.schedule, lastRun.Local().Format(time.DateTime))
+	}
+	return missed, nil
+}
+
+// missedScheduledRun returns true when one of the schedules was due between the last run and one minute ago:
+// the last minute is left to the scheduled run, which may be just starting.
+func missedScheduledRun(schedules []string, lastRun, now time.Time) (bool, error) {
+	for _, schedule := range schedules {
+		event := calendar.NewEvent()
+		if err := event.Parse(schedule); err != nil {
+			return false, fmt.Errorf("schedule %q: %w", schedule, err)
+		}
+		next := event.Next(lastRun.Truncate(time.Minute).Add(time.Minute))
+		if !next.IsZero() && next.Before(now.Add(-time.Minute)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func getAbsoluteConfigFile(configFile string) string {
 	if configFile == "" {
 		return ""
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/schedule"
+	"github.com/creativeprojects/resticprofile/util"
+	"github.com/creativeprojects/resticprofile/util/ansi"
+)
+
+// getScheduleDrift compares the jobs declared in the configuration with the jobs installed in the scheduler.
+// Only the installed jobs of the current configuration file and of the selected profiles and groups are compared.
+func getScheduleDrift(ctx commandContext, handler schedule.Handler, declared []*config.Schedule) ([]schedule.Drift, error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	binary, err := util.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	all := slices.Contains(ctx.request.arguments, "--all")
+	profileName := ctx.request.profile
+	if all {
+		profileName = ""
+	}
+	installed, err := handler.Scheduled(profileName)
+	if err != nil {
+		if len(installed) == 0 {
+			return nil, err
+		}
+		clog.Errorf("some configurations failed to load:\n%v", err)
+	}
+
+	configFile := getAbsoluteConfigFile(ctx.config.GetConfigFile())
+	selected := selectProfilesAndGroups(ctx.config, ctx.request.profile, ctx.request.arguments)
+	installed = slices.DeleteFunc(installed, func(cfg schedule.Config) bool {
+		if cfg.ConfigFile != configFile {
+			clog.Debugf("skipping job %s/%s from configuration file %s", cfg.ProfileName, cfg.CommandName, cfg.ConfigFile)
+			return true
+		}
+		// some schedulers return the jobs of all the profiles
+		return !all && !slices.ContainsFunc(selected, func(name string) bool { return strings.EqualFold(name, cfg.ProfileName) })
+	})
+
+	jobs := make([]*schedule.Config, 0, len(declared))
+	for _, cfg := range declared {
+		if cfg.HasSchedules() {
+			jobs = append(jobs, newScheduleConfig(cfg, currentDir, binary))
+		}
+	}
+	drifts := schedule.CompareJobs(jobs, installed)
+	return schedule.CompareJobFiles(handler, jobs, installed, drifts), nil
+}
+
+// displayScheduleDrift prints the differences between the scheduled jobs and the configuration
+func displayScheduleDrift(ctx commandContext, drifts []schedule.Drift) {
+	out, closer := displayWriter(ctx.terminal)
+	defer closer()
+
+	if len(drifts) == 0 {
+		out("scheduled jobs are in sync with the configuration\n")
+		return
+	}
+	out("%s\n", ansi.Bold("Schedule drift"))
+	for _, drift := range drifts {
+		switch drift.State {
+		case schedule.DriftMissing:
+			out("  %s\t%s/%s\t%s\n", ansi.Green("+"), drift.ProfileName, drift.CommandName, drift.State)
+		case schedule.DriftOrphaned:
+			out("  %s\t%s/%s\t%s\n", ansi.Red("-"), drift.ProfileName, drift.CommandName, drift.State)
+		default:
+			out("  %s\t%s/%s\t%s: %s\n", ansi.Yellow("~"), drift.ProfileName, drift.CommandName, drift.State, strings.Join(drift.Changes, ", "))
+		}
+	}
+	out("\n")
+}
+
+// syncSchedules removes the orphaned jobs, and creates the missing or changed jobs
+func syncSchedules(ctx commandContext, allJobs []profileJobs) error {
+	declared := make([]*config.Schedule, 0, len(allJobs))
+	for _, j := range allJobs {
+		declared = append(declared, j.jobs...)
+	}
+
+	schedulerConfig := schedule.NewSchedulerConfig(ctx.global)
+	handler := schedule.NewHandler(schedulerConfig)
+	if err := handler.Init(); err != nil {
+		return retryElevated(err, ctx.flags)
+	}
+	drifts, err := getScheduleDrift(ctx, handler, declared)
+	if err != nil {
+		handler.Close()
+		return retryElevated(err, ctx.flags)
+	}
+	displayScheduleDrift(ctx, drifts)
+
+	var errs error
+	toCreate := make([]*config.Schedule, 0, len(drifts))
+	for _, drift := range drifts {
+		if drift.Installed != nil {
+			err := schedule.NewJob(handler, drift.Installed).Remove()
+			if err != nil && !errors.Is(err, schedule.ErrScheduledJobNotFound) {
+				errs = errors.Join(errs, fmt.Errorf("error removing job %s/%s: %w", drift.ProfileName, drift.CommandName, err))
+				continue
+			}
+			clog.Infof("scheduled job %s/%s removed", drift.ProfileName, drift.CommandName)
+		}
+		if drift.Declared != nil {
+			index := slices.IndexFunc(declared, func(cfg *config.Schedule) bool {
+				origin := cfg.ScheduleOrigin()
+				return origin.Name == drift.Declared.ProfileName && origin.Command == drift.Declared.CommandName
+			})
+			if index >= 0 {
+				toCreate = append(toCreate, declared[index])
+			}
+		}
+	}
+	handler.Close()
+
+	if len(toCreate) > 0 {
+		errs = errors.Join(errs, scheduleJobs(schedule.NewHandler(schedulerConfig), toCreate))
+	}
+	return retryElevated(errs, ctx.flags)
+}
+
+// driftSchedule displays the differences between the scheduled jobs and the configuration.
+// It returns an error when the scheduled jobs are out of sync.
+func driftSchedule(ctx commandContext) error {
+	declared := make([]*config.Schedule, 0)
+	for _, profileName := range selectProfilesAndGroups(ctx.config, ctx.request.profile, ctx.request.arguments) {
+		_, jobs, _, err := getScheduleJobs(ctx.config, profileName)
+		if err != nil {
+			return err
+		}
+		// same environment as the jobs created by the schedule command
+		captureEnvironment(ctx.config.GetConfigFile(), profileName, jobs)
+		declared = append(declared, jobs...)
+	}
+
+	handler := schedule.NewHandler(schedule.NewSchedulerConfig(ctx.global))
+	if err := handler.Init(); err != nil {
+		return retryElevated(err, ctx.flags)
+	}
+	defer handler.Close()
+
+	drifts, err := getScheduleDrift(ctx, handler, declared)
+	if err != nil {
+		return retryElevated(err, ctx.flags)
+	}
+	displayScheduleDrift(ctx, drifts)
+	if len(drifts) > 0 {
+		return fmt.Errorf("%d scheduled job(s) out of sync with the configuration, run \"resticprofile schedule --sync\" to fix them", len(drifts))
+	}
+	return nil
+}
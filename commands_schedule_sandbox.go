@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/schedule"
+)
+
+var (
+	// sandboxReadOnlyPaths are read-only with "systemd-hardening" (ProtectSystem=full and ProtectHome=read-only)
+	sandboxReadOnlyPaths = []string{"/usr", "/boot", "/efi", "/etc", "/home", "/root", "/run/user"}
+	// sandboxPrivatePaths are private to the unit with "systemd-hardening" (PrivateTmp=true)
+	sandboxPrivatePaths = []string{"/tmp", "/var/tmp"}
+)
+
+// sandboxPath is a file or a directory written by a scheduled run
+type sandboxPath struct {
+	name, path string
+	shared     bool // the path must be shared with the other runs
+}
+
+// warnSandboxPaths displays a warning for each path written by the scheduled jobs which won't work
+// inside the sandbox of "systemd-hardening"
+func warnSandboxPaths(c *config.Config, scheduler schedule.SchedulerConfig, schedulable config.Schedulable, jobs []*config.Schedule) {
+	if !usesSystemd(scheduler) {
+		return
+	}
+	var profiles []*config.Profile
+	switch s := schedulable.(type) {
+	case *config.Profile:
+		profiles = append(profiles, s)
+	case *config.Group:
+		for _, name := range s.Profiles {
+			if profile, err := c.GetProfile(name); err == nil {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	global, err := c.GetGlobalSection()
+	if err != nil {
+		return
+	}
+	for _, job := range jobs {
+		if !job.SystemdHardening.IsTrue() {
+			continue
+		}
+		for _, path := range scheduledRunPaths(global, profiles, job) {
+			if warning := sandboxWarning(path, job.SystemdReadWritePaths); warning != "" {
+				clog.Warningf("schedule %s: %s", job.GetId(), warning)
+			}
+		}
+	}
+}
+
+func usesSystemd(scheduler schedule.SchedulerConfig) bool {
+	return scheduler.Type() == constants.SchedulerSystemd ||
+		(scheduler.Type() == constants.SchedulerOSDefault && !platform.IsWindows() && !platform.IsDarwin())
+}
+
+// scheduledRunPaths returns the files and directories written by a scheduled run of the profiles
+func scheduledRunPaths(global *config.Global, profiles []*config.Profile, job *config.Schedule) []sandboxPath {
+	paths := []sandboxPath{{name: "log", path: job.Log}}
+	if job.CatchUp.IsTrue() {
+		paths = append(paths, sandboxPath{name: "schedule state directory", path: global.GetScheduleStateDir()})
+	}
+	if global.MaxConcurrentRuns > 0 {
+		paths = append(paths, sandboxPath{name: "concurrent runs lock directory", path: global.GetConcurrentRunsDir(), shared: true})
+	}
+	for _, profile := range profiles {
+		paths = append(paths,
+			sandboxPath{name: "lock file", path: profile.Lock, shared: true},
+			sandboxPath{name: "status file", path: profile.StatusFile},
+		)
+		if lockFile := profile.GetRepositoryLockFile(global.GetRepositoryLockDir()); lockFile != "" {
+			paths = append(paths, sandboxPath{name: "repository lock file", path: lockFile, shared: true})
+		}
+	}
+	return paths
+}
+
+// sandboxWarning returns a warning when the path is not writable or not shared inside the sandbox
+func sandboxWarning(path sandboxPath, readWritePaths []string) string {
+	if path.path == "" || !filepath.IsAbs(path.path) {
+		return "" // not a file (syslog) or not configured
+	}
+	filename := filepath.ToSlash(filepath.Clean(path.path))
+	// "ReadWritePaths" cannot share a path of the private temporary directory
+	if path.shared && slices.ContainsFunc(sandboxPrivatePaths, func(dir string) bool { return isPathIn(filename, dir) }) {
+		return fmt.Sprintf("%s %q is in the private temporary directory of systemd-hardening: it is not shared with the other processes", path.name, path.path)
+	}
+	if slices.ContainsFunc(readWritePaths, func(dir string) bool { return isPathIn(filename, dir) }) {
+		return ""
+	}
+	if slices.ContainsFunc(sandboxReadOnlyPaths, func(dir string) bool { return isPathIn(filename, dir) }) {
+		return fmt.Sprintf("%s %q is read-only with systemd-hardening: add it to systemd-read-write-paths", path.name, path.path)
+	}
+	return ""
+}
+
+// isPathIn returns true when filename is dir or inside dir
+func isPathIn(filename, dir string) bool {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	return filename == dir || strings.HasPrefix(filename, strings.TrimSuffix(dir, "/")+"/")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/util/maybe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxWarning(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("systemd sandbox is only available on linux")
+	}
+	readWritePaths := []string{"/home/user/logs/", "/tmp/shared"}
+	testCases := []struct {
+		path     sandboxPath
+		expected string
+	}{
+		{path: sandboxPath{name: "log", path: ""}},
+		{path: sandboxPath{name: "log", path: "syslog://localhost"}},
+		{path: sandboxPath{name: "log", path: "/var/log/resticprofile.log"}},
+		{path: sandboxPath{name: "log", path: "/home/user/logs/backup.log"}},
+		{path: sandboxPath{name: "log", path: "/home/user/logs"}},
+		{path: sandboxPath{name: "log", path: "/tmp/backup.log"}},
+		{
+			path:     sandboxPath{name: "log", path: "/home/user/logs-backup.log"},
+			expected: `log "/home/user/logs-backup.log" is read-only with systemd-hardening: add it to systemd-read-write-paths`,
+		},
+		{
+			path:     sandboxPath{name: "status file", path: "/root/status.json"},
+			expected: `status file "/root/status.json" is read-only with systemd-hardening: add it to systemd-read-write-paths`,
+		},
+		{
+			path:     sandboxPath{name: "lock file", path: "/tmp/shared/profile.lock", shared: true},
+			expected: `lock file "/tmp/shared/profile.lock" is in the private temporary directory of systemd-hardening: it is not shared with the other processes`,
+		},
+		{
+			path:     sandboxPath{name: "lock file", path: "/var/tmp/profile.lock", shared: true},
+			expected: `lock file "/var/tmp/profile.lock" is in the private temporary directory of systemd-hardening: it is not shared with the other processes`,
+		},
+		{path: sandboxPath{name: "lock file", path: "/run/lock/profile.lock", shared: true}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.path.path, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, sandboxWarning(testCase.path, readWritePaths))
+		})
+	}
+}
+
+func TestScheduledRunPaths(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("systemd sandbox is only available on linux")
+	}
+	cfg, err := config.Load(bytes.NewBufferString(`
+version = "2"
+
+[global]
+max-concurrent-runs = 2
+concurrent-runs-lock-dir = "/run/lock/runs"
+repository-lock-dir = "/run/lock/repositories"
+
+[profiles.first]
+lock = "/tmp/first.lock"
+status-file = "/home/user/status.json"
+repository = "local:/backup"
+repository-lock = true
+
+[profiles.second]
+`), "toml")
+	require.NoError(t, err)
+	global, err := cfg.GetGlobalSection()
+	require.NoError(t, err)
+	first, err := cfg.GetProfile("first")
+	require.NoError(t, err)
+	second, err := cfg.GetProfile("second")
+	require.NoError(t, err)
+
+	job := &config.Schedule{}
+	job.Log = "/var/log/backup.log"
+	job.CatchUp = maybe.False()
+	paths := scheduledRunPaths(global, []*config.Profile{first, second}, job)
+	assert.Equal(t, []sandboxPath{
+		{name: "log", path: "/var/log/backup.log"},
+		{name: "concurrent runs lock directory", path: "/run/lock/runs", shared: true},
+		{name: "lock file", path: "/tmp/first.lock", shared: true},
+		{name: "status file", path: "/home/user/status.json"},
+		{name: "repository lock file", path: first.GetRepositoryLockFile("/run/lock/repositories"), shared: true},
+		{name: "lock file", shared: true},
+		{name: "status file"},
+	}, paths)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/creativeprojects/clog"
@@ -287,6 +288,152 @@ func TestCreateScheduleOverwriteExistingIntegrationUsingCrontab(t *testing.T) {
 	t.Log(output.String())
 }
 
+func TestScheduleDryRunIntegrationUsingCrontab(t *testing.T) {
+	const scheduleIntegrationTestsCrontab = `
+### this content was generated by resticprofile, please leave this line intact ###
+10,40 * * * *	user	cd /workdir && /home/resticprofile --no-ansi --config %s run-schedule backup@profile-schedule-struct
+### end of resticprofile content, please leave this line intact ###
+`
+	crontab := filepath.Join(t.TempDir(), "crontab")
+	original := fmt.Sprintf(scheduleIntegrationTestsCrontab, getAbsoluteConfigFile("config.yaml"))
+	err := os.WriteFile(crontab, []byte(original), 0o600)
+	require.NoError(t, err)
+	clog.SetTestLog(t)
+	defer clog.CloseTestLog()
+
+	cfg, err := config.Load(
+		bytes.NewBufferString(fmt.Sprintf(scheduleIntegrationTestsConfiguration, crontab)),
+		config.FormatYAML,
+		config.WithConfigFile("config.yaml"),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+
+	global, err := cfg.GetGlobalSection()
+	require.NoError(t, err)
+	require.NotNil(t, global)
+
+	ctx := commandContext{
+		Context: Context{
+			config: cfg,
+			global: global,
+			request: Request{
+				profile:   "profile-schedule-struct",
+				arguments: []string{"--dry-run"},
+			},
+		},
+	}
+	output := &bytes.Buffer{}
+	terminal := term.Set(term.NewTerminal(term.WithStdout(output)))
+	ctx.terminal = terminal
+	defer term.Set(nil)
+
+	err = createSchedule(ctx)
+	require.NoError(t, err)
+
+	assert.Contains(t, output.String(), "--- "+crontab+"\n+++ "+crontab+"\n")
+	assert.Contains(t, output.String(), "\n-10,40 * * * *\tuser\tcd /workdir && /home/resticprofile")
+	assert.Contains(t, output.String(), "\n+20,50 * * * *\t")
+	t.Log(output.String())
+
+	// the crontab is left untouched
+	result, err := os.ReadFile(crontab)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(result))
+
+	// all the jobs are displayed in a single diff of the crontab
+	output.Reset()
+	ctx.request.arguments = []string{"--dry-run", "--all"}
+	err = createSchedule(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, strings.Count(output.String(), "+++ "+crontab+"\n"))
+	assert.Contains(t, output.String(), "\n-10,40 * * * *\tuser\tcd /workdir && /home/resticprofile")
+	assert.Contains(t, output.String(), "\n+00,30 * * * *\t")
+	assert.Contains(t, output.String(), "run-schedule backup@profile-schedule-inline\n")
+	assert.Contains(t, output.String(), "\n+20,50 * * * *\t")
+	assert.Contains(t, output.String(), "run-schedule backup@profile-schedule-struct\n")
+	t.Log(output.String())
+
+	result, err = os.ReadFile(crontab)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(result))
+}
+
+func TestScheduleDriftAndSyncIntegrationUsingCrontab(t *testing.T) {
+	const scheduleIntegrationTestsCrontab = `
+### this content was generated by resticprofile, please leave this line intact ###
+10,40 * * * *	user	cd /workdir && /home/resticprofile --no-ansi --config %s run-schedule backup@profile
+10,40 * * * *	user	cd /workdir && /home/resticprofile --no-ansi --config %s run-schedule backup@profile-schedule-struct
+11,41 * * * *	user	different-script.sh
+### end of resticprofile content, please leave this line intact ###
+`
+	crontab := filepath.Join(t.TempDir(), "crontab")
+	err := os.WriteFile(crontab, []byte(fmt.Sprintf(scheduleIntegrationTestsCrontab, getAbsoluteConfigFile("config.yaml"), getAbsoluteConfigFile("config.yaml"))), 0o600)
+	require.NoError(t, err)
+	clog.SetTestLog(t)
+	defer clog.CloseTestLog()
+
+	cfg, err := config.Load(
+		bytes.NewBufferString(fmt.Sprintf(scheduleIntegrationTestsConfiguration, crontab)),
+		config.FormatYAML,
+		config.WithConfigFile("config.yaml"),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+
+	global, err := cfg.GetGlobalSection()
+	require.NoError(t, err)
+	require.NotNil(t, global)
+
+	ctx := commandContext{
+		Context: Context{
+			config: cfg,
+			global: global,
+			request: Request{
+				arguments: []string{"--all", "--drift"},
+			},
+		},
+	}
+	output := &bytes.Buffer{}
+	terminal := term.Set(term.NewTerminal(term.WithStdout(output)))
+	ctx.terminal = terminal
+	defer term.Set(nil)
+
+	// report only
+	err = statusSchedule(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, output.String(), "profile-schedule-inline/backup  missing")
+	assert.Contains(t, output.String(), "profile/backup                  orphaned")
+	assert.Contains(t, output.String(), `profile-schedule-struct/backup  changed: schedules ["*-*-* *:10,40:00"] -> ["*-*-* *:20,50:00"]`)
+	t.Log(output.String())
+
+	result, err := os.ReadFile(crontab)
+	require.NoError(t, err)
+	assert.Contains(t, string(result), "run-schedule backup@profile\n")
+
+	// reconcile
+	output.Reset()
+	ctx.request.arguments = []string{"--all", "--sync"}
+	err = createSchedule(ctx)
+	require.NoError(t, err)
+
+	result, err = os.ReadFile(crontab)
+	require.NoError(t, err)
+	assert.NotContains(t, string(result), "run-schedule backup@profile\n")
+	assert.NotContains(t, string(result), "10,40 * * * *")
+	assert.Contains(t, string(result), "00,30 * * * *")
+	assert.Contains(t, string(result), "20,50 * * * *")
+	assert.Contains(t, string(result), "different-script.sh")
+	t.Log(string(result))
+
+	output.Reset()
+	ctx.request.arguments = []string{"--all", "--drift"}
+	err = statusSchedule(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), "scheduled jobs are in sync with the configuration")
+}
+
 const scheduleWithEnvVarConfiguration = `
 version: "2"
 
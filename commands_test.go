@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/batt"
 	"github.com/creativeprojects/resticprofile/config"
@@ -14,6 +16,7 @@ import (
 	"github.com/creativeprojects/resticprofile/schedule"
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/creativeprojects/resticprofile/util/collect"
+	"github.com/creativeprojects/resticprofile/util/maybe"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -433,6 +436,157 @@ func TestPreRunScheduleProfileUnknown(t *testing.T) {
 	assert.ErrorIs(t, err, config.ErrNotFound)
 }
 
+func TestDelayScheduledRun(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString(`
+[default.backup]
+schedule = "daily"
+schedule-randomized-delay = "1s"
+`), "toml")
+	require.NoError(t, err)
+
+	ctx := &Context{
+		request: Request{arguments: []string{"backup@default"}},
+		config:  cfg,
+	}
+	require.NoError(t, preRunSchedule(ctx))
+	require.NotNil(t, ctx.schedule)
+	assert.Equal(t, time.Second, ctx.schedule.GetRandomizedDelay())
+
+	start := time.Now()
+	assert.NoError(t, delayScheduledRun(ctx))
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// already delayed by the scheduler
+	ctx.schedule.RandomizedDelay = maybe.SetDuration(time.Hour)
+	ctx.flags.noRandomDelay = true
+	start = time.Now()
+	assert.NoError(t, delayScheduledRun(ctx))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestPreRunNamedSchedule(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString(`
+[default.check]
+schedule = [
+  { at = "daily" },
+  { name = "weekly", at = "weekly", flags = { read-data-subset = "10%" } },
+]
+`), "toml")
+	require.NoError(t, err)
+
+	ctx := &Context{
+		request: Request{arguments: []string{"check.weekly@default"}},
+		config:  cfg,
+	}
+	require.NoError(t, preRunSchedule(ctx))
+	assert.Equal(t, "check", ctx.command)
+	assert.Equal(t, "check.weekly@default", ctx.request.schedule)
+	require.NotNil(t, ctx.schedule)
+	assert.Equal(t, []string{"weekly"}, ctx.schedule.Schedules)
+	assert.Same(t, ctx.schedule, ctx.scheduleEntry)
+
+	// the entry is kept for the profiles of a group
+	profileCtx := ctx.WithProfile("default")
+	assert.Nil(t, profileCtx.schedule)
+	assert.Same(t, ctx.scheduleEntry, profileCtx.scheduleEntry)
+
+	ctx = &Context{
+		request: Request{arguments: []string{"check@default"}},
+		config:  cfg,
+	}
+	require.NoError(t, preRunSchedule(ctx))
+	assert.Equal(t, "check", ctx.command)
+	require.NotNil(t, ctx.schedule)
+	assert.Equal(t, []string{"daily"}, ctx.schedule.Schedules)
+}
+
+func TestMissedScheduledRun(t *testing.T) {
+	lastRun := time.Date(2024, 1, 1, 2, 0, 10, 0, time.Local)
+	testData := []struct {
+		schedules []string
+		now       time.Time
+		missed    bool
+	}{
+		{[]string{"*-*-* 02:00"}, time.Date(2024, 1, 1, 23, 0, 0, 0, time.Local), false},
+		{[]string{"*-*-* 02:00"}, time.Date(2024, 1, 2, 2, 0, 30, 0, time.Local), false},
+		{[]string{"*-*-* 02:00"}, time.Date(2024, 1, 2, 2, 30, 0, 0, time.Local), true},
+		{[]string{"*-*-* 02:00"}, time.Date(2024, 1, 5, 10, 0, 0, 0, time.Local), true},
+		{[]string{"Mon 02:00", "*-*-* 22:00"}, time.Date(2024, 1, 1, 22, 30, 0, 0, time.Local), true},
+		{[]string{"cron: 0 */6 * * *"}, time.Date(2024, 1, 1, 5, 59, 0, 0, time.Local), false},
+		{[]string{"cron: 0 */6 * * *"}, time.Date(2024, 1, 1, 6, 30, 0, 0, time.Local), true},
+	}
+	for _, testItem := range testData {
+		missed, err := missedScheduledRun(testItem.schedules, lastRun, testItem.now)
+		require.NoError(t, err)
+		assert.Equal(t, testItem.missed, missed, "%v at %s", testItem.schedules, testItem.now)
+	}
+
+	_, err := missedScheduledRun([]string{"invalid"}, lastRun, time.Now())
+	assert.Error(t, err)
+}
+
+func TestCatchUpScheduledRun(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString(`
+[default.backup]
+schedule = "*-*-* 02:00"
+schedule-catch-up = true
+
+[default.check]
+schedule = "*-*-* 03:00"
+`), "toml")
+	require.NoError(t, err)
+
+	newContext := func(scheduleName string) *Context {
+		ctx := &Context{
+			request: Request{arguments: []string{scheduleName}},
+			config:  cfg,
+			global:  &config.Global{ScheduleStateDir: t.TempDir()},
+		}
+		require.NoError(t, preRunSchedule(ctx))
+		require.NotNil(t, ctx.schedule)
+		return ctx
+	}
+
+	t.Run("without catch-up", func(t *testing.T) {
+		ctx := newContext("check@default")
+		assert.Empty(t, scheduleStateFile(ctx))
+		missed, err := catchUpScheduledRun(ctx, time.Now())
+		require.NoError(t, err)
+		assert.False(t, missed)
+	})
+
+	t.Run("with catch-up", func(t *testing.T) {
+		ctx := newContext("backup@default")
+		filename := scheduleStateFile(ctx)
+		assert.NotEmpty(t, filename)
+		assert.NoFileExists(t, filename)
+
+		// the first check only records the current time
+		start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+		missed, err := catchUpScheduledRun(ctx, start)
+		require.NoError(t, err)
+		assert.False(t, missed)
+		assert.FileExists(t, filename)
+
+		missed, err = catchUpScheduledRun(ctx, start.Add(12*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, missed)
+
+		missed, err = catchUpScheduledRun(ctx, start.Add(15*time.Hour))
+		require.NoError(t, err)
+		assert.True(t, missed)
+
+		recordScheduledRun(ctx, start.Add(15*time.Hour))
+		missed, err = catchUpScheduledRun(ctx, start.Add(16*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, missed)
+
+		require.NoError(t, os.WriteFile(filename, []byte("yesterday"), 0o600))
+		_, err = catchUpScheduledRun(ctx, start)
+		assert.Error(t, err)
+	})
+}
+
 func TestRunScheduleNoScheduleName(t *testing.T) {
 	// loads an (almost) empty config
 	cfg, err := config.Load(bytes.NewBufferString("[default]"), "toml")
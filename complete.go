@@ -117,6 +117,9 @@ func (c *Completer) completeFlagSetValue(flag *pflag.Flag, word string) (complet
 	case "theme":
 		list = []string{"dark", "light", "none"}
 
+	case "log-format":
+		list = []string{"text", "json"}
+
 	case "config":
 		fallthrough
 	case "log":
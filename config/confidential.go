@@ -122,6 +122,14 @@ func ProcessConfidentialValues(profile *Profile) {
 	// Handle the repo URL
 	profile.Repository.hideSubmatches(urlConfidentialPart)
 
+	// Handle MQTT broker
+	profile.MqttBroker.hideSubmatches(urlConfidentialPart)
+	profile.MqttPassword.hideValue()
+
+	// Handle InfluxDB
+	profile.InfluxDBWrite.hideSubmatches(urlConfidentialPart)
+	profile.InfluxDBToken.hideValue()
+
 	// Handle env variables
 	ProcessConfidentialEnvironment(profile.Environment)
 
@@ -162,6 +170,12 @@ func getAllConfidentialValues(profile *Profile) []*ConfidentialValue {
 		// Repository
 		confidentials = append(confidentials, &profile.Repository)
 
+		// MQTT
+		confidentials = append(confidentials, &profile.MqttBroker, &profile.MqttPassword)
+
+		// InfluxDB
+		confidentials = append(confidentials, &profile.InfluxDBWrite, &profile.InfluxDBToken)
+
 		// Env
 		for _, value := range profile.Environment {
 			confidentials = append(confidentials, &value)
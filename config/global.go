@@ -1,9 +1,13 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/platform"
 	"github.com/creativeprojects/resticprofile/util/maybe"
 )
 
@@ -22,11 +26,24 @@ type Global struct {
 	FilterResticFlags    bool                `mapstructure:"restic-arguments-filter" default:"true" description:"Remove unknown flags instead of passing all configured flags to restic"`
 	ResticLockRetryAfter time.Duration       `mapstructure:"restic-lock-retry-after" default:"1m" description:"Time to wait before trying to get a lock on a restic repository - see https://creativeprojects.github.io/resticprofile/usage/locks/"`
 	ResticStaleLockAge   time.Duration       `mapstructure:"restic-stale-lock-age" default:"1h" description:"The age an unused lock on a restic repository must have at least before resticprofile attempts to unlock - see https://creativeprojects.github.io/resticprofile/usage/locks/"`
+	MaxConcurrentRuns    int                 `mapstructure:"max-concurrent-runs" default:"0" description:"Maximum number of profiles running at the same time on this host, across all resticprofile processes of the same user, or sharing the \"concurrent-runs-lock-dir\" (0 is unlimited) - see https://creativeprojects.github.io/resticprofile/usage/locks/"`
+	ConcurrentRunsWait   time.Duration       `mapstructure:"concurrent-runs-wait" examples:"30m;1h;6h" description:"Maximum time to wait for a free slot when \"max-concurrent-runs\" is reached (wait indefinitely if not set)"`
+	ConcurrentRunsDir    string              `mapstructure:"concurrent-runs-lock-dir" description:"Directory of the lock files counting the concurrent runs. It must be shared by all the resticprofile processes of the host (default is \"resticprofile-runs\" in the runtime directory: \"/var/run\" for root, \"$XDG_RUNTIME_DIR\" for the other users, or the temporary directory)"`
+	RepositoryLockDir    string              `mapstructure:"repository-lock-dir" description:"Directory of the repository lock files shared by all the profiles using the same repository (default is \"resticprofile-repositories\" in the runtime directory: \"/var/run\" for root, \"$XDG_RUNTIME_DIR\" for the other users, or the temporary directory) - see https://creativeprojects.github.io/resticprofile/usage/locks/"`
+	ScheduleStateDir     string              `mapstructure:"schedule-state-dir" description:"Directory keeping the time of the last run of the schedules catching up missed runs (default is \"resticprofile-schedules\" in the user cache directory)"`
 	ShellBinary          []string            `mapstructure:"shell" default:"auto" examples:"sh;bash;pwsh;powershell;cmd" description:"The shell that is used to run commands (default is OS specific)"`
 	MinMemory            uint64              `mapstructure:"min-memory" default:"100" description:"Minimum available memory (in MB) required to run any commands - see https://creativeprojects.github.io/resticprofile/usage/memory/"`
 	Scheduler            string              `mapstructure:"scheduler" default:"auto" examples:"auto;launchd;systemd;taskscheduler;crond;crond:/usr/bin/crontab;crontab:*:/etc/cron.d/resticprofile" description:"Selects the scheduler. Blank or \"auto\" uses the default scheduler of your operating system: \"launchd\", \"systemd\", \"taskscheduler\" or \"crond\" (as fallback). Alternatively you can set \"crond\" for cron compatible schedulers supporting the crontab executable API or \"crontab:[user:]file\" to write into a crontab file directly. The need for a user is detected if missing and can be set to a name, \"-\" (no user) or \"*\" (current user)."`
 	ScheduleDefaults     *ScheduleBaseConfig `mapstructure:"schedule-defaults" default:"" description:"Sets defaults for all schedules"`
-	Log                  string              `mapstructure:"log" default:"" examples:"/resticprofile.log;syslog-tcp://syslog-server:514;syslog:server;syslog:" description:"Sets the default log destination to be used if not specified in \"--log\" or \"schedule-log\" - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
+	Log                  string              `mapstructure:"log" default:"" examples:"/resticprofile.log;syslog-tcp://syslog-server:514;syslog-tls://syslog-server:6514;syslog:server;syslog:;journald:" description:"Sets the default log destination to be used if not specified in \"--log\" or \"schedule-log\" - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
+	LogFormat            string              `mapstructure:"log-format" default:"text" enum:"text;json" description:"Sets the format of the log output: \"text\" or \"json\" (one JSON object per line) - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
+	LogMaxSize           uint64              `mapstructure:"log-max-size" default:"0" description:"Rotates the log file before it grows over this size in MB (0 disables the rotation) - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
+	LogMaxFiles          int                 `mapstructure:"log-max-files" default:"0" description:"Number of rotated log files, or previous log files of a log path with placeholders, to keep (0 keeps all)"`
+	LogMaxAge            time.Duration       `mapstructure:"log-max-age" examples:"168h;720h" description:"Removes rotated log files, and previous log files of a log path with placeholders, older than this duration"`
+	LogCompress          bool                `mapstructure:"log-compress" default:"false" description:"Compresses rotated log files with gzip"`
+	SyslogFormat         string              `mapstructure:"syslog-format" enum:"rfc3164;rfc5424" description:"Sets the message format sent to a remote syslog server: \"rfc3164\" (BSD syslog) or \"rfc5424\" with the profile and command as structured data. Default is \"rfc5424\" for \"syslog-tls\" and \"rfc3164\" otherwise - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
+	SyslogTLSCACert      string              `mapstructure:"syslog-tls-ca-certificate" description:"Path to a PEM encoded certificate to trust in addition to system certificates when sending logs to a \"syslog-tls\" server"`
+	SyslogTLSClientCert  string              `mapstructure:"syslog-tls-client-certificate" description:"Path to a PEM encoded file containing the client certificate and private key used to authenticate to a \"syslog-tls\" server"`
 	CommandOutput        string              `mapstructure:"command-output" default:"auto" enum:"auto;log;console;all" description:"Sets the destination for command output (stderr/stdout). \"log\" sends output to the log file (if specified), \"console\" sends it to the console instead. \"auto\" sends it to \"both\" if console is a terminal otherwise to \"log\" only - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
 	LegacyArguments      bool                `mapstructure:"legacy-arguments" default:"false" deprecated:"0.20.0" description:"Legacy, broken arguments mode of resticprofile before version 0.15"`
 	SystemdUnitTemplate  string              `mapstructure:"systemd-unit-template" default:"" description:"File containing the go template to generate a systemd unit - see https://creativeprojects.github.io/resticprofile/schedules/systemd/"`
@@ -35,6 +52,9 @@ type Global struct {
 	CACertificates       []string            `mapstructure:"ca-certificates" description:"Path to PEM encoded certificates to trust in addition to system certificates when resticprofile sends to a webhook - see https://creativeprojects.github.io/resticprofile/configuration/http_hooks/"`
 	PreventSleep         bool                `mapstructure:"prevent-sleep" default:"false" description:"Prevent the system from sleeping while running commands - see https://creativeprojects.github.io/resticprofile/configuration/sleep/"`
 	GroupContinueOnError bool                `mapstructure:"group-continue-on-error" default:"false" description:"Enable groups to continue with the next profile(s) instead of stopping at the first failure"`
+	OtelEndpoint         string              `mapstructure:"otel-endpoint" format:"uri" examples:"http://localhost:4318;https://otel-collector.example.com:4318" description:"Base URL of an OpenTelemetry collector receiving a trace and metrics of each run over OTLP/HTTP - see https://creativeprojects.github.io/resticprofile/monitoring/opentelemetry/"`
+	OtelHeaders          map[string]string   `mapstructure:"otel-headers" description:"Additional HTTP headers sent to the OpenTelemetry collector (e.g. for authentication)"`
+	OtelResource         map[string]string   `mapstructure:"otel-resource-attributes" description:"Additional resource attributes of the OpenTelemetry trace and metrics (\"service.name\" is \"resticprofile\" by default)"`
 }
 
 // NewGlobal instantiates a new Global with default values
@@ -48,6 +68,7 @@ func NewGlobal() *Global {
 		ResticLockRetryAfter: constants.DefaultResticLockRetryAfter,
 		ResticStaleLockAge:   constants.DefaultResticStaleLockAge,
 		MinMemory:            constants.DefaultMinMemory,
+		LogFormat:            constants.DefaultLogFormat,
 		CommandOutput:        constants.DefaultCommandOutput,
 		SenderTimeout:        constants.DefaultSenderTimeout,
 	}
@@ -57,6 +78,11 @@ func (p *Global) SetRootPath(rootPath string) {
 	p.ShellBinary = fixPaths(p.ShellBinary, expandEnv)
 	p.ResticBinary = fixPath(p.ResticBinary, expandEnv)
 	p.Log = fixPath(p.Log, expandEnv, expandUserHome)
+	p.ConcurrentRunsDir = fixPath(p.ConcurrentRunsDir, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.RepositoryLockDir = fixPath(p.RepositoryLockDir, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.ScheduleStateDir = fixPath(p.ScheduleStateDir, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.SyslogTLSCACert = fixPath(p.SyslogTLSCACert, expandEnv, absolutePrefix(rootPath))
+	p.SyslogTLSClientCert = fixPath(p.SyslogTLSClientCert, expandEnv, absolutePrefix(rootPath))
 
 	p.SystemdUnitTemplate = fixPath(p.SystemdUnitTemplate, expandEnv, absolutePrefix(rootPath))
 	p.SystemdTimerTemplate = fixPath(p.SystemdTimerTemplate, expandEnv, absolutePrefix(rootPath))
@@ -65,3 +91,54 @@ func (p *Global) SetRootPath(rootPath string) {
 		p.CACertificates[index] = fixPath(file, expandEnv, absolutePrefix(rootPath))
 	}
 }
+
+// GetConcurrentRunsDir returns the directory of the lock files counting the concurrent runs
+func (p *Global) GetConcurrentRunsDir() string {
+	if p.ConcurrentRunsDir != "" {
+		return p.ConcurrentRunsDir
+	}
+	return filepath.Join(runtimeDir(), constants.DefaultConcurrentRunsDir)
+}
+
+// GetRepositoryLockDir returns the directory of the repository lock files
+func (p *Global) GetRepositoryLockDir() string {
+	if p.RepositoryLockDir != "" {
+		return p.RepositoryLockDir
+	}
+	return filepath.Join(runtimeDir(), constants.DefaultRepositoryLockDir)
+}
+
+// runtimeDir returns the directory of the lock files of the current user. The temporary directory is only used as a last resort:
+// it can be private to a systemd unit (PrivateTmp), and anyone can leave a lock file in it which the other users cannot remove.
+func runtimeDir() string {
+	if platform.IsWindows() {
+		return os.TempDir()
+	}
+	if os.Geteuid() == 0 {
+		return "/var/run"
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	if dir := fmt.Sprintf("/run/user/%d", os.Geteuid()); isDir(dir) {
+		return dir
+	}
+	return os.TempDir()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// GetScheduleStateDir returns the directory keeping the time of the last run of the schedules
+func (p *Global) GetScheduleStateDir() string {
+	if p.ScheduleStateDir != "" {
+		return p.ScheduleStateDir
+	}
+	// the state must survive a reboot: the temporary directory is not a good default
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, constants.DefaultScheduleStateDir)
+	}
+	return filepath.Join(os.TempDir(), constants.DefaultScheduleStateDir)
+}
@@ -2,10 +2,13 @@ package config
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/platform"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,3 +87,25 @@ func getGlobalSection(configString string) (*Global, error) {
 	}
 	return global, nil
 }
+
+func TestConcurrentRunsDir(t *testing.T) {
+	global := NewGlobal()
+	assert.Equal(t, filepath.Join(runtimeDir(), constants.DefaultConcurrentRunsDir), global.GetConcurrentRunsDir())
+
+	global.ConcurrentRunsDir = "runs"
+	global.SetRootPath(filepath.FromSlash("/etc/resticprofile"))
+	assert.Equal(t, filepath.FromSlash("/etc/resticprofile/runs"), global.GetConcurrentRunsDir())
+}
+
+func TestRuntimeDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	switch {
+	case platform.IsWindows():
+		assert.Equal(t, os.TempDir(), runtimeDir())
+	case os.Geteuid() == 0:
+		assert.Equal(t, "/var/run", runtimeDir())
+	default:
+		assert.Equal(t, dir, runtimeDir())
+	}
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/util/maybe"
 )
@@ -12,7 +15,7 @@ type Group struct {
 	Description      string                     `mapstructure:"description" description:"Describe the group"`
 	Profiles         []string                   `mapstructure:"profiles" description:"Names of the profiles belonging to this group"`
 	ContinueOnError  maybe.Bool                 `mapstructure:"continue-on-error" default:"auto" description:"Continue with the next profile on a failure, overrides \"global.group-continue-on-error\""`
-	CommandSchedules map[string]*ScheduleConfig `mapstructure:"schedules" show:"noshow" description:"Allows to run the group on schedule for the specified command name (backup, copy, check, forget, prune)."`
+	CommandSchedules map[string]*ScheduleConfig `mapstructure:"schedules" show:"noshow" description:"Allows to run the group on schedule for the specified command name (backup, copy, check, forget, prune, or any other restic command)."`
 }
 
 func NewGroup(c *Config, name string) (g *Group) {
@@ -25,21 +28,26 @@ func NewGroup(c *Config, name string) (g *Group) {
 
 func (g *Group) ResolveConfiguration() {
 	global := g.config.mustGetGlobalSection()
-	for command, cfg := range g.CommandSchedules {
+	for key, cfg := range g.CommandSchedules {
 		if cfg.HasSchedules() {
 			cfg.init(global.ScheduleDefaults)
-			cfg.origin = ScheduleOrigin(g.Name, command, ScheduleOriginGroup)
+			// the name of the schedule can be set in the key ("command.name")
+			command, name, _ := strings.Cut(key, constants.ScheduleNameSeparator)
+			if cfg.Name == "" {
+				cfg.Name = name
+			}
+			cfg.origin = ScheduleOrigin(g.Name, cfg.scheduleName(command), ScheduleOriginGroup)
 		} else {
-			delete(g.CommandSchedules, command)
+			delete(g.CommandSchedules, key)
 		}
 	}
 }
 
 func (g *Group) Schedules() map[string]*Schedule {
 	schedules := make(map[string]*Schedule)
-	for command, cfg := range g.CommandSchedules {
+	for _, cfg := range g.CommandSchedules {
 		if cfg.HasSchedules() {
-			schedules[command] = NewSchedule(g.config, cfg)
+			schedules[cfg.origin.Command] = NewSchedule(g.config, cfg)
 		}
 	}
 	return schedules
@@ -47,15 +55,10 @@ func (g *Group) Schedules() map[string]*Schedule {
 
 // SchedulableCommands returns the list of commands that can be scheduled (whether they have schedules or not)
 func (g *Group) SchedulableCommands() []string {
-	// once the deprecated retention schedule is removed, we can use the list from profiles
-	// return NewProfile(g.config, "").SchedulableCommands()
-	return []string{
-		constants.CommandBackup,
-		constants.CommandCheck,
-		constants.CommandForget,
-		constants.CommandPrune,
-		constants.CommandCopy,
-	}
+	// the deprecated retention schedule is only available in profiles
+	return slices.DeleteFunc(NewProfile(g.config, "").SchedulableCommands(), func(command string) bool {
+		return command == constants.SectionConfigurationRetention
+	})
 }
 
 func (g *Group) Kind() string {
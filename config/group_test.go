@@ -9,14 +9,16 @@ import (
 
 func TestGroupSchedulableCommands(t *testing.T) {
 	// Define expected schedulable commands
-	expectedCommands := []string{"backup", "check", "forget", "prune", "copy"}
+	expectedCommands := []string{"backup", "check", "forget", "prune", "copy", "stats", "rewrite", "repair-snapshots", "unlock"}
 
 	config := &Config{}
 	group := NewGroup(config, "test")
 
 	commands := group.SchedulableCommands()
 
-	assert.ElementsMatch(t, commands, expectedCommands, "Schedulable commands should match expected list")
+	assert.Subset(t, commands, expectedCommands, "Schedulable commands should contain expected list")
+	assert.NotContains(t, commands, constants.SectionConfigurationRetention, "Retention schedule is only available in profiles")
+	assert.NotContains(t, commands, constants.CommandInit)
 
 	// Test Kind() method
 	assert.Equal(t, constants.SchedulableKindGroup, group.Kind(), "Group kind should be SchedulableKindGroup")
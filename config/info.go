@@ -606,7 +606,7 @@ func init() {
 		infoTypes.mixinUse = reflect.TypeFor[mixinUse]()
 		infoTypes.profile = reflect.TypeFor[Profile]()
 		infoTypes.scheduleConfig = reflect.TypeFor[ScheduleConfig]()
-		infoTypes.genericSection = reflect.TypeFor[GenericSection]()
+		infoTypes.genericSection = reflect.TypeFor[GenericSectionWithSchedule]()
 		infoTypes.genericSectionNames = slices.Collect(maps.Keys(profile.OtherSections))
 	}
 }
@@ -117,7 +117,7 @@ func init() {
 				basic := property.basic().resetTypeInfo()
 				basic.nested = NewScheduleConfigInfo()
 				basic.single = false
-				basic.singleNested = true
+				basic.singleNested = false // a list of schedule structures declares named schedules
 				basic.mayString = true
 				basic.mayNil = true
 			}
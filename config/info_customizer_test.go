@@ -264,7 +264,7 @@ func TestScheduleProperty(t *testing.T) {
 			assert.Equal(t, NewScheduleConfigInfo().Name(), info.PropertySet().Name())
 
 			assert.False(t, info.IsSingle(), "multiple strings")
-			assert.True(t, info.IsSinglePropertySet(), "just one nested type")
+			assert.False(t, info.IsSinglePropertySet(), "list of named schedules")
 		})
 	}
 }
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/restic"
@@ -1031,6 +1032,131 @@ profile:
 	}
 }
 
+func TestOtherCommandSchedule(t *testing.T) {
+	AddOwnCommandSection("test-own-command")
+
+	profile, err := getResolvedProfile("toml", `
+[profile.stats]
+mode = "raw-data"
+schedule = "weekly"
+schedule-lock-wait = "10m"
+
+[profile.repair-snapshots]
+forget = true
+schedule = "monthly"
+
+[profile.test-own-command]
+remove = true
+schedule = "daily"
+`, "profile")
+	require.NoError(t, err)
+	require.NotNil(t, profile)
+
+	schedules := profile.Schedules()
+	require.Contains(t, schedules, "stats")
+	assert.Equal(t, []string{"weekly"}, schedules["stats"].Schedules)
+	assert.Equal(t, 10*time.Minute, schedules["stats"].GetLockWait())
+	require.Contains(t, schedules, "repair-snapshots")
+	assert.Equal(t, []string{"monthly"}, schedules["repair-snapshots"].Schedules)
+	require.Contains(t, schedules, "test-own-command")
+	assert.Contains(t, profile.SchedulableCommands(), "test-own-command")
+
+	// schedule parameters are not sent to restic
+	assert.Equal(t, []string{"--mode=raw-data"}, profile.GetCommandFlags("stats").GetAll())
+	assert.Equal(t, []string{"--forget"}, profile.GetCommandFlags("repair-snapshots").GetAll())
+	assert.Equal(t, []string{"--remove"}, profile.GetOwnCommandFlags("test-own-command").GetAll())
+	assert.Empty(t, profile.GetOwnCommandFlags("unknown").GetAll())
+}
+
+func TestNamedSchedules(t *testing.T) {
+	profile, err := getResolvedProfile("yaml", `
+profile:
+  backup:
+    tag: profile
+    schedule-lock-wait: 10m
+    schedule:
+      - "*:05"
+      - name: daily
+        at: "23:15"
+        flags:
+          tag: daily
+        env:
+          backup_kind: daily
+      - name: daily
+        at: "23:45"
+      - name: "invalid.name"
+        at: "12:00"
+  check:
+    schedule:
+      - name: weekly
+        at: "Sun 04:00"
+        flags:
+          read-data-subset: 10%
+`, "profile")
+	require.NoError(t, err)
+	require.NotNil(t, profile)
+
+	schedules := profile.Schedules()
+	assert.ElementsMatch(t, []string{"backup", "backup.daily", "check.weekly"}, slices.Collect(maps.Keys(schedules)))
+
+	backup := schedules["backup"]
+	assert.Equal(t, []string{"*:05"}, backup.Schedules)
+	assert.Empty(t, backup.GetCommandFlags().GetAll())
+	assert.Equal(t, 10*time.Minute, backup.GetLockWait())
+	assert.Equal(t, ScheduleOrigin("profile", "backup"), backup.ScheduleOrigin())
+
+	daily := schedules["backup.daily"]
+	assert.Equal(t, "daily", daily.Name)
+	assert.Equal(t, []string{"23:15"}, daily.Schedules)
+	assert.Equal(t, []string{"--tag=daily"}, daily.GetCommandFlags().GetAll())
+	assert.Equal(t, "daily", daily.CommandEnv["backup_kind"].Value())
+	assert.Equal(t, 10*time.Minute, daily.GetLockWait())
+	assert.Equal(t, ScheduleOrigin("profile", "backup.daily"), daily.ScheduleOrigin())
+
+	weekly := schedules["check.weekly"]
+	assert.Equal(t, []string{"--read-data-subset=10%"}, weekly.GetCommandFlags().GetAll())
+
+	// flags of the schedule replace the flags of the profile
+	args := profile.GetCommandFlags("backup")
+	daily.AddCommandFlags(args)
+	tags, _ := args.Get("tag")
+	require.Len(t, tags, 1)
+	assert.Equal(t, "daily", tags[0].Value())
+
+	// environment of the schedule
+	profile.AddEnvironment(daily.CommandEnv)
+	assert.Equal(t, "daily", profile.Environment["BACKUP_KIND"].Value())
+}
+
+func TestNamedGroupSchedules(t *testing.T) {
+	c, err := Load(bytes.NewBufferString(`
+version: "2"
+profiles:
+  one:
+    repository: local
+groups:
+  all:
+    profiles: one
+    schedules:
+      check:
+        at: daily
+      check.weekly:
+        at: weekly
+        flags:
+          read-data-subset: 10%
+`), FormatYAML)
+	require.NoError(t, err)
+	group, err := c.GetProfileGroup("all")
+	require.NoError(t, err)
+
+	schedules := group.Schedules()
+	require.Contains(t, schedules, "check")
+	require.Contains(t, schedules, "check.weekly")
+	assert.Equal(t, "weekly", schedules["check.weekly"].Name)
+	assert.Equal(t, ScheduleOrigin("all", "check.weekly", ScheduleOriginGroup), schedules["check.weekly"].ScheduleOrigin())
+	assert.Equal(t, []string{"--read-data-subset=10%"}, schedules["check.weekly"].GetCommandFlags().GetAll())
+}
+
 // schedule is moving from "retention" to "forget" section
 // second test: check the schedule deprecation in the "retention" section
 func TestRetentionSchedule(t *testing.T) {
@@ -1080,7 +1206,7 @@ profile:
 
 			assert.NotNil(t, profile)
 			assert.NotNil(t, profile.Retention)
-			assert.NotNil(t, profile.Retention.getScheduleConfig(nil, ""))
+			assert.NotEmpty(t, profile.Retention.getScheduleConfigs(nil, ""))
 			assert.True(t, profile.HasDeprecatedRetentionSchedule())
 		})
 	}
@@ -1677,3 +1803,26 @@ func getExamplesDirectory(t *testing.T) string {
 	t.Skip("examples directory not found")
 	return ""
 }
+
+func TestIsSharedLockCommand(t *testing.T) {
+	runForVersions(t, func(t *testing.T, version, prefix string) {
+		t.Helper()
+		profile, err := getProfile("toml", version+"["+prefix+"profile]\n", "profile", "")
+		require.NoError(t, err)
+		assert.True(t, profile.IsSharedLockCommand("snapshots"))
+		assert.True(t, profile.IsSharedLockCommand("ls"))
+		assert.False(t, profile.IsSharedLockCommand("backup"))
+		assert.False(t, profile.IsSharedLockCommand("forget"))
+
+		testConfig := version + "[" + prefix + "profile]\nshared-lock-commands = [\"check\"]\n"
+		profile, err = getProfile("toml", testConfig, "profile", "")
+		require.NoError(t, err)
+		assert.True(t, profile.IsSharedLockCommand("check"))
+		assert.False(t, profile.IsSharedLockCommand("snapshots"))
+
+		testConfig = version + "[" + prefix + "profile]\nshared-lock-commands = []\n"
+		profile, err = getProfile("toml", testConfig, "profile", "")
+		require.NoError(t, err)
+		assert.False(t, profile.IsSharedLockCommand("snapshots"))
+	})
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+// repositoryBackends are the restic backends using a "backend:location" repository
+var repositoryBackends = []string{"azure", "b2", "gs", "opendal", "rclone", "rest", "s3", "sftp", "swift"}
+
+// defaultPorts are removed from the repository URLs
+var defaultPorts = map[string]string{"http": "80", "https": "443", "sftp": "22"}
+
+// defaultExclusiveRepoCommands are the commands taking an exclusive repository lock when "exclusive-repository-lock-commands" is not set
+var defaultExclusiveRepoCommands = []string{
+	constants.CommandForget,
+	"key",
+	"migrate",
+	constants.CommandPrune,
+	"rebuild-index",
+	"repair-index",
+	"repair-packs",
+	"repair-snapshots",
+}
+
+// NormalizeRepository returns the canonical form of a restic repository location, so that the different spellings of
+// the same repository are equal. Passwords are removed from the URLs.
+func NormalizeRepository(repository string) string {
+	repository = strings.TrimSpace(repository)
+	if repository == "" {
+		return ""
+	}
+	backend, location, found := strings.Cut(repository, ":")
+	if !found || !slices.Contains(repositoryBackends, backend) {
+		return normalizeLocalRepository(strings.TrimPrefix(repository, "local:"))
+	}
+	if strings.Contains(location, "://") {
+		return backend + ":" + normalizeRepositoryURL(location)
+	}
+	if backend == "sftp" && strings.HasPrefix(location, "//") {
+		// sftp://user@host:port//path
+		return normalizeRepositoryURL(repository)
+	}
+	location = strings.TrimRight(location, "/")
+	switch backend {
+	case "sftp":
+		// sftp:user@host:/path
+		if userHost, path, found := strings.Cut(location, ":"); found {
+			user, host, hasUser := strings.Cut(userHost, "@")
+			if !hasUser {
+				user, host = "", userHost
+			} else {
+				user += "@"
+			}
+			location = user + strings.ToLower(host) + ":" + path
+		}
+	case "s3":
+		// s3:host/bucket/path
+		host, path, _ := strings.Cut(location, "/")
+		location = strings.ToLower(host) + "/" + path
+	}
+	return backend + ":" + location
+}
+
+func normalizeLocalRepository(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" {
+		// paths are not case sensitive
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+func normalizeRepositoryURL(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return strings.TrimRight(location, "/")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port != "" && port != defaultPorts[u.Scheme] {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	if u.User != nil {
+		// the same repository can be used with different passwords
+		u.User = url.User(u.User.Username())
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// GetNormalizedRepository returns the normalized location of the repository (from "repository" or "repository-file")
+func (p *Profile) GetNormalizedRepository() string {
+	repository := p.Repository.Value()
+	if repository == "" && p.RepositoryFile != "" {
+		if content, err := os.ReadFile(p.RepositoryFile); err == nil {
+			repository, _, _ = strings.Cut(string(content), "\n")
+		}
+	}
+	return NormalizeRepository(repository)
+}
+
+// GetRepositoryLockFile returns the lock file in dir shared by all the profiles using the same repository.
+// It returns an empty string when "repository-lock" is disabled or when the repository is not set.
+func (p *Profile) GetRepositoryLockFile(dir string) string {
+	if p.RepositoryLock.IsStrictlyFalse() {
+		return ""
+	}
+	repository := p.GetNormalizedRepository()
+	if repository == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(repository))
+	return filepath.Join(dir, "repository-"+hex.EncodeToString(hash[:8])+".lock")
+}
+
+// IsExclusiveRepositoryLockCommand returns true when the command takes an exclusive lock on the repository.
+// A backup applying the retention policy takes an exclusive lock as it runs a "forget" command.
+func (p *Profile) IsExclusiveRepositoryLockCommand(command string) bool {
+	commands := p.ExclusiveRepoCommands
+	if commands == nil {
+		commands = defaultExclusiveRepoCommands
+	}
+	if command == constants.CommandBackup && p.Retention != nil &&
+		(p.Retention.BeforeBackup.IsTrue() || p.Retention.AfterBackup.IsTrue()) &&
+		slices.Contains(commands, constants.CommandForget) {
+		return true
+	}
+	return slices.Contains(commands, command)
+}
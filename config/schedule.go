@@ -3,6 +3,8 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
 	"os"
 	"path"
 	"path/filepath"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
 	"github.com/creativeprojects/resticprofile/util"
 	"github.com/creativeprojects/resticprofile/util/collect"
 	"github.com/creativeprojects/resticprofile/util/maybe"
@@ -37,6 +40,7 @@ type ScheduleBaseConfig struct {
 	Permission              string         `mapstructure:"permission" default:"auto" enum:"auto;system;user;user_logged_on" description:"Specify whether the schedule runs with system or user privileges - see https://creativeprojects.github.io/resticprofile/schedules/configuration/"`
 	RunLevel                string         `mapstructure:"run-level" default:"auto" enum:"auto;lowest;highest" description:"Specify the schedule privilege level (for Windows Task Scheduler only)"`
 	Log                     string         `mapstructure:"log" examples:"/resticprofile.log;syslog-tcp://syslog-server:514;syslog:server;syslog:" description:"Redirect the output into a log file or to syslog when running on schedule - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
+	LogFormat               string         `mapstructure:"log-format" enum:"text;json" description:"Sets the format of the log output when running on schedule: \"text\" or \"json\" (one JSON object per line). Defaults to the \"log-format\" of the global section - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
 	CommandOutput           string         `mapstructure:"command-output" default:"auto" enum:"auto;log;console;all" description:"Sets the destination for command output (stderr/stdout). \"log\" sends output to the log file (if specified), \"console\" sends it to the console instead. \"auto\" sends it to \"both\" if console is a terminal otherwise to \"log\" only - see https://creativeprojects.github.io/resticprofile/configuration/logs/"`
 	Priority                string         `mapstructure:"priority" default:"standard" enum:"background;standard" description:"Set the priority at which the schedule is run"`
 	LockMode                string         `mapstructure:"lock-mode" default:"default" enum:"default;fail;ignore" description:"Specify how locks are used when running on schedule - see https://creativeprojects.github.io/resticprofile/schedules/configuration/"`
@@ -48,6 +52,17 @@ type ScheduleBaseConfig struct {
 	SystemdDropInFiles      []string       `mapstructure:"systemd-drop-in-files" default:"" description:"Files containing systemd drop-in (override) files - see https://creativeprojects.github.io/resticprofile/schedules/systemd/"`
 	HideWindow              maybe.Bool     `mapstructure:"hide-window" default:"false" description:"Hide schedule window when running in foreground (Windows only)"`
 	StartWhenAvailable      maybe.Bool     `mapstructure:"start-when-available" default:"false" description:"Start the task as soon as possible after a scheduled start is missed (Windows only)"`
+	RandomizedDelay         maybe.Duration `mapstructure:"randomized-delay" examples:"5m;15m;30m;1h" description:"Delay the start of the schedule by a random time between zero and this duration, to spread the load of many computers using the same repository (\"RandomizedDelaySec\" on systemd)"`
+	FixedRandomDelay        maybe.Bool     `mapstructure:"fixed-random-delay" default:"false" description:"Use the same random delay for every run of the schedule on this computer (\"FixedRandomDelay\" on systemd)"`
+	CatchUp                 maybe.Bool     `mapstructure:"catch-up" default:"false" description:"Run the schedule once as soon as possible after a scheduled start is missed, for instance when the computer was off (crond and crontab only)"`
+	SystemdHardening        maybe.Bool     `mapstructure:"systemd-hardening" default:"false" description:"Run the schedule in a sandbox: read-only system and home directories (except the restic cache and \"systemd-read-write-paths\"), private /tmp and no new privileges (supported in \"systemd\")"`
+	SystemdReadWritePaths   []string       `mapstructure:"systemd-read-write-paths" default:"" description:"Paths that stay writable with \"systemd-hardening\", like a local repository, a lock or a log file (\"ReadWritePaths\" on systemd)"`
+	SystemdMemoryMax        string         `mapstructure:"systemd-memory-max" examples:"512M;2G;25%" description:"Limit the memory used by the schedule (\"MemoryMax\" on systemd)"`
+	SystemdCPUQuota         string         `mapstructure:"systemd-cpu-quota" examples:"50%;100%;200%" description:"Limit the CPU time used by the schedule, 100% being one CPU (\"CPUQuota\" on systemd)"`
+	SystemdIOWeight         int            `mapstructure:"systemd-io-weight" default:"" range:"[1:10000]" examples:"10;100;1000" description:"Set the I/O weight of the schedule, the default weight of systemd is 100 (\"IOWeight\" on systemd)"`
+	SystemdWatchdog         maybe.Duration `mapstructure:"systemd-watchdog" examples:"5m;15m;1h" description:"Kill the schedule when it shows no activity (output or progress of the commands) for this duration (\"WatchdogSec\" on systemd)"`
+	SystemdOnFailure        maybe.Bool     `mapstructure:"systemd-on-failure" default:"false" description:"Start a companion unit when the schedule fails (\"OnFailure\" on systemd), sending the \"send-after-fail\" hooks of the profile with an excerpt of the journal of the failed run"`
+	SystemdLoadCredential   maybe.Bool     `mapstructure:"systemd-load-credential" default:"false" description:"Pass the \"password-file\" of the profile to the schedule as a systemd credential (\"LoadCredential\"), so it only needs to be readable by systemd (supported in \"systemd\")"`
 }
 
 // scheduleBaseConfigDefaults declares built-in scheduling defaults
@@ -74,6 +89,9 @@ func (s *ScheduleBaseConfig) init(defaults *ScheduleBaseConfig) {
 	if s.Log == "" {
 		s.Log = defaults.Log
 	}
+	if s.LogFormat == "" {
+		s.LogFormat = defaults.LogFormat
+	}
 	if s.CommandOutput == "" {
 		s.CommandOutput = defaults.CommandOutput
 	}
@@ -104,6 +122,39 @@ func (s *ScheduleBaseConfig) init(defaults *ScheduleBaseConfig) {
 	if !s.StartWhenAvailable.HasValue() {
 		s.StartWhenAvailable = defaults.StartWhenAvailable
 	}
+	if !s.RandomizedDelay.HasValue() {
+		s.RandomizedDelay = defaults.RandomizedDelay
+	}
+	if !s.FixedRandomDelay.HasValue() {
+		s.FixedRandomDelay = defaults.FixedRandomDelay
+	}
+	if !s.CatchUp.HasValue() {
+		s.CatchUp = defaults.CatchUp
+	}
+	if !s.SystemdHardening.HasValue() {
+		s.SystemdHardening = defaults.SystemdHardening
+	}
+	if s.SystemdReadWritePaths == nil {
+		s.SystemdReadWritePaths = slices.Clone(defaults.SystemdReadWritePaths)
+	}
+	if s.SystemdMemoryMax == "" {
+		s.SystemdMemoryMax = defaults.SystemdMemoryMax
+	}
+	if s.SystemdCPUQuota == "" {
+		s.SystemdCPUQuota = defaults.SystemdCPUQuota
+	}
+	if s.SystemdIOWeight == 0 {
+		s.SystemdIOWeight = defaults.SystemdIOWeight
+	}
+	if !s.SystemdWatchdog.HasValue() {
+		s.SystemdWatchdog = defaults.SystemdWatchdog
+	}
+	if !s.SystemdOnFailure.HasValue() {
+		s.SystemdOnFailure = defaults.SystemdOnFailure
+	}
+	if !s.SystemdLoadCredential.HasValue() {
+		s.SystemdLoadCredential = defaults.SystemdLoadCredential
+	}
 }
 
 func (s *ScheduleBaseConfig) applyOverrides(section *ScheduleBaseSection) {
@@ -121,6 +172,17 @@ func (s *ScheduleBaseConfig) applyOverrides(section *ScheduleBaseSection) {
 	s.AfterNetworkOnline = section.ScheduleAfterNetworkOnline
 	s.HideWindow = section.ScheduleHideWindow
 	s.StartWhenAvailable = section.ScheduleStartWhenAvailable
+	s.RandomizedDelay = section.ScheduleRandomizedDelay
+	s.FixedRandomDelay = section.ScheduleFixedRandomDelay
+	s.CatchUp = section.ScheduleCatchUp
+	s.SystemdHardening = section.ScheduleSystemdHardening
+	s.SystemdReadWritePaths = slices.Clone(section.ScheduleSystemdReadWritePaths)
+	s.SystemdMemoryMax = section.ScheduleSystemdMemoryMax
+	s.SystemdCPUQuota = section.ScheduleSystemdCPUQuota
+	s.SystemdIOWeight = section.ScheduleSystemdIOWeight
+	s.SystemdWatchdog = section.ScheduleSystemdWatchdog
+	s.SystemdOnFailure = section.ScheduleSystemdOnFailure
+	s.SystemdLoadCredential = section.ScheduleSystemdLoadCredential
 	// re-init with defaults
 	s.init(&defaults)
 }
@@ -173,9 +235,12 @@ func ScheduleOrigin(name, command string, kind ...ScheduleOriginType) (s Schedul
 
 // ScheduleConfig is the user configuration of a specific schedule bound to a command in a profile or group.
 type ScheduleConfig struct {
-	normalized bool
-	origin     ScheduleConfigOrigin `show:"noshow"`
-	Schedules  []string             `mapstructure:"at" examples:"hourly;daily;weekly;monthly;10:00,14:00,18:00,22:00;Wed,Fri 17:48;*-*-15 02:45;Mon..Fri 00:30" description:"Set the times at which the scheduled command is run (times are specified in systemd timer format)"`
+	normalized   bool
+	origin       ScheduleConfigOrigin         `show:"noshow"`
+	Schedules    []string                     `mapstructure:"at" examples:"hourly;daily;weekly;monthly;10:00,14:00,18:00,22:00;Wed,Fri 17:48;*-*-15 02:45;Mon..Fri 00:30" description:"Set the times at which the scheduled command is run (times are specified in systemd timer format, or as cron expressions prefixed with \"cron:\")"`
+	Name         string                       `mapstructure:"name" examples:"hourly;daily;weekly;monthly" description:"Name of the schedule, required when a list of schedules is declared for the same command. The schedule is named \"command.name\""`
+	CommandFlags map[string]any               `mapstructure:"flags" show:"noshow" description:"Additional restic flags of the command when it's started by this schedule, replacing the flags with the same name in the profile"`
+	CommandEnv   map[string]ConfidentialValue `mapstructure:"env" show:"noshow" description:"Additional environment variables when the command is started by this schedule"`
 
 	ScheduleBaseConfig `mapstructure:",squash"`
 }
@@ -197,36 +262,14 @@ func NewDefaultScheduleConfig(config *Config, origin ScheduleConfigOrigin, sched
 }
 
 func newScheduleConfig(profile *Profile, section *ScheduleBaseSection) (s *ScheduleConfig) {
-	origin := ScheduleConfigOrigin{} // is set later
-	config := profile.config
-
 	// decode ScheduleBaseSection.Schedule
 	switch expression := section.Schedule.(type) {
 	case string:
-		s = NewDefaultScheduleConfig(config, origin, expression)
-		s.applyProfile(profile)
+		s = newProfileScheduleConfig(profile, expression)
 	case []string, []any:
-		s = NewDefaultScheduleConfig(config, origin, cast.ToStringSlice(expression)...)
-		s.applyProfile(profile)
+		s = newProfileScheduleConfig(profile, cast.ToStringSlice(expression)...)
 	default:
-		if expression != nil {
-			cfg := new(ScheduleConfig)
-			decoder, err := config.newUnmarshaller(cfg)
-			if err == nil {
-				err = decoder.Decode(expression)
-			}
-			if err == nil {
-				defaults := NewDefaultScheduleConfig(config, origin) // applying defaults after parsing to avoid side effects
-				defaults.applyProfile(profile)
-				cfg.init(&defaults.ScheduleBaseConfig)
-				s = cfg
-			} else {
-				if bytes, e := json.Marshal(expression); e == nil {
-					expression = string(bytes)
-				}
-				clog.Errorf("failed decoding schedule %v: %s", expression, err.Error())
-			}
-		}
+		s = decodeScheduleConfig(profile, expression)
 	}
 
 	// init
@@ -238,6 +281,77 @@ func newScheduleConfig(profile *Profile, section *ScheduleBaseSection) (s *Sched
 	return
 }
 
+// newScheduleConfigs returns the schedules declared in the section: a list of schedule structures
+// declares one schedule per name, any other kind of value declares a single schedule
+func newScheduleConfigs(profile *Profile, section *ScheduleBaseSection) (configs []*ScheduleConfig) {
+	list, isList := section.Schedule.([]any)
+	if !isList || !slices.ContainsFunc(list, isScheduleStructure) {
+		if s := newScheduleConfig(profile, section); s != nil {
+			configs = append(configs, s)
+		}
+		return
+	}
+
+	for _, item := range list {
+		var s *ScheduleConfig
+		if at, ok := item.(string); ok {
+			s = newProfileScheduleConfig(profile, at)
+		} else {
+			s = decodeScheduleConfig(profile, item)
+		}
+		if !s.HasSchedules() {
+			continue
+		}
+		if s.Name != "" && !scheduleNamePattern.MatchString(s.Name) {
+			clog.Errorf("invalid schedule name %q: only letters, digits, \"-\" and \"_\" are allowed", s.Name)
+			continue
+		}
+		if slices.ContainsFunc(configs, func(c *ScheduleConfig) bool { return c.Name == s.Name }) {
+			clog.Errorf("ignoring schedule %q: each schedule of the same command needs a distinct name", s.Schedules)
+			continue
+		}
+		s.applyOverrides(section)
+		configs = append(configs, s)
+	}
+	return
+}
+
+var scheduleNamePattern = regexp.MustCompile(`^[\w-]+$`)
+
+func isScheduleStructure(item any) bool {
+	_, isString := item.(string)
+	return !isString
+}
+
+func newProfileScheduleConfig(profile *Profile, schedules ...string) (s *ScheduleConfig) {
+	s = NewDefaultScheduleConfig(profile.config, ScheduleConfigOrigin{}, schedules...) // origin is set later
+	s.applyProfile(profile)
+	return
+}
+
+func decodeScheduleConfig(profile *Profile, expression any) (s *ScheduleConfig) {
+	if expression == nil {
+		return
+	}
+	config := profile.config
+	cfg := new(ScheduleConfig)
+	decoder, err := config.newUnmarshaller(cfg)
+	if err == nil {
+		err = decoder.Decode(expression)
+	}
+	if err == nil {
+		defaults := newProfileScheduleConfig(profile) // applying defaults after parsing to avoid side effects
+		cfg.init(&defaults.ScheduleBaseConfig)
+		s = cfg
+	} else {
+		if bytes, e := json.Marshal(expression); e == nil {
+			expression = string(bytes)
+		}
+		clog.Errorf("failed decoding schedule %v: %s", expression, err.Error())
+	}
+	return
+}
+
 func (s *ScheduleConfig) setSchedules(schedules []string) {
 	schedules = collect.From(schedules, strings.TrimSpace)
 	schedules = collect.All(schedules, func(at string) bool { return len(at) > 0 })
@@ -261,6 +375,26 @@ func (s *ScheduleConfig) ScheduleOrigin() ScheduleConfigOrigin {
 	return s.origin
 }
 
+// scheduleName returns the name of the schedule for the command: "command" or "command.name" for a named schedule
+func (s *ScheduleConfig) scheduleName(command string) string {
+	if s.Name == "" {
+		return command
+	}
+	return command + constants.ScheduleNameSeparator + s.Name
+}
+
+// GetCommandFlags returns the additional flags of the command when it's started by this schedule
+func (s *ScheduleConfig) GetCommandFlags() *shell.Args {
+	flags := shell.NewArgs()
+	addArgsFromMap(flags, nil, s.CommandFlags)
+	return flags
+}
+
+// AddCommandFlags sets the additional flags of this schedule into args, replacing the flags with the same name
+func (s *ScheduleConfig) AddCommandFlags(args *shell.Args) {
+	addArgsFromMap(args, nil, s.CommandFlags)
+}
+
 // Schedulable may be implemented by sections that can provide command schedules (= groups and profiles)
 type Schedulable interface {
 	// Schedules returns a command to schedule map
@@ -278,6 +412,7 @@ type Schedule struct {
 	ConfigFile  string            `show:"noshow"`
 	Environment []string          `show:"noshow"`
 	Flags       map[string]string `show:"noshow"`
+	Credentials map[string]string `show:"noshow"` // ID to file path of the systemd credentials
 }
 
 // NewDefaultSchedule creates a new Schedule for the specified ScheduleConfigOrigin that is initialized with defaults
@@ -315,6 +450,9 @@ func newSchedule(config *Config, sc *ScheduleConfig, profile *Profile) *Schedule
 	var env *util.Environment
 	if profile != nil {
 		env = profile.GetEnvironment(true)
+		if s.SystemdLoadCredential.IsTrue() && profile.PasswordFile != "" {
+			s.Credentials = map[string]string{constants.CredentialPassword: profile.PasswordFile}
+		}
 	}
 
 	// init
@@ -328,6 +466,7 @@ func (s *Schedule) init(env *util.Environment) {
 	// fix paths
 	rootPath := filepath.Dir(s.ConfigFile)
 	s.SystemdDropInFiles = fixPaths(s.SystemdDropInFiles, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	s.SystemdReadWritePaths = fixPaths(s.SystemdReadWritePaths, expandEnv, expandUserHome, absolutePrefix(rootPath))
 	if uriPrefixRegex.MatchString(s.Log) {
 		s.Log = fixPath(s.Log, expandEnv, expandUserHome)
 	} else {
@@ -406,6 +545,29 @@ func (s *Schedule) GetLockWait() time.Duration {
 	return s.LockWait.Value()
 }
 
+// GetRandomizedDelay returns the maximum delay before starting the schedule, or zero when it starts on time
+func (s *Schedule) GetRandomizedDelay() time.Duration {
+	if !s.RandomizedDelay.HasValue() || s.RandomizedDelay.Value() < time.Second {
+		return 0
+	}
+	return s.RandomizedDelay.Value()
+}
+
+// GetStartDelay returns a random delay before starting the schedule, up to the randomized delay.
+// With "fixed-random-delay", the delay is always the same for this schedule on the host in parameter.
+func (s *Schedule) GetStartDelay(hostname string) time.Duration {
+	maxDelay := s.GetRandomizedDelay()
+	if maxDelay == 0 {
+		return 0
+	}
+	if s.FixedRandomDelay.IsTrue() {
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(hostname + "\n" + s.origin.String()))
+		return time.Duration(hash.Sum64() % uint64(maxDelay))
+	}
+	return rand.N(maxDelay)
+}
+
 func (s *Schedule) GetFlag(name string) (string, bool) {
 	if len(s.Flags) == 0 {
 		return "", false
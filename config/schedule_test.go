@@ -110,6 +110,112 @@ func TestNewSchedule(t *testing.T) {
 		assert.Equal(t, "ignore", schedule.LockMode)
 	})
 
+	t.Run("profile randomized delay", func(t *testing.T) {
+		p, _ := profile(t, `
+			[global.schedule-defaults]
+			randomized-delay = "30m"
+
+			[default.backup]
+			schedule = "daily"
+			schedule-randomized-delay = "15m"
+			schedule-fixed-random-delay = true
+
+			[default.check.schedule]
+			at = "monthly"
+		`)
+
+		assert.Equal(t, 15*time.Minute, p.Schedules()["backup"].GetRandomizedDelay())
+		assert.True(t, p.Schedules()["backup"].FixedRandomDelay.IsTrue())
+		assert.Equal(t, 30*time.Minute, p.Schedules()["check"].GetRandomizedDelay())
+		assert.False(t, p.Schedules()["check"].FixedRandomDelay.IsTrue())
+	})
+
+	t.Run("profile catch-up", func(t *testing.T) {
+		p, _ := profile(t, `
+			[global.schedule-defaults]
+			catch-up = true
+
+			[default.backup]
+			schedule = "daily"
+
+			[default.check]
+			schedule = "monthly"
+			schedule-catch-up = false
+		`)
+
+		assert.True(t, p.Schedules()["backup"].CatchUp.IsTrue())
+		assert.True(t, p.Schedules()["check"].CatchUp.IsStrictlyFalse())
+	})
+
+	t.Run("profile systemd hardening", func(t *testing.T) {
+		p, _ := profile(t, `
+			[global.schedule-defaults]
+			systemd-hardening = true
+			systemd-memory-max = "1G"
+
+			[default]
+			password-file = "/etc/restic/password"
+
+			[default.backup]
+			schedule = "daily"
+			schedule-systemd-read-write-paths = "/srv/backup"
+			schedule-systemd-cpu-quota = "50%"
+			schedule-systemd-io-weight = 10
+			schedule-systemd-load-credential = true
+
+			[default.check]
+			schedule = "monthly"
+			schedule-systemd-hardening = false
+		`)
+
+		backup := p.Schedules()["backup"]
+		assert.True(t, backup.SystemdHardening.IsTrue())
+		assert.Equal(t, []string{filepath.FromSlash("/srv/backup")}, backup.SystemdReadWritePaths)
+		assert.Equal(t, "1G", backup.SystemdMemoryMax)
+		assert.Equal(t, "50%", backup.SystemdCPUQuota)
+		assert.Equal(t, 10, backup.SystemdIOWeight)
+		assert.Equal(t, map[string]string{constants.CredentialPassword: filepath.FromSlash("/etc/restic/password")}, backup.Credentials)
+
+		check := p.Schedules()["check"]
+		assert.True(t, check.SystemdHardening.IsStrictlyFalse())
+		assert.Equal(t, "1G", check.SystemdMemoryMax)
+		assert.Empty(t, check.Credentials)
+	})
+
+	t.Run("profile systemd on-failure", func(t *testing.T) {
+		p, _ := profile(t, `
+			[global.schedule-defaults]
+			systemd-on-failure = true
+
+			[default.backup]
+			schedule = "daily"
+
+			[default.check]
+			schedule = "monthly"
+			schedule-systemd-on-failure = false
+		`)
+
+		assert.True(t, p.Schedules()["backup"].SystemdOnFailure.IsTrue())
+		assert.True(t, p.Schedules()["check"].SystemdOnFailure.IsStrictlyFalse())
+	})
+
+	t.Run("profile systemd watchdog", func(t *testing.T) {
+		p, _ := profile(t, `
+			[global.schedule-defaults]
+			systemd-watchdog = "15m"
+
+			[default.backup]
+			schedule = "daily"
+
+			[default.check]
+			schedule = "monthly"
+			schedule-systemd-watchdog = "1h"
+		`)
+
+		assert.Equal(t, 15*time.Minute, p.Schedules()["backup"].SystemdWatchdog.Value())
+		assert.Equal(t, time.Hour, p.Schedules()["check"].SystemdWatchdog.Value())
+	})
+
 	t.Run("profile drop-in overrides", func(t *testing.T) {
 		p, _ := profile(t, `
 			[global.schedule-defaults]
@@ -378,6 +484,31 @@ func TestLockWait(t *testing.T) {
 	}
 }
 
+func TestStartDelay(t *testing.T) {
+	s := NewDefaultSchedule(nil, ScheduleOrigin("profile", "backup"))
+	assert.Zero(t, s.GetRandomizedDelay())
+	assert.Zero(t, s.GetStartDelay("host"))
+
+	s.RandomizedDelay = maybe.SetDuration(time.Hour)
+	assert.Equal(t, time.Hour, s.GetRandomizedDelay())
+	for range 10 {
+		delay := s.GetStartDelay("host")
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, time.Hour)
+	}
+
+	s.FixedRandomDelay = maybe.True()
+	delay := s.GetStartDelay("host")
+	assert.Less(t, delay, time.Hour)
+	assert.Equal(t, delay, s.GetStartDelay("host"))
+	assert.NotEqual(t, delay, s.GetStartDelay("other-host"))
+
+	other := NewDefaultSchedule(nil, ScheduleOrigin("profile", "check"))
+	other.RandomizedDelay = maybe.SetDuration(time.Hour)
+	other.FixedRandomDelay = maybe.True()
+	assert.NotEqual(t, delay, other.GetStartDelay("host"))
+}
+
 func TestScheduleFlags(t *testing.T) {
 	schedule := &Schedule{}
 
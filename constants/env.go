@@ -9,4 +9,14 @@ const (
 	EnvErrorExitCode    = "ERROR_EXIT_CODE"
 	EnvErrorStderr      = "ERROR_STDERR"
 	EnvScheduleId       = "RESTICPROFILE_SCHEDULE_ID"
+	EnvCredentialsDir   = "CREDENTIALS_DIRECTORY"
+	EnvRunSlot          = "RESTICPROFILE_RUN_SLOT"
+	// variable set by systemd on a unit
+	EnvInvocationID = "INVOCATION_ID"
+	// variables set by systemd on the "OnFailure" unit
+	EnvMonitorUnit          = "MONITOR_UNIT"
+	EnvMonitorServiceResult = "MONITOR_SERVICE_RESULT"
+	EnvMonitorExitCode      = "MONITOR_EXIT_CODE"
+	EnvMonitorExitStatus    = "MONITOR_EXIT_STATUS"
+	EnvMonitorInvocationID  = "MONITOR_INVOCATION_ID"
 )
@@ -22,6 +22,12 @@ const (
 	MinResticStaleLockAge          = 15 * time.Minute
 )
 
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 // Schedule lock mode config options
 const (
 	ScheduleLockModeOptionFail   = "fail"
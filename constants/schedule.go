@@ -4,3 +4,9 @@ const (
 	SchedulableKindProfile = "profile"
 	SchedulableKindGroup   = "group"
 )
+
+// ScheduleNameSeparator separates the command from the name of a named schedule ("command.name")
+const ScheduleNameSeparator = "."
+
+// CredentialPassword is the ID of the systemd credential containing the password of the repository
+const CredentialPassword = "restic-password"
@@ -6,6 +6,7 @@ import (
 
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/otel"
 	"github.com/creativeprojects/resticprofile/term"
 )
 
@@ -29,14 +30,18 @@ type Context struct {
 	command       string // which restic command to use
 	profile       *config.Profile
 	schedule      *config.Schedule // when profile is running with run-schedule command
+	scheduleEntry *config.Schedule // schedule started with run-schedule, also kept for the profiles of a group
 	sigChan       chan os.Signal   // termination request
 	logTarget     string           // where to send the log output
+	logFormat     string           // format of the log output (text or json)
 	commandOutput string           // where to send the command output when a lotTarget is set
 	stopOnBattery int              // stop if running on battery
 	noLock        bool             // skip profile lock file
+	failureUnit   bool             // "send-after-fail" is sent by the systemd OnFailure unit of the schedule
 	lockWait      time.Duration    // wait up to duration to acquire a lock
 	legacyArgs    bool             // I'm not even sure it's been used by anyone?
 	terminal      *term.Terminal
+	traceSpan     *otel.Span // OpenTelemetry span of the profile run (when enabled)
 }
 
 func CreateContext(flags commandLineFlags, global *config.Global, cfg *config.Config, ownCommands *OwnCommands) (*Context, error) {
@@ -63,8 +68,10 @@ func CreateContext(flags commandLineFlags, global *config.Global, cfg *config.Co
 		command:       "",
 		profile:       nil,
 		schedule:      nil,
+		scheduleEntry: nil,
 		sigChan:       nil,
 		logTarget:     global.Log, // default to global (which can be empty)
+		logFormat:     global.LogFormat,
 		commandOutput: global.CommandOutput,
 		legacyArgs:    global.LegacyArguments, // use the broken arguments escaping (before v0.15.0)
 	}
@@ -79,6 +86,9 @@ func CreateContext(flags commandLineFlags, global *config.Global, cfg *config.Co
 	if flags.log != "" {
 		ctx.logTarget = flags.log
 	}
+	if flags.logFormat != "" {
+		ctx.logFormat = flags.logFormat
+	}
 	if flags.commandOutput != constants.DefaultCommandOutput {
 		ctx.commandOutput = flags.commandOutput
 	}
@@ -137,6 +147,13 @@ func (c *Context) WithProfile(profileName string) *Context {
 	return newContext
 }
 
+// WithTraceSpan sets the OpenTelemetry span of the profile run. A new copy of the context is returned.
+func (c *Context) WithTraceSpan(span *otel.Span) *Context {
+	newContext := c.clone()
+	newContext.traceSpan = span
+	return newContext
+}
+
 func (c *Context) WithTerminal(terminal *term.Terminal) *Context {
 	newContext := c.clone()
 	newContext.terminal = terminal
@@ -185,12 +185,26 @@ func (c *Crontab) username() string {
 }
 
 func (c *Crontab) Rewrite() error {
-	crontab, err := c.LoadCurrent()
+	_, content, err := c.Render()
 	if err != nil {
 		return err
 	}
+	return saveCrontab(c.fs, c.file, content, c.charset, c.binary)
+}
+
+// Render returns the current content of the crontab and the content after adding the entries, without saving it
+func (c *Crontab) Render() (current, content string, err error) {
+	current, err = c.LoadCurrent()
+	if err != nil {
+		return
+	}
+	content, err = c.RenderFrom(current)
+	return
+}
 
-	if len(c.file) > 0 && detectNeedsUserColumn(crontab) {
+// RenderFrom returns the content of the crontab after adding the entries to current, without saving it
+func (c *Crontab) RenderFrom(current string) (content string, err error) {
+	if len(c.file) > 0 && detectNeedsUserColumn(current) {
 		for i, entry := range c.entries {
 			if !entry.HasUser() {
 				c.entries[i] = entry.WithUser(c.username())
@@ -199,12 +213,12 @@ func (c *Crontab) Rewrite() error {
 	}
 
 	buffer := new(strings.Builder)
-	_, err = c.update(crontab, true, buffer)
+	_, err = c.update(current, true, buffer)
 	if err != nil {
-		return err
+		return
 	}
-
-	return saveCrontab(c.fs, c.file, buffer.String(), c.charset, c.binary)
+	content = buffer.String()
+	return
 }
 
 func (c *Crontab) Remove() (int, error) {
@@ -200,6 +200,28 @@ func TestFromFile(t *testing.T) {
 	assert.Contains(t, result, "01 01 * * *\tresticprofile backup")
 }
 
+func TestRenderDoesNotSave(t *testing.T) {
+	file, err := filepath.Abs(filepath.Join(t.TempDir(), "crontab"))
+	require.NoError(t, err)
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, file, []byte("# existing content\n"), 0o644))
+
+	crontab := NewCrontab([]Entry{NewEntry(calendar.NewEvent(func(event *calendar.Event) {
+		event.Minute.MustAddValue(1)
+		event.Hour.MustAddValue(1)
+	}), "", "", "", "resticprofile backup", "")}).SetFs(fs).SetFile(file)
+
+	current, content, err := crontab.Render()
+	require.NoError(t, err)
+	assert.Equal(t, "# existing content\n", current)
+	assert.Equal(t, "# existing content\n\n"+startMarker+"01 01 * * *\tresticprofile backup\n"+endMarker, content)
+
+	result, err := crontab.LoadCurrent()
+	require.NoError(t, err)
+	assert.Equal(t, current, result)
+}
+
 func getExpectedUser(crontab *Crontab) (expectedUser string) {
 	if c, e := user.Current(); e != nil || strings.ContainsAny(c.Username, "\n \r\n") {
 		expectedUser = "testuser"
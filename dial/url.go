@@ -13,11 +13,13 @@ var validSchemes = []string{
 	"tcp",
 	"syslog",     // local or UDP
 	"syslog-tcp", // TCP
-	// "syslog-tls", reserved for future support
+	"syslog-tls", // TLS (RFC 5425)
+	"journald",   // local systemd journal
 }
 
 var noHostAllowed = []string{
 	"syslog",
+	"journald",
 }
 
 // GetAddr returns scheme, host&port, is(Supported)URL
@@ -23,17 +23,22 @@ func TestGetDialAddr(t *testing.T) {
 		{"udp://:123", "udp", ":123", true},
 		{"syslog://:123", "syslog", ":123", true},
 		{"syslog-tcp://:123", "syslog-tcp", ":123", true},
+		{"syslog-tls://host:6514", "syslog-tls", "host:6514", true},
 		// url
 		{"syslog://:123", "syslog", ":123", true},
 		{"syslog://host:123", "syslog", "host:123", true},
 		{"syslog://host", "syslog", "host", true},
 		{"syslog://", "syslog", "", true},
 		{"syslog:", "syslog", "", true},
+		{"journald:", "journald", "", true},
+		{"journald://", "journald", "", true},
 		// too short
 		{"syslog:opaque", "", "", false},
+		{"journald:opaque", "", "", false},
 		{"tcp://", "", "", false},
 		{"tcp:", "", "", false},
 		{"syslog-tcp:", "", "", false},
+		{"syslog-tls:", "", "", false},
 		{"udp:", "", "", false},
 		{"c://", "", "", false},
 		{"c://:", "", "", false},
@@ -24,6 +24,7 @@ type commandLineFlags struct {
 	format          string
 	name            string
 	log             string // file path or log url
+	logFormat       string // text or json
 	commandOutput   string
 	dryRun          bool
 	noLock          bool
@@ -36,6 +37,8 @@ type commandLineFlags struct {
 	stderr          bool
 	parentPort      int
 	noPriority      bool
+	noRandomDelay   bool
+	catchUp         bool
 	ignoreOnBattery int
 	usagesHelp      string
 	remote          string // url of the remote server to download configuration files from
@@ -83,6 +86,7 @@ func loadFlags(args []string) (*pflag.FlagSet, commandLineFlags, error) {
 		format:          envValueOverride("", "RESTICPROFILE_FORMAT"),
 		name:            envValueOverride(constants.DefaultProfileName, "RESTICPROFILE_NAME"),
 		log:             envValueOverride("", "RESTICPROFILE_LOG"),
+		logFormat:       envValueOverride("", "RESTICPROFILE_LOG_FORMAT"),
 		commandOutput:   envValueOverride(constants.DefaultCommandOutput, "RESTICPROFILE_COMMAND_OUTPUT"),
 		dryRun:          envValueOverride(false, "RESTICPROFILE_DRY_RUN"),
 		noLock:          envValueOverride(false, "RESTICPROFILE_NO_LOCK"),
@@ -91,6 +95,8 @@ func loadFlags(args []string) (*pflag.FlagSet, commandLineFlags, error) {
 		noAnsi:          envValueOverride(false, "RESTICPROFILE_NO_ANSI"),
 		theme:           envValueOverride(constants.DefaultTheme, "RESTICPROFILE_THEME"),
 		noPriority:      envValueOverride(false, "RESTICPROFILE_NO_PRIORITY"),
+		noRandomDelay:   envValueOverride(false, "RESTICPROFILE_NO_RANDOM_DELAY"),
+		catchUp:         envValueOverride(false, "RESTICPROFILE_CATCH_UP"),
 		wait:            envValueOverride(false, "RESTICPROFILE_WAIT"),
 		ignoreOnBattery: envValueOverride(0, "RESTICPROFILE_IGNORE_ON_BATTERY"),
 		remote:          envValueOverride("", "RESTICPROFILE_REMOTE"),
@@ -104,6 +110,7 @@ func loadFlags(args []string) (*pflag.FlagSet, commandLineFlags, error) {
 	flagset.StringVarP(&flags.format, "format", "f", flags.format, "file format of the configuration (default is to use the file extension)")
 	flagset.StringVarP(&flags.name, "name", "n", flags.name, "profile name")
 	flagset.StringVarP(&flags.log, "log", "l", flags.log, "logs to a target instead of the console (file, syslog:[//server])")
+	flagset.StringVar(&flags.logFormat, "log-format", flags.logFormat, "format of the log output (text, json)")
 	flagset.StringVar(&flags.commandOutput, "command-output", flags.commandOutput, "redirect command output when a log target is specified (log, console, all)")
 	flagset.BoolVar(&flags.dryRun, "dry-run", flags.dryRun, "display the restic commands instead of running them")
 	flagset.BoolVar(&flags.noLock, "no-lock", flags.noLock, "skip profile lock file")
@@ -112,6 +119,8 @@ func loadFlags(args []string) (*pflag.FlagSet, commandLineFlags, error) {
 	flagset.BoolVar(&flags.noAnsi, "no-ansi", flags.noAnsi, "disable ansi control characters (disable console colouring)")
 	flagset.StringVar(&flags.theme, "theme", flags.theme, "console colouring theme (dark, light, none)")
 	flagset.BoolVar(&flags.noPriority, "no-prio", flags.noPriority, "don't change the process priority: used when started from a service that has already set the priority")
+	flagset.BoolVar(&flags.noRandomDelay, "no-random-delay", flags.noRandomDelay, "don't delay the start of a scheduled run: used when started from a service that has already delayed it")
+	flagset.BoolVar(&flags.catchUp, "catch-up", flags.catchUp, "only start a scheduled run when it was missed since the last run: used by the crontab entry catching up missed schedules")
 	flagset.BoolVarP(&flags.wait, "wait", "w", flags.wait, "wait at the end until the user presses the enter key")
 	flagset.IntVar(&flags.ignoreOnBattery, "ignore-on-battery", flags.ignoreOnBattery, "don't start the profile when the computer is running on battery. You can specify a value to ignore only when the % charge left is less or equal than the value")
 	flagset.Lookup("ignore-on-battery").NoOptDefVal = "100" // 0 is flag not set, 100 is for a flag with no value (meaning just battery discharge)
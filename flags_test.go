@@ -66,6 +66,7 @@ func TestEnvOverrides(t *testing.T) {
 		format:          setEnv("custom-format", "RESTICPROFILE_FORMAT").(string),
 		name:            setEnv("custom-profile", "RESTICPROFILE_NAME").(string),
 		log:             setEnv("custom.log", "RESTICPROFILE_LOG").(string),
+		logFormat:       setEnv("json", "RESTICPROFILE_LOG_FORMAT").(string),
 		commandOutput:   setEnv("log", "RESTICPROFILE_COMMAND_OUTPUT").(string),
 		dryRun:          setEnv(true, "RESTICPROFILE_DRY_RUN").(bool),
 		noLock:          setEnv(true, "RESTICPROFILE_NO_LOCK").(bool),
@@ -74,6 +75,8 @@ func TestEnvOverrides(t *testing.T) {
 		noAnsi:          setEnv(true, "RESTICPROFILE_NO_ANSI").(bool),
 		theme:           setEnv("custom-theme", "RESTICPROFILE_THEME").(string),
 		noPriority:      setEnv(true, "RESTICPROFILE_NO_PRIORITY").(bool),
+		noRandomDelay:   setEnv(true, "RESTICPROFILE_NO_RANDOM_DELAY").(bool),
+		catchUp:         setEnv(true, "RESTICPROFILE_CATCH_UP").(bool),
 		wait:            setEnv(true, "RESTICPROFILE_WAIT").(bool),
 		ignoreOnBattery: setEnv(50, "RESTICPROFILE_IGNORE_ON_BATTERY").(int),
 	}
@@ -0,0 +1,153 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+// journalSocket is the socket of the native journal protocol
+var journalSocket = "/run/systemd/journal/socket"
+
+// Journald sends log entries to the systemd journal using the native protocol, with the context of the run as journal fields
+type Journald struct {
+	mutex  sync.Mutex
+	conn   *net.UnixConn
+	socket *net.UnixAddr
+	fields *logFields
+	json   bool // messages are JSON records
+}
+
+func NewJournaldHandler(logFormat string) (*Journald, error) {
+	if _, err := os.Stat(journalSocket); err != nil {
+		return nil, fmt.Errorf("systemd journal is not available: %w", err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("cannot open journal socket: %w", err)
+	}
+	return &Journald{
+		conn:   conn,
+		socket: &net.UnixAddr{Name: journalSocket, Net: "unixgram"},
+		fields: jsonLogFields,
+		json:   logFormat == constants.LogFormatJSON,
+	}, nil
+}
+
+func (j *Journald) LogEntry(entry clog.LogEntry) error {
+	message := entry.GetMessage()
+	if j.json {
+		message = string(j.fields.encode(entry.Level, "", message))
+	}
+	return j.send(syslogSeverity(entry.Level), message)
+}
+
+// Write sends a line of command output with the "notice" priority
+func (j *Journald) Write(p []byte) (int, error) {
+	message := strings.TrimRight(string(p), "\r\n")
+	if strings.TrimSpace(message) == "" {
+		return len(p), nil
+	}
+	return len(p), j.send(syslogSeverity(-1), message)
+}
+
+func (j *Journald) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.conn == nil {
+		return nil
+	}
+	err := j.conn.Close()
+	j.conn = nil
+	return err
+}
+
+func (j *Journald) send(priority int, message string) error {
+	j.fields.mutex.RLock()
+	fields := [][2]string{
+		{"MESSAGE", message},
+		{"PRIORITY", strconv.Itoa(priority)},
+		{"SYSLOG_IDENTIFIER", constants.ApplicationName},
+		{"RESTICPROFILE_PROFILE", j.fields.profile},
+		{"RESTICPROFILE_GROUP", j.fields.group},
+		{"RESTICPROFILE_COMMAND", j.fields.command},
+		{"RESTICPROFILE_RUN_ID", j.fields.runID},
+	}
+	j.fields.mutex.RUnlock()
+
+	data := &bytes.Buffer{}
+	for _, field := range fields {
+		if field[1] != "" {
+			writeJournalField(data, field[0], field[1])
+		}
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.conn == nil {
+		return errors.New("journal handler is closed")
+	}
+	_, _, err := j.conn.WriteMsgUnix(data.Bytes(), nil, j.socket)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		// too large for a datagram: send the entry in a file descriptor instead
+		err = j.sendFile(data.Bytes())
+	}
+	return err
+}
+
+// sendFile passes the entry to journald in an unlinked temporary file (as sd_journal_sendv does)
+func (j *Journald) sendFile(data []byte) error {
+	dir := "/dev/shm"
+	if _, err := os.Stat(dir); err != nil {
+		dir = os.TempDir()
+	}
+	file, err := os.CreateTemp(dir, "journal.*")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_ = os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		return err
+	}
+	_, _, err = j.conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), j.socket)
+	return err
+}
+
+// writeJournalField encodes a field as "KEY=value\n", or as "KEY\n" followed by the 64-bit little endian size and the value when it contains a new line
+func writeJournalField(writer io.Writer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		_, _ = fmt.Fprintf(writer, "%s=%s\n", key, value)
+		return
+	}
+	_, _ = fmt.Fprintf(writer, "%s\n", key)
+	_ = binary.Write(writer, binary.LittleEndian, uint64(len(value)))
+	_, _ = io.WriteString(writer, value+"\n")
+}
+
+func getJournaldHandler(hostPort, logFormat string) (LogCloser, io.Writer, error) {
+	if hostPort != "" {
+		return nil, nil, fmt.Errorf("journald log target does not accept a host: %q", hostPort)
+	}
+	handler, err := NewJournaldHandler(logFormat)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handler, &tokenWriter{separator: []byte("\n"), target: handler}, nil
+}
+
+var _ LogCloser = &Journald{}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+func getJournaldHandler(hostPort, logFormat string) (_ LogCloser, _ io.Writer, err error) {
+	err = errors.New("journald is only available on Linux")
+	return
+}
@@ -0,0 +1,139 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenJournal replaces the journal socket with a test socket
+func listenJournal(t *testing.T) *net.UnixConn {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	defaultSocket := journalSocket
+	journalSocket = socket
+	t.Cleanup(func() { journalSocket = defaultSocket })
+	return conn
+}
+
+// readJournalEntry reads one entry sent in a datagram or in a file descriptor
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	data := make([]byte, 1024*1024)
+	oob := make([]byte, 1024)
+	n, oobn, _, _, err := conn.ReadMsgUnix(data, oob)
+	require.NoError(t, err)
+	data = data[:n]
+
+	if oobn > 0 {
+		messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		fds, err := syscall.ParseUnixRights(&messages[0])
+		require.NoError(t, err)
+		require.Len(t, fds, 1)
+		file := os.NewFile(uintptr(fds[0]), "journal")
+		defer file.Close()
+		_, err = file.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		data, err = io.ReadAll(file)
+		require.NoError(t, err)
+	}
+
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n')
+		require.GreaterOrEqual(t, end, 0)
+		line := string(data[:end])
+		data = data[end+1:]
+		if key, value, found := strings.Cut(line, "="); found {
+			fields[key] = value
+			continue
+		}
+		// binary encoded value
+		size := binary.LittleEndian.Uint64(data[:8])
+		fields[line] = string(data[8 : 8+size])
+		data = data[8+size+1:]
+	}
+	return fields
+}
+
+func TestJournaldHandler(t *testing.T) {
+	conn := listenJournal(t)
+	jsonLogFields.set("home", "", "backup")
+	defer jsonLogFields.set("", "", "")
+
+	handler, writer, err := getJournaldHandler("", constants.LogFormatText)
+	require.NoError(t, err)
+	defer handler.Close()
+
+	require.NoError(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelWarning, Format: "value is %d", Values: []any{10}}))
+	fields := readJournalEntry(t, conn)
+	assert.Equal(t, map[string]string{
+		"MESSAGE":               "value is 10",
+		"PRIORITY":              "4",
+		"SYSLOG_IDENTIFIER":     "resticprofile",
+		"RESTICPROFILE_PROFILE": "home",
+		"RESTICPROFILE_COMMAND": "backup",
+		"RESTICPROFILE_RUN_ID":  jsonLogFields.runID,
+	}, fields)
+
+	require.NoError(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelError, Format: "first line\nsecond line"}))
+	fields = readJournalEntry(t, conn)
+	assert.Equal(t, "first line\nsecond line", fields["MESSAGE"])
+	assert.Equal(t, "3", fields["PRIORITY"])
+
+	_, err = writer.Write([]byte("restic output\r\n\n"))
+	require.NoError(t, err)
+	fields = readJournalEntry(t, conn)
+	assert.Equal(t, "restic output", fields["MESSAGE"])
+	assert.Equal(t, "5", fields["PRIORITY"])
+
+	require.NoError(t, handler.Close())
+	assert.Error(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelInfo, Format: "closed"}))
+}
+
+func TestJournaldLargeEntry(t *testing.T) {
+	conn := listenJournal(t)
+	handler, _, err := getJournaldHandler("", constants.LogFormatJSON)
+	require.NoError(t, err)
+	defer handler.Close()
+
+	message := strings.Repeat("a", 512*1024)
+	require.NoError(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelInfo, Format: message}))
+	fields := readJournalEntry(t, conn)
+	assert.Equal(t, "6", fields["PRIORITY"])
+	assert.True(t, strings.HasPrefix(fields["MESSAGE"], `{"time":`))
+	assert.Contains(t, fields["MESSAGE"], message)
+}
+
+func TestJournaldNotAvailable(t *testing.T) {
+	defaultSocket := journalSocket
+	journalSocket = filepath.Join(t.TempDir(), "missing")
+	defer func() { journalSocket = defaultSocket }()
+
+	_, _, err := getJournaldHandler("", "")
+	assert.ErrorContains(t, err, "systemd journal is not available")
+
+	_, _, err = getJournaldHandler("host", "")
+	assert.ErrorContains(t, err, "does not accept a host")
+}
@@ -15,24 +15,44 @@ import (
 )
 
 // lockRun is making sure the function is only run once by putting a lockfile on the disk
-func lockRun(lockFile string, force bool, lockWait *time.Duration, sigChan <-chan os.Signal, run func(setPID lock.SetPID) error) error {
+func lockRun(lockFile, profileName, command string, shared, force bool, lockWait *time.Duration, sigChan <-chan os.Signal, run func(setPID lock.SetPID) error) error {
+	runLock, err := acquireLock("profile", lockFile, profileName, command, shared, force, lockWait, sigChan)
+	if err != nil {
+		return err
+	}
 	// No lock
-	if lockFile == "" {
+	if runLock == nil {
 		return run(nil)
 	}
 
+	// Run locked
+	defer runLock.Release()
+	return run(runLock.SetPID)
+}
+
+// acquireLock acquires the lock file of a profile or a repository (subject).
+// It returns a nil lock when no lock file is needed, or when it cannot be created.
+func acquireLock(subject, lockFile, profileName, command string, shared, force bool, lockWait *time.Duration, sigChan <-chan os.Signal) (*lock.Lock, error) {
+	// No lock
+	if lockFile == "" {
+		return nil, nil
+	}
+
 	// Make sure the path to the lock exists
 	if dir := filepath.Dir(lockFile); dir != "" {
 		err := os.MkdirAll(dir, 0755)
 		if err != nil {
-			clog.Warningf("the profile will run without a lockfile: %v", err)
-			return run(nil)
+			clog.Warningf("the %s will run without a lockfile: %v", subject, err)
+			return nil, nil
 		}
 	}
 
 	// Acquire lock
-	runLock := lock.NewLock(lockFile)
+	runLock := lock.NewLock(lockFile).WithOwner(profileName, command).WithShared(shared)
 	success := runLock.TryAcquire()
+	if err := runLock.LastError(); !success && err != nil {
+		return nil, fmt.Errorf("cannot create the lock file of the %s: %w", subject, err)
+	}
 	start := time.Now()
 	locker := ""
 	lockWaitLogged := time.Unix(0, 0)
@@ -46,7 +66,7 @@ func lockRun(lockFile string, force bool, lockWait *time.Duration, sigChan <-cha
 		} else if errors.Is(err, fs.ErrNotExist) {
 			locker = "none"
 		} else {
-			return fmt.Errorf("another process left the lockfile unreadable: %w", err)
+			return nil, fmt.Errorf("another process left the lockfile unreadable: %w", err)
 		}
 
 		// should we try to force our way?
@@ -54,36 +74,90 @@ func lockRun(lockFile string, force bool, lockWait *time.Duration, sigChan <-cha
 			success = runLock.ForceAcquire()
 
 			if lockWait == nil || success {
-				clog.Warningf("previous run of the profile started by %s hasn't finished properly", locker)
+				clog.Warningf("previous run of the %s started by %s hasn't finished properly", subject, locker)
 			}
 		} else {
 			success = runLock.TryAcquire()
 		}
+		if err := runLock.LastError(); !success && err != nil {
+			return nil, fmt.Errorf("cannot create the lock file of the %s: %w", subject, err)
+		}
 
 		// Retry or return?
 		if !success {
 			if lockWait == nil {
-				return fmt.Errorf("another process is already running this profile: %s", locker)
+				return nil, fmt.Errorf("another process is already running this %s: %s", subject, locker)
 			}
 			if time.Since(start) < *lockWait {
 				lockName := fmt.Sprintf("%s locked by %s", lockFile, locker)
 				lockWaitLogged = logLockWait(lockName, start, lockWaitLogged, 0, *lockWait)
 
 				sleep := min(constants.LocalLockRetryDelay, *lockWait)
+				notifyWait("waiting for "+lockName, sleep)
 				err := interruptibleSleep(sleep, sigChan)
 				if err != nil {
-					return err
+					return nil, err
 				}
 			} else {
-				clog.Warningf("previous run of the profile hasn't finished after %s", *lockWait)
+				clog.Warningf("previous run of the %s hasn't finished after %s", subject, *lockWait)
 				lockWait = nil
 			}
 		}
 	}
+	return runLock, nil
+}
 
-	// Run locked
-	defer runLock.Release()
-	return run(runLock.SetPID)
+// acquireSlot waits until one of the maxRuns slots of the host is free (up to wait, or indefinitely when wait is zero).
+// The slot is held until release is called.
+func acquireSlot(slotDir string, maxRuns int, profileName, command string, wait time.Duration, sigChan <-chan os.Signal) (release func(), err error) {
+	release = func() {}
+	// a resticprofile process started from a hook of a run holding a slot doesn't need another slot
+	if maxRuns <= 0 || os.Getenv(constants.EnvRunSlot) != "" {
+		return
+	}
+
+	semaphore := lock.NewSemaphore(slotDir, maxRuns).WithOwner(profileName, command)
+	start := time.Now()
+	waitLogged := time.Unix(0, 0)
+	for {
+		slot, err := semaphore.TryAcquire()
+		if err != nil {
+			return release, fmt.Errorf("cannot acquire a slot of the concurrent runs: %w", err)
+		}
+		if slot != nil {
+			_ = os.Setenv(constants.EnvRunSlot, slot.Lockfile)
+			release = func() {
+				_ = os.Unsetenv(constants.EnvRunSlot)
+				slot.Release()
+			}
+			return release, nil
+		}
+
+		holders := describeSlotHolders(semaphore.Holders())
+		if wait > 0 && time.Since(start) >= wait {
+			return release, fmt.Errorf("%d concurrent runs still in progress after %s: %s", maxRuns, wait, holders)
+		}
+		if time.Since(waitLogged) > logLockWaitEvery {
+			clog.Infof("waiting for one of the %d concurrent runs to finish (waited %s): %s", maxRuns, time.Since(start).Truncate(time.Second), holders)
+			waitLogged = time.Now()
+		}
+		sleep := constants.LocalLockRetryDelay
+		if wait > 0 {
+			sleep = min(sleep, wait)
+		}
+		notifyWait(fmt.Sprintf("waiting for one of the %d concurrent runs to finish", maxRuns), sleep)
+		if err = interruptibleSleep(sleep, sigChan); err != nil {
+			return release, err
+		}
+	}
+}
+
+func describeSlotHolders(holders []*lock.Info) string {
+	descriptions := make([]string, 0, len(holders))
+	for _, info := range holders {
+		descriptions = append(descriptions, fmt.Sprintf("profile %q running %q (pid %d)", info.Profile, info.Command, info.PID))
+	}
+	return strings.Join(descriptions, ", ")
 }
 
 const logLockWaitEvery = 5 * time.Minute
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// flock places a shared or an exclusive advisory lock on the file
+func flock(file *os.File, shared, wait bool) error {
+	how := unix.LOCK_EX
+	if shared {
+		how = unix.LOCK_SH
+	}
+	if !wait {
+		how |= unix.LOCK_NB
+	}
+	for {
+		err := unix.Flock(int(file.Fd()), how)
+		if !errors.Is(err, unix.EINTR) {
+			return err
+		}
+	}
+}
+
+// funlock removes the advisory lock of the file
+func funlock(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
+
+// removeLocked removes the lock file while it is still locked: other processes
+// which opened the same file will detect it was removed from the path.
+func removeLocked(file *os.File, filename string) {
+	_ = os.Remove(filename)
+	_ = file.Close()
+}
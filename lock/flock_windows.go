@@ -0,0 +1,44 @@
+//go:build windows
+
+package lock
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// the locked byte is far beyond the content of the file, which stays readable and writable by the holders of a shared lock
+const (
+	lockRange      = 1
+	lockOffsetHigh = 0x7fffffff
+)
+
+func lockRegion() *windows.Overlapped {
+	return &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+}
+
+// flock places a shared or an exclusive lock on the file
+func flock(file *os.File, shared, wait bool) error {
+	flags := uint32(0)
+	if !wait {
+		flags |= windows.LOCKFILE_FAIL_IMMEDIATELY
+	}
+	if !shared {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	return windows.LockFileEx(windows.Handle(file.Fd()), flags, 0, lockRange, 0, lockRegion())
+}
+
+// funlock removes the lock of the file
+func funlock(file *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, lockRange, 0, lockRegion())
+}
+
+// removeLocked removes the lock file. An open file cannot be removed on Windows: the file is closed first,
+// and it stays in place when another process has it open.
+func removeLocked(file *os.File, filename string) {
+	_ = funlock(file)
+	_ = file.Close()
+	_ = os.Remove(filename)
+}
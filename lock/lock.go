@@ -1,12 +1,16 @@
 package lock
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/user"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/process"
@@ -15,13 +19,144 @@ import (
 // SetPID is a callback that writes the PID in the lockfile
 type SetPID func(pid int32)
 
+// Info is the content of a lock file
+type Info struct {
+	User      string    `json:"user"`
+	Host      string    `json:"host"`
+	PID       int32     `json:"pid,omitempty"` // resticprofile process holding the lock
+	ChildPIDs []int32   `json:"restic_pids,omitempty"`
+	Profile   string    `json:"profile,omitempty"`
+	Command   string    `json:"command,omitempty"`
+	Shared    bool      `json:"shared,omitempty"` // shared lock of a read-only command
+	Started   time.Time `json:"started"`
+	legacy    string    // "who" line of a lock file written by a previous version
+}
+
+// Who returns a description of the owner of the lock
+func (i *Info) Who() string {
+	if i.legacy != "" {
+		return i.legacy
+	}
+	return fmt.Sprintf("%s on %s from %s", i.User, i.Started.Format(time.RFC850), i.Host)
+}
+
+// StaleReason returns why the lock looks abandoned, or an empty string when the lock may still be in use
+func (i *Info) StaleReason() string {
+	if i.IsOtherHost() {
+		return fmt.Sprintf("created on another host %q", i.Host)
+	}
+	pids := i.ChildPIDs
+	if i.PID > 0 {
+		pids = append([]int32{i.PID}, pids...)
+	}
+	if len(pids) == 0 {
+		// the owner cannot be verified
+		return ""
+	}
+	for _, pid := range pids {
+		if running, err := process.PidExists(pid); err != nil || running {
+			return ""
+		}
+	}
+	return "process is not running"
+}
+
+// IsOtherHost returns true when the lock was created on another host
+func (i *Info) IsOtherHost() bool {
+	hostname, err := os.Hostname()
+	return i.Host != "" && err == nil && !strings.EqualFold(hostname, i.Host)
+}
+
+// ReadInfo reads the content of a lock file (in the current or the legacy format)
+func ReadInfo(filename string) (*Info, error) {
+	buffer, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(buffer)
+}
+
+// RemoveIfStale removes the lock file when it looks abandoned. Locks created on another host are only removed when otherHost is true.
+// It returns the reason why the lock was considered stale.
+func RemoveIfStale(filename string, otherHost bool) (reason string, err error) {
+	buffer, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	info, err := parseInfo(buffer)
+	if err != nil {
+		return "", err
+	}
+	reason = info.StaleReason()
+	if reason == "" {
+		return "", errors.New("lock is in use")
+	}
+	if info.IsOtherHost() && !otherHost {
+		return reason, errors.New("lock was created on another host")
+	}
+	if err = removeIfUnlocked(filename, buffer); err != nil {
+		return "", err
+	}
+	return reason, nil
+}
+
+// removeIfUnlocked removes the lock file when no process holds a lock on it, and its content is still the same
+func removeIfUnlocked(filename string, content []byte) error {
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if err = flock(file, false, false); err != nil {
+		_ = file.Close()
+		return errors.New("lock is in use")
+	}
+	// make sure the lock hasn't been acquired again in the meantime
+	if current, err := os.ReadFile(filename); err != nil || !bytes.Equal(current, content) || !isLockedFile(file, filename) {
+		_ = file.Close()
+		return errors.New("lock has changed")
+	}
+	removeLocked(file, filename)
+	return nil
+}
+
+func parseInfo(buffer []byte) (*Info, error) {
+	info := &Info{}
+	if bytes.HasPrefix(bytes.TrimSpace(buffer), []byte("{")) {
+		if err := json.Unmarshal(buffer, info); err != nil {
+			return nil, fmt.Errorf("invalid lock file: %w", err)
+		}
+		return info, nil
+	}
+	// legacy format: first line is "who" owns the lock, any subsequent line contains a restic PID
+	contents := strings.Split(string(buffer), "\n")
+	info.legacy = contents[0]
+	for _, line := range contents[1:] {
+		if pid, err := strconv.ParseInt(strings.TrimSpace(line), 10, 32); err == nil {
+			info.ChildPIDs = append(info.ChildPIDs, int32(pid))
+		}
+	}
+	return info, nil
+}
+
 // Lock prevents code to run at the same time by using a lockfile
 type Lock struct {
 	Lockfile string
 	file     *os.File
 	locked   bool
+	shared   bool
+	info     Info
+	err      error
+	mutex    sync.Mutex
 }
 
+const (
+	// maxLockAttempts is the number of times the lock file is opened again when it was removed by the previous holder,
+	// or when joining a shared lock while its creator is still writing the information
+	maxLockAttempts = 10
+	// joinRetryDelay is the delay before opening the lock file again
+	joinRetryDelay = 10 * time.Millisecond
+)
+
 // NewLock creates a new lock
 func NewLock(filename string) *Lock {
 	return &Lock{
@@ -30,11 +165,32 @@ func NewLock(filename string) *Lock {
 	}
 }
 
+// WithOwner records the profile and command running under the lock
+func (l *Lock) WithOwner(profile, command string) *Lock {
+	l.info.Profile = profile
+	l.info.Command = command
+	return l
+}
+
+// WithShared sets whether the lock is shared with other holders of a shared lock (for read-only commands), or exclusive
+func (l *Lock) WithShared(shared bool) *Lock {
+	l.shared = shared
+	return l
+}
+
 // TryAcquire returns true if the lock was successfully set. It returns false if a lock already exists
 func (l *Lock) TryAcquire() bool {
 	return l.lock()
 }
 
+// LastError returns the error which prevented the last attempt from creating the lock file,
+// or nil when the lock file couldn't be created because it's already in use
+func (l *Lock) LastError() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.err
+}
+
 // ForceAcquire returns true if the lock was successfully set.
 //
 // If a lock file already exists, it reads the PID of the supposedly running process and check if
@@ -45,6 +201,15 @@ func (l *Lock) ForceAcquire() bool {
 	if l.lock() {
 		return true
 	}
+	if buffer, err := os.ReadFile(l.Lockfile); err == nil {
+		if info, err := parseInfo(buffer); err == nil && info.Shared {
+			// a shared lock is in use as long as a process holds it
+			if removeIfUnlocked(l.Lockfile, buffer) != nil {
+				return false
+			}
+			return l.lock()
+		}
+	}
 	pid, err := l.LastPID()
 	if err != nil {
 		return false
@@ -64,31 +229,29 @@ func (l *Lock) ForceAcquire() bool {
 
 // Release the lockfile
 func (l *Lock) Release() {
-	if l.file != nil {
-		_ = l.file.Close()
-	}
 	l.unlock()
 }
 
 // Who owns the lock?
 func (l *Lock) Who() (string, error) {
-	buffer, err := os.ReadFile(l.Lockfile)
+	info, err := ReadInfo(l.Lockfile)
 	if err != nil {
 		return "", err
 	}
-	// first line should be "who" owns the lock, any subsequent line will contain the restic PIDs
-	contents := strings.Split(string(buffer), "\n")
-	return contents[0], nil
+	return info.Who(), nil
 }
 
 // SetPID writes down the PID in the lock file.
 // You can run the method as many times as you want when the PID changes
 func (l *Lock) SetPID(pid int32) {
-	if !l.locked {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.locked || l.info.PID == 0 {
+		// another holder of the shared lock wrote the lock information
 		return
 	}
-	// just add the PID on a newline
-	_, _ = fmt.Fprintf(l.file, "\n%d", pid)
+	l.info.ChildPIDs = append(l.info.ChildPIDs, pid)
+	l.write()
 }
 
 // HasLocked check this instance (and only this one) has locked the file
@@ -98,33 +261,55 @@ func (l *Lock) HasLocked() bool {
 
 // LastPID returns the last PID written into the lock file.
 func (l *Lock) LastPID() (int32, error) {
-	buffer, err := os.ReadFile(l.Lockfile)
+	info, err := ReadInfo(l.Lockfile)
 	if err != nil {
 		return 0, err
 	}
-	// first line should be "who" owns the lock, any subsequent line will contain the restic PIDs
-	contents := strings.Split(string(buffer), "\n")
-	// we stop at line 1: line 0 should not contain any PID
-	for i := len(contents) - 1; i >= 1; i-- {
-		if contents[i] != "" {
-			pid, err := strconv.ParseInt(contents[i], 10, 32)
-			if err == nil {
-				return int32(pid), nil
-			}
-		}
+	if len(info.ChildPIDs) == 0 {
+		return 0, errors.New("lock file does not contain any child process information")
 	}
-	return 0, errors.New("lock file does not contain any child process information")
+	return info.ChildPIDs[len(info.ChildPIDs)-1], nil
 }
 
 func (l *Lock) lock() bool {
-	var err error
-
-	l.file, err = os.OpenFile(l.Lockfile, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
-	if err != nil {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.locked {
 		return false
 	}
-	// Leave the lock file open
+	l.err = nil
 
+	for attempt := 0; attempt < maxLockAttempts; attempt++ {
+		file, err := os.OpenFile(l.Lockfile, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			l.create(file)
+			return true
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			l.err = err
+			return false
+		}
+		if !l.shared {
+			return false
+		}
+		joined, retry := l.join()
+		if joined {
+			return true
+		}
+		if !retry {
+			return false
+		}
+		time.Sleep(joinRetryDelay)
+	}
+	return false
+}
+
+// create writes the lock information in the new lock file
+func (l *Lock) create(file *os.File) {
+	// other processes cannot join a shared lock until the information is written
+	_ = flock(file, false, true)
+	// Leave the lock file open
+	l.file = file
 	l.locked = true
 
 	username := "unknown user"
@@ -139,14 +324,111 @@ func (l *Lock) lock() bool {
 		hostname = currentHost
 	}
 
-	now := time.Now().Format(time.RFC850)
+	l.info.User = username
+	l.info.Host = hostname
+	l.info.PID = int32(os.Getpid()) //nolint:gosec
+	l.info.ChildPIDs = nil
+	l.info.Shared = l.shared
+	l.info.Started = time.Now().Truncate(time.Second)
+	l.write()
+
+	if l.shared {
+		_ = funlock(file)
+		_ = flock(file, true, false)
+	}
+}
 
+// join acquires the shared lock of an existing lock file. It returns retry when the lock file was removed in the meantime,
+// or when the creator of the lock file hasn't finished writing the information and downgrading its lock to shared.
+func (l *Lock) join() (joined, retry bool) {
+	file, err := os.OpenFile(l.Lockfile, os.O_RDWR, 0)
+	if err != nil {
+		return false, errors.Is(err, fs.ErrNotExist)
+	}
+	if err = flock(file, true, false); err != nil {
+		_ = file.Close()
+		// an exclusive lock, unless the creator of a shared lock holds it while writing the information
+		info := readWrittenInfo(l.Lockfile)
+		return false, info == nil || info.Shared
+	}
+	// the last holder may have removed the file after we opened it
+	if !isLockedFile(file, l.Lockfile) {
+		_ = file.Close()
+		return false, true
+	}
+	info := readWrittenInfo(l.Lockfile)
+	if info == nil {
+		// the creator hasn't written the information yet
+		_ = file.Close()
+		return false, true
+	}
+	if !info.Shared {
+		_ = file.Close()
+		return false, false
+	}
+	// Leave the lock file open
+	l.file = file
+	l.locked = true
+	// the information of the lock file belongs to another holder
+	l.info.PID = 0
+	return true, false
+}
+
+// readWrittenInfo returns the information of the lock file, or nil when its creator hasn't finished writing it
+func readWrittenInfo(filename string) *Info {
+	buffer, err := os.ReadFile(filename)
+	if err != nil || len(bytes.TrimSpace(buffer)) == 0 {
+		return nil
+	}
+	info, err := parseInfo(buffer)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// isLockedFile returns true when the open file is still the one at filename
+func isLockedFile(file *os.File, filename string) bool {
+	opened, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(filename)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(opened, current)
+}
+
+// write replaces the content of the lock file with the current information
+func (l *Lock) write() {
+	data, err := json.Marshal(&l.info)
+	if err != nil {
+		return
+	}
 	// No error checking... it's not a big deal if we cannot write that
-	_, _ = fmt.Fprintf(l.file, "%s on %s from %s", username, now, hostname)
-	return true
+	if n, err := l.file.WriteAt(data, 0); err == nil {
+		_ = l.file.Truncate(int64(n))
+	}
 }
 
 func (l *Lock) unlock() {
-	_ = os.Remove(l.Lockfile)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.locked {
+		return
+	}
+	if l.shared {
+		// the last holder of a shared lock removes the file
+		_ = funlock(l.file)
+		if flock(l.file, false, false) != nil {
+			_ = l.file.Close()
+			l.file = nil
+			l.locked = false
+			return
+		}
+	}
+	removeLocked(l.file, l.Lockfile)
+	l.file = nil
 	l.locked = false
 }
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sync"
 	"testing"
 	"time"
 
@@ -63,6 +65,26 @@ func TestLockIsAvailable(t *testing.T) {
 	assert.True(t, lock.TryAcquire())
 }
 
+func TestLockLastError(t *testing.T) {
+	t.Parallel()
+
+	tempfile := getTempfile(t)
+	lock := NewLock(tempfile)
+	defer lock.Release()
+	assert.True(t, lock.TryAcquire())
+	assert.NoError(t, lock.LastError())
+
+	// the lock is in use
+	other := NewLock(tempfile)
+	assert.False(t, other.TryAcquire())
+	assert.NoError(t, other.LastError())
+
+	// the lock file cannot be created
+	missing := NewLock(filepath.Join(tempfile+".missing", "file.lock"))
+	assert.False(t, missing.TryAcquire())
+	assert.ErrorIs(t, missing.LastError(), fs.ErrNotExist)
+}
+
 func TestLockIsNotAvailable(t *testing.T) {
 	t.Parallel()
 
@@ -259,3 +281,223 @@ func TestLockWithNoInterruption(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "started\nlock acquired\ntask finished\nlock released\n", buffer.String())
 }
+
+func TestLockInfo(t *testing.T) {
+	t.Parallel()
+
+	tempfile := getTempfile(t)
+	lock := NewLock(tempfile).WithOwner("home", "backup")
+	defer lock.Release()
+	require.True(t, lock.TryAcquire())
+	lock.SetPID(11)
+	lock.SetPID(12)
+
+	info, err := ReadInfo(tempfile)
+	require.NoError(t, err)
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, info.Host)
+	assert.NotEmpty(t, info.User)
+	assert.Equal(t, int32(os.Getpid()), info.PID)
+	assert.Equal(t, []int32{11, 12}, info.ChildPIDs)
+	assert.Equal(t, "home", info.Profile)
+	assert.Equal(t, "backup", info.Command)
+	assert.WithinDuration(t, time.Now(), info.Started, 2*time.Second)
+	// this process is still running
+	assert.Empty(t, info.StaleReason())
+}
+
+func TestLegacyLockFile(t *testing.T) {
+	t.Parallel()
+
+	tempfile := getTempfile(t)
+	require.NoError(t, os.WriteFile(tempfile, []byte("user on Monday, 02-Jan-06 15:04:05 MST from host\n11\n12"), 0o600))
+
+	lock := NewLock(tempfile)
+	who, err := lock.Who()
+	require.NoError(t, err)
+	assert.Equal(t, "user on Monday, 02-Jan-06 15:04:05 MST from host", who)
+	pid, err := lock.LastPID()
+	require.NoError(t, err)
+	assert.Equal(t, int32(12), pid)
+}
+
+func deadPID(t *testing.T) int32 {
+	t.Helper()
+	cmd := exec.Command(lockBinary)
+	_ = cmd.Run()
+	pid := int32(cmd.Process.Pid)
+	running, err := process.PidExists(pid)
+	require.NoError(t, err)
+	require.False(t, running)
+	return pid
+}
+
+func TestRemoveIfStale(t *testing.T) {
+	t.Parallel()
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	dead := deadPID(t)
+
+	fixtures := []struct {
+		name      string
+		info      string
+		otherHost bool
+		reason    string
+		err       string
+	}{
+		{"running", fmt.Sprintf(`{"host":%q,"pid":%d}`, hostname, os.Getpid()), false, "", "lock is in use"},
+		{"running child", fmt.Sprintf(`{"host":%q,"pid":%d,"restic_pids":[%d]}`, hostname, dead, os.Getpid()), false, "", "lock is in use"},
+		{"dead", fmt.Sprintf(`{"host":%q,"pid":%d,"restic_pids":[%d]}`, hostname, dead, dead), false, "process is not running", ""},
+		{"unknown owner", "legacy lock", false, "", "lock is in use"},
+		{"legacy dead", fmt.Sprintf("legacy lock\n%d", dead), false, "process is not running", ""},
+		{"other host", `{"host":"other-host-name","pid":1}`, false, `created on another host "other-host-name"`, "lock was created on another host"},
+		{"other host forced", `{"host":"other-host-name","pid":1}`, true, `created on another host "other-host-name"`, ""},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "lock")
+			require.NoError(t, os.WriteFile(filename, []byte(fixture.info), 0o600))
+
+			reason, err := RemoveIfStale(filename, fixture.otherHost)
+			assert.Equal(t, fixture.reason, reason)
+			if fixture.err != "" {
+				assert.EqualError(t, err, fixture.err)
+				assert.FileExists(t, filename)
+			} else {
+				assert.NoError(t, err)
+				assert.NoFileExists(t, filename)
+			}
+		})
+	}
+}
+
+func TestSharedLock(t *testing.T) {
+	t.Parallel()
+
+	tempfile := getTempfile(t)
+	first := NewLock(tempfile).WithShared(true).WithOwner("home", "snapshots")
+	require.True(t, first.TryAcquire())
+	defer first.Release()
+
+	second := NewLock(tempfile).WithShared(true).WithOwner("home", "ls")
+	require.True(t, second.TryAcquire())
+	defer second.Release()
+	second.SetPID(11) // not written: the first holder owns the information
+
+	exclusive := NewLock(tempfile).WithOwner("home", "backup")
+	assert.False(t, exclusive.TryAcquire())
+
+	info, err := ReadInfo(tempfile)
+	require.NoError(t, err)
+	assert.True(t, info.Shared)
+	assert.Equal(t, "snapshots", info.Command)
+	assert.Empty(t, info.ChildPIDs)
+
+	first.Release()
+	assert.FileExists(t, tempfile)
+	assert.False(t, exclusive.TryAcquire())
+
+	// the last holder removes the lock file
+	second.Release()
+	assert.NoFileExists(t, tempfile)
+	assert.True(t, exclusive.TryAcquire())
+	defer exclusive.Release()
+
+	shared := NewLock(tempfile).WithShared(true)
+	assert.False(t, shared.TryAcquire())
+}
+
+func TestConcurrentSharedLocks(t *testing.T) {
+	t.Parallel()
+
+	tempfile := getTempfile(t)
+	const joiners = 10
+	for round := 0; round < 20; round++ {
+		locks := make([]*Lock, joiners)
+		acquired := make([]bool, joiners)
+		start := make(chan struct{})
+		wg := sync.WaitGroup{}
+		for i := range locks {
+			locks[i] = NewLock(tempfile).WithShared(true).WithOwner("home", "snapshots")
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				acquired[i] = locks[i].TryAcquire()
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+		for i, lock := range locks {
+			assert.Truef(t, acquired[i], "round %d: shared lock %d not acquired", round, i)
+			lock.Release()
+		}
+		require.NoFileExists(t, tempfile)
+	}
+}
+
+func TestJoinSharedLockBeingCreated(t *testing.T) {
+	t.Parallel()
+
+	tempfile := getTempfile(t)
+	// the creator of the shared lock holds an exclusive lock until the information is written
+	creator, err := os.OpenFile(tempfile, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	require.NoError(t, err)
+	defer creator.Close()
+	require.NoError(t, flock(creator, false, true))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		time.Sleep(30 * time.Millisecond)
+		_, _ = creator.WriteAt([]byte(`{"user":"user","host":"host","pid":1,"shared":true}`), 0)
+		time.Sleep(20 * time.Millisecond)
+		_ = funlock(creator)
+		_ = flock(creator, true, false)
+	}()
+
+	joiner := NewLock(tempfile).WithShared(true)
+	assert.True(t, joiner.TryAcquire())
+	<-done
+	joiner.Release()
+}
+
+func TestSharedLockLeftBehind(t *testing.T) {
+	t.Parallel()
+
+	// the shared lock file of processes which died is not locked anymore
+	tempfile := getTempfile(t)
+	require.NoError(t, os.WriteFile(tempfile, []byte(`{"user":"user","host":"host","pid":1,"shared":true}`), 0o600))
+
+	shared := NewLock(tempfile).WithShared(true)
+	require.True(t, shared.TryAcquire())
+	shared.Release()
+	assert.NoFileExists(t, tempfile)
+
+	require.NoError(t, os.WriteFile(tempfile, []byte(`{"user":"user","host":"host","pid":1,"shared":true}`), 0o600))
+	lock := NewLock(tempfile).WithOwner("home", "backup")
+	assert.False(t, lock.TryAcquire())
+	require.True(t, lock.ForceAcquire())
+	info, err := ReadInfo(tempfile)
+	require.NoError(t, err)
+	assert.Equal(t, "backup", info.Command)
+	assert.False(t, info.Shared)
+
+	lock.Release()
+	assert.NoFileExists(t, tempfile)
+}
+
+func TestRemoveIfStaleWhenLocked(t *testing.T) {
+	t.Parallel()
+
+	tempfile := getTempfile(t)
+	lock := NewLock(tempfile)
+	require.True(t, lock.TryAcquire())
+	defer lock.Release()
+
+	// pretend the process is dead: the file is still locked
+	require.NoError(t, os.WriteFile(tempfile, fmt.Appendf(nil, `{"pid":%d}`, deadPID(t)), 0o600))
+	_, err := RemoveIfStale(tempfile, false)
+	assert.EqualError(t, err, "lock is in use")
+	assert.FileExists(t, tempfile)
+}
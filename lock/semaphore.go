@@ -0,0 +1,76 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Semaphore limits the number of processes running at the same time on the host.
+// Each slot is a lock file in the semaphore directory.
+type Semaphore struct {
+	Dir     string
+	Slots   int
+	profile string
+	command string
+}
+
+// NewSemaphore creates a semaphore of slots lock files in dir
+func NewSemaphore(dir string, slots int) *Semaphore {
+	return &Semaphore{
+		Dir:   dir,
+		Slots: slots,
+	}
+}
+
+// WithOwner records the profile and command running in the slot
+func (s *Semaphore) WithOwner(profile, command string) *Semaphore {
+	s.profile = profile
+	s.command = command
+	return s
+}
+
+// SlotFile returns the lock file of a slot
+func (s *Semaphore) SlotFile(slot int) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("slot-%d.lock", slot))
+}
+
+// TryAcquire returns the lock of a free slot, or nil when all the slots are in use.
+// The slot of a process which is no longer running is freed.
+// A slot lock file which cannot be opened (e.g. left by another user) is skipped like a slot in use.
+// It returns an error when none of the slot lock files can be used (e.g. no permission on the directory).
+func (s *Semaphore) TryAcquire() (*Lock, error) {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return nil, err
+	}
+	var errs []error
+	for slot := 0; slot < s.Slots; slot++ {
+		filename := s.SlotFile(slot)
+		lock := NewLock(filename).WithOwner(s.profile, s.command)
+		if lock.TryAcquire() {
+			return lock, nil
+		}
+		if err := lock.LastError(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := RemoveIfStale(filename, false); err == nil && lock.TryAcquire() {
+			return lock, nil
+		}
+	}
+	if len(errs) == s.Slots {
+		return nil, errs[0]
+	}
+	return nil, nil
+}
+
+// Holders returns the information of the slots in use
+func (s *Semaphore) Holders() []*Info {
+	holders := make([]*Info, 0, s.Slots)
+	for slot := 0; slot < s.Slots; slot++ {
+		if info, err := ReadInfo(s.SlotFile(slot)); err == nil {
+			holders = append(holders, info)
+		}
+	}
+	return holders
+}
@@ -0,0 +1,104 @@
+package lock
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphoreNoPermission(t *testing.T) {
+	if platform.IsWindows() || os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced")
+	}
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0o555))
+	defer func() { _ = os.Chmod(dir, 0o755) }()
+
+	slot, err := NewSemaphore(dir, 2).TryAcquire()
+	assert.ErrorIs(t, err, fs.ErrPermission)
+	assert.Nil(t, slot)
+}
+
+func TestSemaphoreSkipsSlotOfAnotherUser(t *testing.T) {
+	if platform.IsWindows() || os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced")
+	}
+	t.Parallel()
+
+	// a stale slot which cannot be opened nor removed: like a slot left by another user in a shared directory
+	dir := t.TempDir()
+	semaphore := NewSemaphore(dir, 2)
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(semaphore.SlotFile(0), fmt.Appendf(nil, `{"host":%q,"pid":%d}`, hostname, deadPID(t)), 0o400))
+
+	slot, err := semaphore.TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, slot)
+	defer slot.Release()
+	assert.Equal(t, semaphore.SlotFile(1), slot.Lockfile)
+
+	// all the slots are in use
+	other, err := semaphore.TryAcquire()
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
+
+func TestSemaphore(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "runs")
+	semaphore := NewSemaphore(dir, 2).WithOwner("home", "backup")
+
+	first, err := semaphore.TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	defer first.Release()
+
+	second, err := semaphore.TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	defer second.Release()
+
+	third, err := semaphore.TryAcquire()
+	require.NoError(t, err)
+	assert.Nil(t, third)
+
+	holders := semaphore.Holders()
+	require.Len(t, holders, 2)
+	assert.Equal(t, "home", holders[0].Profile)
+	assert.Equal(t, "backup", holders[1].Command)
+	assert.Equal(t, int32(os.Getpid()), holders[0].PID)
+
+	first.Release()
+	assert.Len(t, semaphore.Holders(), 1)
+	third, err = semaphore.TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, third)
+	defer third.Release()
+	assert.Equal(t, semaphore.SlotFile(0), third.Lockfile)
+}
+
+func TestSemaphoreStaleSlot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	semaphore := NewSemaphore(dir, 1)
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(semaphore.SlotFile(0), fmt.Appendf(nil, `{"host":%q,"pid":%d}`, hostname, deadPID(t)), 0o600))
+
+	slot, err := semaphore.TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, slot)
+	slot.Release()
+	assert.NoFileExists(t, semaphore.SlotFile(0))
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/lock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLockRunWithNoLockfile(t *testing.T) {
@@ -16,7 +19,7 @@ func TestLockRunWithNoLockfile(t *testing.T) {
 		called++
 		return nil
 	}
-	err := lockRun("", false, nil, nil, callback)
+	err := lockRun("", "", "", false, false, nil, nil, callback)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, called)
 }
@@ -30,7 +33,7 @@ func TestLockRunWithNoLock(t *testing.T) {
 	lockfile := filepath.Join(t.TempDir(), "lockfile")
 	assert.NoFileExists(t, lockfile)
 
-	err := lockRun(lockfile, false, nil, nil, callback)
+	err := lockRun(lockfile, "", "", false, false, nil, nil, callback)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, called)
 	assert.NoFileExists(t, lockfile)
@@ -47,7 +50,7 @@ func TestLockRunWithLock(t *testing.T) {
 	assert.NoError(t, err)
 	assert.FileExists(t, lockfile)
 
-	err = lockRun(lockfile, false, nil, nil, callback)
+	err = lockRun(lockfile, "", "", false, false, nil, nil, callback)
 	assert.Error(t, err)
 	assert.Equal(t, 0, called)
 	assert.FileExists(t, lockfile)
@@ -64,7 +67,7 @@ func TestLockRunWithLockAndForce(t *testing.T) {
 	assert.NoError(t, err)
 	assert.FileExists(t, lockfile)
 
-	err = lockRun(lockfile, true, nil, nil, callback)
+	err = lockRun(lockfile, "", "", false, true, nil, nil, callback)
 	assert.Error(t, err)
 	assert.Equal(t, 0, called)
 	assert.FileExists(t, lockfile)
@@ -89,7 +92,7 @@ func TestLockRunWithLockAndWait(t *testing.T) {
 	defer timer.Stop()
 
 	wait := 1 * time.Second
-	err = lockRun(lockfile, false, &wait, nil, callback)
+	err = lockRun(lockfile, "", "", false, false, &wait, nil, callback)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, called)
 }
@@ -113,8 +116,94 @@ func TestLockRunWithLockAndCancel(t *testing.T) {
 	defer timer.Stop()
 
 	wait := 1 * time.Second
-	err = lockRun(lockfile, false, &wait, sigChan, callback)
+	err = lockRun(lockfile, "", "", false, false, &wait, sigChan, callback)
 	assert.Error(t, err)
 	assert.Equal(t, 0, called)
 	assert.FileExists(t, lockfile)
 }
+
+func TestLockRunShared(t *testing.T) {
+	called := 0
+	callback := func(setPID lock.SetPID) error {
+		called++
+		return nil
+	}
+	lockfile := filepath.Join(t.TempDir(), "lockfile")
+	shared := lock.NewLock(lockfile).WithShared(true)
+	assert.True(t, shared.TryAcquire())
+	defer shared.Release()
+
+	err := lockRun(lockfile, "", "", false, false, nil, nil, callback)
+	assert.Error(t, err)
+	assert.Equal(t, 0, called)
+
+	err = lockRun(lockfile, "", "", true, false, nil, nil, callback)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, called)
+	assert.FileExists(t, lockfile)
+}
+
+func TestAcquireLockWithInvalidLockfile(t *testing.T) {
+	// the lock file cannot be created: it's not reported as locked by another process
+	lockfile := filepath.Join(t.TempDir(), strings.Repeat("x", 300)+".lock")
+	_, err := acquireLock("repository", lockfile, "profile", "backup", false, false, nil, nil)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "cannot create the lock file of the repository"))
+}
+
+func TestAcquireSlot(t *testing.T) {
+	t.Setenv(constants.EnvRunSlot, "")
+	dir := t.TempDir()
+
+	release, err := acquireSlot(dir, 1, "first", "backup", 0, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, os.Getenv(constants.EnvRunSlot))
+
+	// a process started from a hook of the run shares its slot
+	nestedRelease, err := acquireSlot(dir, 1, "nested", "backup", 0, nil)
+	require.NoError(t, err)
+	nestedRelease()
+
+	os.Unsetenv(constants.EnvRunSlot)
+	_, err = acquireSlot(dir, 1, "second", "check", 100*time.Millisecond, nil)
+	assert.ErrorContains(t, err, `1 concurrent runs still in progress after 100ms: profile "first" running "backup"`)
+
+	// release the slot while the second run is waiting
+	timer := time.AfterFunc(500*time.Millisecond, release)
+	defer timer.Stop()
+	release, err = acquireSlot(dir, 1, "second", "check", time.Second, nil)
+	require.NoError(t, err)
+	release()
+	assert.Empty(t, os.Getenv(constants.EnvRunSlot))
+}
+
+func TestAcquireSlotCancel(t *testing.T) {
+	t.Setenv(constants.EnvRunSlot, "")
+	dir := t.TempDir()
+
+	release, err := acquireSlot(dir, 1, "first", "backup", 0, nil)
+	require.NoError(t, err)
+	defer release()
+	os.Unsetenv(constants.EnvRunSlot)
+
+	sigChan := make(chan os.Signal, 1)
+	sigChan <- os.Interrupt
+	_, err = acquireSlot(dir, 1, "second", "backup", 0, sigChan)
+	assert.ErrorIs(t, err, errInterrupt)
+}
+
+func TestAcquireSlotError(t *testing.T) {
+	t.Setenv(constants.EnvRunSlot, "")
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	// the slot directory cannot be created: don't wait for a slot forever
+	_, err := acquireSlot(filepath.Join(file, "runs"), 1, "first", "backup", 0, nil)
+	assert.ErrorContains(t, err, "cannot acquire a slot of the concurrent runs")
+}
+
+func TestAcquireSlotUnlimited(t *testing.T) {
+	release, err := acquireSlot(t.TempDir(), 0, "", "", 0, nil)
+	require.NoError(t, err)
+	release()
+}
@@ -8,8 +8,10 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/dial"
 	"github.com/creativeprojects/resticprofile/platform"
@@ -26,7 +28,47 @@ type LogCloser interface {
 	Close() error
 }
 
-func setupConsoleLogger(flags commandLineFlags) {
+// syslogOptions are the settings of the global section used to connect to a syslog server
+type syslogOptions struct {
+	format            string // rfc3164 or rfc5424 (default depends on the transport)
+	caCertificate     string
+	clientCertificate string
+}
+
+// logTargetOptions are the settings of the global section used to open a log target
+type logTargetOptions struct {
+	syslog   syslogOptions
+	rotation write.Rotation // also limits the previous files of a log path with placeholders
+}
+
+func newLogTargetOptions(global *config.Global) logTargetOptions {
+	if global == nil {
+		return logTargetOptions{}
+	}
+	return logTargetOptions{
+		syslog: syslogOptions{
+			format:            strings.ToLower(global.SyslogFormat),
+			caCertificate:     global.SyslogTLSCACert,
+			clientCertificate: global.SyslogTLSClientCert,
+		},
+		rotation: write.Rotation{
+			MaxSize:  int64(global.LogMaxSize) * 1024 * 1024, //nolint:gosec
+			MaxAge:   global.LogMaxAge,
+			MaxFiles: global.LogMaxFiles,
+			Compress: global.LogCompress,
+		},
+	}
+}
+
+func setupConsoleLogger(flags commandLineFlags, logFormat string) {
+	if logFormat == constants.LogFormatJSON {
+		output := os.Stdout
+		if flags.stderr {
+			output = os.Stderr
+		}
+		clog.SetDefaultLogger(newFilteredLogger(flags, NewJSONLogHandler(output, jsonLogFields)))
+		return
+	}
 	if flags.stderr {
 		out := color.Output
 		color.Output = color.Error
@@ -50,18 +92,23 @@ func setupRemoteLogger(flags commandLineFlags, client *remote.Client) {
 	clog.SetDefaultLogger(logger)
 }
 
-func setupTargetLogger(flags commandLineFlags, terminal *term.Terminal, logTarget, commandOutput string) (io.Closer, []term.TerminalOption, error) {
+func setupTargetLogger(flags commandLineFlags, terminal *term.Terminal, logTarget, logFormat, commandOutput string, options logTargetOptions) (io.Closer, []term.TerminalOption, error) {
 	var (
 		handler LogCloser
 		file    io.Writer
 		err     error
 	)
-	if scheme, hostPort, isURL := dial.GetAddr(logTarget); isURL {
-		handler, file, err = getSyslogHandler(scheme, hostPort)
+	if logFormat != "" && logFormat != constants.LogFormatText && logFormat != constants.LogFormatJSON {
+		return nil, nil, fmt.Errorf("unsupported log format %q", logFormat)
+	}
+	if scheme, hostPort, isURL := dial.GetAddr(logTarget); isURL && scheme == "journald" {
+		handler, file, err = getJournaldHandler(hostPort, logFormat)
+	} else if isURL {
+		handler, file, err = getSyslogHandler(scheme, hostPort, logFormat, options.syslog)
 	} else if dial.IsURL(logTarget) {
 		err = fmt.Errorf("unsupported URL: %s", logTarget)
 	} else {
-		handler, file, err = getFileHandler(logTarget)
+		handler, file, err = getFileHandler(logTarget, logFormat, options.rotation)
 	}
 	if err != nil {
 		return nil, nil, err
@@ -74,16 +121,21 @@ func setupTargetLogger(flags commandLineFlags, terminal *term.Terminal, logTarge
 	var terminalOptions []term.TerminalOption
 	// also redirect all terminal output
 	if file != nil {
+		stdout, stderr := file, file
+		if logFormat == constants.LogFormatJSON {
+			stdout = newJSONLineWriter(file, "stdout", jsonLogFields)
+			stderr = newJSONLineWriter(file, "stderr", jsonLogFields)
+		}
 		if all, toLog := parseCommandOutput(terminal, commandOutput); all {
 			clog.Debugf("sending a copy of the console logs to %q", logTarget)
 			terminalOptions = []term.TerminalOption{
-				term.WithStdoutCopy(file),
-				term.WithStderrCopy(file),
+				term.WithStdoutCopy(stdout),
+				term.WithStderrCopy(stderr),
 			}
 		} else if toLog {
 			terminalOptions = []term.TerminalOption{
-				term.WithStdout(file),
-				term.WithStderr(file),
+				term.WithStdout(stdout),
+				term.WithStderr(stderr),
 			}
 		}
 	}
@@ -105,7 +157,59 @@ func parseCommandOutput(terminal *term.Terminal, commandOutput string) (all, log
 	return
 }
 
-func getFileHandler(logfile string) (*clog.StandardLogHandler, io.Writer, error) {
+// logPathPlaceholders are replaced in a log file path with the values of the current run.
+// The values of the varying placeholders are replaced by a wildcard to find the log files of previous runs.
+var logPathPlaceholders = []struct {
+	name    string
+	varying bool
+	value   func(fields *logFields, now time.Time) string
+}{
+	{"%profile%", false, func(fields *logFields, _ time.Time) string { return fields.profile }},
+	{"%group%", false, func(fields *logFields, _ time.Time) string { return fields.group }},
+	{"%command%", false, func(fields *logFields, _ time.Time) string { return fields.command }},
+	{"%date%", true, func(_ *logFields, now time.Time) string { return now.Format(time.DateOnly) }},
+	{"%time%", true, func(_ *logFields, now time.Time) string { return now.Format("15-04-05") }},
+	{"%run_id%", true, func(fields *logFields, _ time.Time) string { return fields.runID }},
+}
+
+// expandLogPath replaces the placeholders in logfile. The pattern matches the files of the previous runs
+// when logfile contains a varying placeholder (date, time or run ID), otherwise the pattern is empty.
+func expandLogPath(logfile string, fields *logFields, now time.Time) (path, pattern string) {
+	if !strings.Contains(logfile, "%") {
+		return logfile, ""
+	}
+	fields.mutex.RLock()
+	defer fields.mutex.RUnlock()
+
+	path, pattern = logfile, write.EscapeGlob(logfile)
+	varying := false
+	for _, placeholder := range logPathPlaceholders {
+		if !strings.Contains(logfile, placeholder.name) {
+			continue
+		}
+		value := placeholder.value(fields, now)
+		path = strings.ReplaceAll(path, placeholder.name, value)
+		if placeholder.varying {
+			varying = true
+			pattern = strings.ReplaceAll(pattern, placeholder.name, "*")
+		} else {
+			pattern = strings.ReplaceAll(pattern, placeholder.name, write.EscapeGlob(value))
+		}
+	}
+	if !varying {
+		pattern = ""
+	}
+	return
+}
+
+func getFileHandler(logfile, logFormat string, rotation write.Rotation) (LogCloser, io.Writer, error) {
+	logfile, previousFiles := expandLogPath(logfile, jsonLogFields, time.Now())
+	if logfile != "" && previousFiles != "" {
+		err := os.MkdirAll(filepath.Dir(logfile), 0755) //nolint:gosec
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create log file directory: %w", err)
+		}
+	}
 	if strings.HasPrefix(logfile, constants.TemporaryDirMarker) {
 		if tempDir, err := util.TempDir(); err == nil {
 			logfile = logfile[len(constants.TemporaryDirMarker):]
@@ -134,12 +238,20 @@ func getFileHandler(logfile string) (*clog.StandardLogHandler, io.Writer, error)
 		}
 	}
 
-	file, err := write.NewFile(logfile, write.WithFilePerm(0644))
+	file, err := write.NewFile(logfile, write.WithFilePerm(0644), write.WithFileRotation(rotation))
 	if err != nil {
 		return nil, nil, err
 	}
+	if previousFiles != "" && (rotation.MaxFiles > 0 || rotation.MaxAge > 0) {
+		if err = write.CleanupFiles(previousFiles, rotation.MaxFiles, rotation.MaxAge); err != nil {
+			clog.Warningf("cannot remove previous log files: %s", err)
+		}
+	}
 	writer := write.NewAsync(write.NewAppend(file, appender))
 
+	if logFormat == constants.LogFormatJSON {
+		return NewJSONLogHandler(writer, jsonLogFields), writer, nil
+	}
 	return clog.NewStandardLogHandler(writer, "", log.LstdFlags), writer, nil
 }
 
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// logRecord is one line of the JSON log output
+type logRecord struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Profile string `json:"profile,omitempty"`
+	Group   string `json:"group,omitempty"`
+	Command string `json:"command,omitempty"`
+	RunID   string `json:"run_id"`
+	Output  string `json:"output,omitempty"` // "stdout" or "stderr" for lines of command output
+	Message string `json:"message"`
+}
+
+// logFields holds the context of the run added to each JSON log record
+type logFields struct {
+	mutex   sync.RWMutex
+	runID   string
+	profile string
+	group   string
+	command string
+}
+
+// jsonLogFields is the context of the current run
+var jsonLogFields = newLogFields()
+
+func newLogFields() *logFields {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &logFields{runID: hex.EncodeToString(id)}
+}
+
+// setLogContext sets the profile, group and command added to each JSON log record
+func setLogContext(profile, group, command string) {
+	jsonLogFields.set(profile, group, command)
+}
+
+func (f *logFields) set(profile, group, command string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.profile = profile
+	f.group = group
+	f.command = command
+}
+
+func (f *logFields) encode(level clog.LogLevel, output, message string) []byte {
+	f.mutex.RLock()
+	record := logRecord{
+		Time:    time.Now().Format(time.RFC3339Nano),
+		Level:   levelName(level),
+		Profile: f.profile,
+		Group:   f.group,
+		Command: f.command,
+		RunID:   f.runID,
+		Output:  output,
+		Message: message,
+	}
+	f.mutex.RUnlock()
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		// cannot happen with string fields only
+		return []byte(message)
+	}
+	return line
+}
+
+func levelName(level clog.LogLevel) string {
+	switch level {
+	case clog.LevelTrace:
+		return "trace"
+	case clog.LevelDebug:
+		return "debug"
+	case clog.LevelInfo:
+		return "info"
+	case clog.LevelWarning:
+		return "warning"
+	case clog.LevelError:
+		return "error"
+	default:
+		return "notice"
+	}
+}
+
+// JSONLogHandler writes log entries as JSON objects, one per line
+type JSONLogHandler struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	fields *logFields
+}
+
+func NewJSONLogHandler(writer io.Writer, fields *logFields) *JSONLogHandler {
+	return &JSONLogHandler{
+		writer: writer,
+		fields: fields,
+	}
+}
+
+func (h *JSONLogHandler) LogEntry(entry clog.LogEntry) error {
+	line := append(h.fields.encode(entry.Level, "", entry.GetMessage()), '\n')
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.writer == nil {
+		return errors.New("log handler is closed")
+	}
+	_, err := h.writer.Write(line)
+	return err
+}
+
+func (h *JSONLogHandler) Close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	var err error
+	if closer, ok := h.writer.(io.Closer); ok {
+		err = closer.Close()
+	}
+	h.writer = nil
+	return err
+}
+
+var _ LogCloser = &JSONLogHandler{}
+
+// jsonLineWriter converts each line of command output into a JSON log record
+type jsonLineWriter struct {
+	mutex  sync.Mutex
+	target io.Writer
+	output string
+	fields *logFields
+	buffer []byte
+}
+
+func newJSONLineWriter(target io.Writer, output string, fields *logFields) *jsonLineWriter {
+	return &jsonLineWriter{
+		target: target,
+		output: output,
+		fields: fields,
+	}
+}
+
+func (w *jsonLineWriter) Write(p []byte) (n int, err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.buffer = append(w.buffer, p...)
+	for err == nil {
+		index := bytes.IndexByte(w.buffer, '\n')
+		if index < 0 {
+			break
+		}
+		err = w.writeLine(w.buffer[:index])
+		w.buffer = w.buffer[index+1:]
+	}
+	return len(p), err
+}
+
+// Flush writes an incomplete line and flushes the target
+func (w *jsonLineWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	var err error
+	if len(w.buffer) > 0 {
+		err = w.writeLine(w.buffer)
+		w.buffer = nil
+	}
+	if err == nil {
+		_, err = util.FlushWriter(w.target)
+	}
+	return err
+}
+
+func (w *jsonLineWriter) writeLine(line []byte) error {
+	message := strings.TrimRight(string(line), "\r")
+	if strings.TrimSpace(message) == "" {
+		return nil
+	}
+	_, err := w.target.Write(append(w.fields.encode(clog.LevelInfo, w.output, message), '\n'))
+	return err
+}
+
+var _ util.Flusher = &jsonLineWriter{}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/util"
+	"github.com/creativeprojects/resticprofile/util/write"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLogRecords(t *testing.T, lines []string) []logRecord {
+	t.Helper()
+	records := make([]logRecord, 0, len(lines))
+	for _, line := range lines {
+		record := logRecord{}
+		require.NoErrorf(t, json.Unmarshal([]byte(line), &record), "line %q", line)
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestJSONLogHandler(t *testing.T) {
+	fields := newLogFields()
+	fields.set("home", "nightly", "backup")
+	buffer := &bytes.Buffer{}
+	handler := NewJSONLogHandler(buffer, fields)
+
+	require.NoError(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelWarning, Format: "value is %d", Values: []any{10}}))
+	require.NoError(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelDebug, Values: []any{`quote " and new line` + "\n"}}))
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	records := decodeLogRecords(t, lines)
+
+	assert.Equal(t, "warning", records[0].Level)
+	assert.Equal(t, "value is 10", records[0].Message)
+	assert.Equal(t, "home", records[0].Profile)
+	assert.Equal(t, "nightly", records[0].Group)
+	assert.Equal(t, "backup", records[0].Command)
+	assert.Len(t, records[0].RunID, 16)
+	assert.Empty(t, records[0].Output)
+	_, err := time.Parse(time.RFC3339Nano, records[0].Time)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "debug", records[1].Level)
+	assert.Equal(t, `quote " and new line`+"\n", records[1].Message)
+	assert.Equal(t, records[0].RunID, records[1].RunID)
+}
+
+func TestJSONLineWriter(t *testing.T) {
+	fields := newLogFields()
+	fields.set("home", "", "check")
+	buffer := &bytes.Buffer{}
+	writer := newJSONLineWriter(buffer, "stderr", fields)
+
+	_, err := writer.Write([]byte("first line\r\nsecond "))
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("line\n\nincomplete"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(buffer.String(), "\n"))
+
+	require.NoError(t, writer.Flush())
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	records := decodeLogRecords(t, lines)
+	require.Len(t, records, 3)
+
+	for i, message := range []string{"first line", "second line", "incomplete"} {
+		assert.Equal(t, message, records[i].Message)
+		assert.Equal(t, "stderr", records[i].Output)
+		assert.Equal(t, "info", records[i].Level)
+		assert.Equal(t, "check", records[i].Command)
+		assert.Empty(t, records[i].Group)
+	}
+}
+
+func TestJSONFileHandler(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "file.log")
+	handler, writer, err := getFileHandler(logFile, constants.LogFormatJSON, write.Rotation{})
+	require.NoError(t, err)
+
+	require.NoError(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelInfo, Format: "log-line-1"}))
+	output := newJSONLineWriter(writer, "stdout", jsonLogFields)
+	_, err = output.Write([]byte("restic output\n"))
+	require.NoError(t, err)
+
+	_, err = util.FlushWriter(writer)
+	require.NoError(t, err)
+	records := decodeLogRecords(t, readTail(t, logFile, 10))
+	require.Len(t, records, 2)
+	assert.Equal(t, "log-line-1", records[0].Message)
+	assert.Empty(t, records[0].Output)
+	assert.Equal(t, "restic output", records[1].Message)
+	assert.Equal(t, "stdout", records[1].Output)
+
+	require.NoError(t, handler.Close())
+	assert.Error(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelInfo, Format: "log-line-2"}))
+}
+
+func TestUnsupportedLogFormat(t *testing.T) {
+	_, _, err := setupTargetLogger(commandLineFlags{}, nil, filepath.Join(t.TempDir(), "file.log"), "xml", "", logTargetOptions{})
+	assert.ErrorContains(t, err, `unsupported log format "xml"`)
+}
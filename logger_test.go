@@ -12,6 +12,7 @@ import (
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/creativeprojects/resticprofile/util"
+	"github.com/creativeprojects/resticprofile/util/write"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,7 +39,7 @@ func TestFileHandlerWithTemporaryDirMarker(t *testing.T) {
 	logFile := filepath.Join(util.MustGetTempDir(), "sub", "file.log")
 	assert.NoFileExists(t, logFile)
 
-	handler, _, err := getFileHandler(filepath.Join(constants.TemporaryDirMarker, "sub", "file.log"))
+	handler, _, err := getFileHandler(filepath.Join(constants.TemporaryDirMarker, "sub", "file.log"), constants.LogFormatText, write.Rotation{})
 	require.NoError(t, err)
 	assert.FileExists(t, logFile)
 
@@ -49,7 +50,7 @@ func TestFileHandlerWithTemporaryDirMarker(t *testing.T) {
 
 func TestFileHandler(t *testing.T) {
 	logFile := filepath.Join(t.TempDir(), "file.log")
-	handler, writer, err := getFileHandler(logFile)
+	handler, writer, err := getFileHandler(logFile, constants.LogFormatText, write.Rotation{})
 	require.NoError(t, err)
 	defer handler.Close()
 
@@ -134,7 +135,7 @@ func TestParseCommandOutput(t *testing.T) {
 
 func TestCloseFileHandler(t *testing.T) {
 	logFile := filepath.Join(t.TempDir(), "file.log")
-	handler, writer, err := getFileHandler(logFile)
+	handler, writer, err := getFileHandler(logFile, constants.LogFormatText, write.Rotation{})
 	require.NoError(t, err)
 	assert.NotNil(t, handler)
 	assert.NotNil(t, writer)
@@ -143,3 +144,46 @@ func TestCloseFileHandler(t *testing.T) {
 	handler.Close()
 	assert.Error(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelInfo, Format: "log-line-2"}))
 }
+
+func TestExpandLogPath(t *testing.T) {
+	fields := newLogFields()
+	fields.set("home", "", "backup")
+	now := time.Date(2024, 5, 1, 2, 3, 4, 0, time.Local)
+
+	path, pattern := expandLogPath("/var/log/restic.log", fields, now)
+	assert.Equal(t, "/var/log/restic.log", path)
+	assert.Empty(t, pattern)
+
+	path, pattern = expandLogPath("/var/log/%profile%-%command%.log", fields, now)
+	assert.Equal(t, "/var/log/home-backup.log", path)
+	assert.Empty(t, pattern)
+
+	path, pattern = expandLogPath("/var/log/%profile%/%command%-%date%_%time%-%run_id%.log", fields, now)
+	assert.Equal(t, "/var/log/home/backup-2024-05-01_02-03-04-"+fields.runID+".log", path)
+	assert.Equal(t, "/var/log/home/backup-*_*-*.log", pattern)
+}
+
+func TestFileHandlerWithPlaceholders(t *testing.T) {
+	dir := t.TempDir()
+	jsonLogFields.set("home", "", "backup")
+	defer jsonLogFields.set("", "", "")
+
+	// previous runs
+	for i, name := range []string{"backup-1.log", "backup-2.log", "backup-3.log", "check-1.log"} {
+		file := filepath.Join(dir, "home", name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o700))
+		require.NoError(t, os.WriteFile(file, nil, 0o600))
+		modTime := time.Now().Add(-time.Duration(i+1) * time.Hour)
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+
+	handler, _, err := getFileHandler(filepath.Join(dir, "%profile%", "%command%-%run_id%.log"), constants.LogFormatText, write.Rotation{MaxFiles: 2})
+	require.NoError(t, err)
+	defer handler.Close()
+
+	assert.FileExists(t, filepath.Join(dir, "home", "backup-"+jsonLogFields.runID+".log"))
+	assert.FileExists(t, filepath.Join(dir, "home", "backup-1.log"))
+	assert.NoFileExists(t, filepath.Join(dir, "home", "backup-2.log"))
+	assert.NoFileExists(t, filepath.Join(dir, "home", "backup-3.log"))
+	assert.FileExists(t, filepath.Join(dir, "home", "check-1.log"))
+}
@@ -123,10 +123,14 @@ func main() {
 
 			return
 		}
-		logTarget, commandOutput := "", ""
+		logTarget, logFormat, commandOutput := "", flags.logFormat, ""
+		targetOptions := logTargetOptions{}
 		if ctx != nil {
+			targetOptions = newLogTargetOptions(ctx.global)
 			logTarget = ctx.logTarget
+			logFormat = ctx.logFormat
 			commandOutput = ctx.commandOutput
+			setLogContext(ctx.request.profile, ctx.request.group, ctx.request.command)
 			if ctx.request.command == constants.CommandCat ||
 				ctx.request.command == constants.CommandDump {
 				clog.Debugf("redirecting console to stderr for command %q", ctx.request.command)
@@ -137,19 +141,19 @@ func main() {
 			}
 		}
 		if logTarget != "" && logTarget != "-" {
-			if closer, options, err := setupTargetLogger(flags, terminal, logTarget, commandOutput); err == nil {
+			if closer, options, err := setupTargetLogger(flags, terminal, logTarget, logFormat, commandOutput, targetOptions); err == nil {
 				logCloser = func() { _ = closer.Close() }
 				terminalOptions = append(terminalOptions, options...)
 				return
 			}
 			// fallback to a console logger
-			setupConsoleLogger(flags)
+			setupConsoleLogger(flags, logFormat)
 			clog.Errorf("cannot open log target: %s", err)
 
 			return
 		}
 		// use the console logger
-		setupConsoleLogger(flags)
+		setupConsoleLogger(flags, logFormat)
 
 		return
 	}
@@ -194,6 +198,7 @@ func main() {
 					arguments: flags.resticArgs[1:],
 				},
 				logTarget:     flags.log,
+				logFormat:     flags.logFormat,
 				commandOutput: flags.commandOutput,
 			}
 
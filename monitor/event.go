@@ -0,0 +1,207 @@
+package monitor
+
+import "time"
+
+// Names of the events sent to receivers publishing JSON messages
+const (
+	EventStart   = "start"
+	EventStatus  = "status"
+	EventSummary = "summary"
+	EventError   = "error"
+	EventHook    = "hook"
+)
+
+// HookResult is the result of a shell command hook (run-before, run-after, etc.) or an HTTP hook (send-before, send-after, etc.)
+type HookResult struct {
+	Type     string // type of hook: "run-before", "send-after", etc.
+	Command  string // restic command of the section defining the hook (empty when defined in the profile)
+	Index    int    // index of the hook in the list (starting at 1)
+	Total    int    // number of hooks in the list
+	Duration time.Duration
+	Error    error
+}
+
+// Failure describes the error which stopped a profile run
+type Failure struct {
+	Message     string
+	CommandLine string
+	ExitCode    string
+	Stderr      string
+}
+
+// Reasons of a Wait
+const (
+	WaitLock  = "lock"  // waiting on the lock of the profile
+	WaitRetry = "retry" // waiting before retrying a command which failed on a repository lock
+	WaitSlot  = "slot"  // waiting for a free slot when the maximum number of concurrent runs is reached
+)
+
+// Wait is the time spent waiting on a lock, or before retrying a command
+type Wait struct {
+	Reason   string // WaitLock, WaitRetry or WaitSlot
+	Command  string
+	Lock     string // description of the lock
+	Start    time.Time
+	Duration time.Duration
+}
+
+// WaitReceiver is an optional interface of a Receiver interested in the time spent waiting
+type WaitReceiver interface {
+	Wait(wait Wait)
+}
+
+// HookReceiver is an optional interface of a Receiver interested in the result of hooks
+type HookReceiver interface {
+	Hook(result HookResult)
+}
+
+// FailureReceiver is an optional interface of a Receiver interested in the failure of a profile run
+type FailureReceiver interface {
+	Failure(command string, failure Failure)
+}
+
+// EventHeader contains the fields common to all JSON events
+type EventHeader struct {
+	Event   string    `json:"event"`
+	Time    time.Time `json:"time"`
+	Profile string    `json:"profile"`
+	Group   string    `json:"group,omitempty"`
+	Command string    `json:"command"`
+}
+
+// NewEventHeader creates the header of an event happening now
+func NewEventHeader(event, profile, group, command string) EventHeader {
+	return EventHeader{
+		Event:   event,
+		Time:    time.Now(),
+		Profile: profile,
+		Group:   group,
+		Command: command,
+	}
+}
+
+// StatusEvent is the JSON representation of a Status
+type StatusEvent struct {
+	EventHeader
+	PercentDone  float64  `json:"percent_done"`
+	TotalFiles   int      `json:"total_files"`
+	FilesDone    int      `json:"files_done"`
+	TotalBytes   int64    `json:"total_bytes"`
+	BytesDone    int64    `json:"bytes_done"`
+	ErrorCount   int      `json:"error_count"`
+	CurrentFiles []string `json:"current_files,omitempty"`
+}
+
+func NewStatusEvent(header EventHeader, status Status) StatusEvent {
+	return StatusEvent{
+		EventHeader:  header,
+		PercentDone:  status.PercentDone,
+		TotalFiles:   status.TotalFiles,
+		FilesDone:    status.FilesDone,
+		TotalBytes:   status.TotalBytes,
+		BytesDone:    status.BytesDone,
+		ErrorCount:   status.ErrorCount,
+		CurrentFiles: status.CurrentFiles,
+	}
+}
+
+// SummaryEvent is the JSON representation of a Summary with the result of the command
+type SummaryEvent struct {
+	EventHeader
+	Status           string  `json:"status"`
+	Error            string  `json:"error,omitempty"`
+	Stderr           string  `json:"stderr,omitempty"`
+	Duration         float64 `json:"duration"`
+	FilesNew         int     `json:"files_new"`
+	FilesChanged     int     `json:"files_changed"`
+	FilesUnmodified  int     `json:"files_unmodified"`
+	DirsNew          int     `json:"dirs_new"`
+	DirsChanged      int     `json:"dirs_changed"`
+	DirsUnmodified   int     `json:"dirs_unmodified"`
+	FilesTotal       int     `json:"files_total"`
+	BytesAdded       uint64  `json:"bytes_added"`
+	BytesAddedPacked uint64  `json:"bytes_added_packed"`
+	BytesTotal       uint64  `json:"bytes_total"`
+}
+
+func NewSummaryEvent(header EventHeader, summary Summary, stderr string, result error) SummaryEvent {
+	event := SummaryEvent{
+		EventHeader:      header,
+		Status:           StatusName(result),
+		Stderr:           stderr,
+		Duration:         summary.Duration.Seconds(),
+		FilesNew:         summary.FilesNew,
+		FilesChanged:     summary.FilesChanged,
+		FilesUnmodified:  summary.FilesUnmodified,
+		DirsNew:          summary.DirsNew,
+		DirsChanged:      summary.DirsChanged,
+		DirsUnmodified:   summary.DirsUnmodified,
+		FilesTotal:       summary.FilesTotal,
+		BytesAdded:       summary.BytesAdded,
+		BytesAddedPacked: summary.BytesAddedPacked,
+		BytesTotal:       summary.BytesTotal,
+	}
+	if result != nil {
+		event.Error = result.Error()
+	}
+	return event
+}
+
+// ErrorEvent is the JSON representation of a Failure
+type ErrorEvent struct {
+	EventHeader
+	Message     string `json:"message"`
+	CommandLine string `json:"command_line,omitempty"`
+	ExitCode    string `json:"exit_code,omitempty"`
+	Stderr      string `json:"stderr,omitempty"`
+}
+
+func NewErrorEvent(header EventHeader, failure Failure) ErrorEvent {
+	return ErrorEvent{
+		EventHeader: header,
+		Message:     failure.Message,
+		CommandLine: failure.CommandLine,
+		ExitCode:    failure.ExitCode,
+		Stderr:      failure.Stderr,
+	}
+}
+
+// HookEvent is the JSON representation of a HookResult
+type HookEvent struct {
+	EventHeader
+	Hook     string  `json:"hook"`
+	Section  string  `json:"section,omitempty"`
+	Index    int     `json:"index"`
+	Total    int     `json:"total"`
+	Duration float64 `json:"duration"`
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+}
+
+func NewHookEvent(header EventHeader, result HookResult) HookEvent {
+	event := HookEvent{
+		EventHeader: header,
+		Hook:        result.Type,
+		Section:     result.Command,
+		Index:       result.Index,
+		Total:       result.Total,
+		Duration:    result.Duration.Seconds(),
+		Status:      StatusName(result.Error),
+	}
+	if result.Error != nil {
+		event.Error = result.Error.Error()
+	}
+	return event
+}
+
+// StatusName returns the status of a command result: "success", "warning" or "failed"
+func StatusName(result error) string {
+	switch StatusOf(result) {
+	case StatusSuccess:
+		return "success"
+	case StatusWarning:
+		return "warning"
+	default:
+		return "failed"
+	}
+}
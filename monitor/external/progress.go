@@ -0,0 +1,211 @@
+package external
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/shell"
+)
+
+const (
+	// closeTimeout is the time given to the external program to exit after its stdin is closed
+	closeTimeout = 10 * time.Second
+	// eventBufferSize is the number of events waiting to be read by the external program before dropping new ones
+	eventBufferSize = 256
+)
+
+// Progress is a monitor.Receiver starting an external program and streaming newline-delimited JSON events to its stdin.
+// The program is started on the first event and its stdin is closed at the end of the profile run.
+type Progress struct {
+	receiver config.ReceiverSection
+	profile  string
+	group    string
+	shell    []string
+	env      []string
+	stdout   io.Writer
+	stderr   io.Writer
+	command  string
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	events   chan []byte
+	written  chan struct{}
+	dropped  int
+	failed   bool
+}
+
+func NewProgress(profile *config.Profile, group string, receiver config.ReceiverSection, shell, env []string) *Progress {
+	return &Progress{
+		receiver: receiver,
+		profile:  profile.Name,
+		group:    group,
+		shell:    shell,
+		env:      env,
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+	}
+}
+
+// WithOutput redirects stdout and stderr of the external program
+func (p *Progress) WithOutput(stdout, stderr io.Writer) *Progress {
+	p.stdout = stdout
+	p.stderr = stderr
+	return p
+}
+
+func (p *Progress) Start(command string) {
+	p.command = command
+	p.send(monitor.EventStart, monitor.NewEventHeader(monitor.EventStart, p.profile, p.group, command))
+}
+
+func (p *Progress) Status(status monitor.Status) {
+	header := monitor.NewEventHeader(monitor.EventStatus, p.profile, p.group, p.command)
+	p.send(monitor.EventStatus, monitor.NewStatusEvent(header, status))
+}
+
+func (p *Progress) Summary(command string, summary monitor.Summary, stderr string, result error) {
+	header := monitor.NewEventHeader(monitor.EventSummary, p.profile, p.group, command)
+	p.send(monitor.EventSummary, monitor.NewSummaryEvent(header, summary, stderr, result))
+}
+
+func (p *Progress) Failure(command string, failure monitor.Failure) {
+	header := monitor.NewEventHeader(monitor.EventError, p.profile, p.group, command)
+	p.send(monitor.EventError, monitor.NewErrorEvent(header, failure))
+}
+
+func (p *Progress) Hook(result monitor.HookResult) {
+	header := monitor.NewEventHeader(monitor.EventHook, p.profile, p.group, p.command)
+	p.send(monitor.EventHook, monitor.NewHookEvent(header, result))
+}
+
+// Close closes stdin of the external program and waits for it to exit
+func (p *Progress) Close() error {
+	if p.cmd == nil {
+		return nil
+	}
+	if p.dropped > 0 {
+		clog.Warningf("receiver %q is too slow: %d events were dropped", p.receiver.Command, p.dropped)
+	}
+	close(p.events)
+	timeout := time.After(closeTimeout)
+
+	var err error
+	select {
+	case <-p.written:
+		_ = p.stdin.Close()
+		done := make(chan error, 1)
+		go func() { done <- p.cmd.Wait() }()
+		select {
+		case err = <-done:
+		case <-timeout:
+			_ = p.cmd.Process.Kill()
+			err = errors.Join(fmt.Errorf("receiver %q did not exit after %s", p.receiver.Command, closeTimeout), <-done)
+		}
+	case <-timeout:
+		// the program is not reading its input anymore
+		_ = p.cmd.Process.Kill()
+		<-p.written
+		_ = p.stdin.Close()
+		err = errors.Join(fmt.Errorf("receiver %q did not exit after %s", p.receiver.Command, closeTimeout), p.cmd.Wait())
+	}
+	p.cmd = nil
+	if err != nil {
+		clog.Warningf("receiver %q: %v", p.receiver.Command, err)
+	}
+	return err
+}
+
+func (p *Progress) send(event string, message any) {
+	if p.failed || !p.accepts(event) {
+		return
+	}
+	if p.cmd == nil {
+		if err := p.startProgram(); err != nil {
+			p.failed = true
+			// not important enough to throw an error here
+			clog.Warningf("cannot start receiver %q: %v", p.receiver.Command, err)
+			return
+		}
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		clog.Warningf("cannot encode %s event: %v", event, err)
+		return
+	}
+	// never block the profile run on a slow program
+	select {
+	case p.events <- append(data, '\n'):
+	default:
+		p.dropped++
+	}
+}
+
+// write sends the events to the program until the channel is closed
+func (p *Progress) write(stdin io.Writer, events <-chan []byte, written chan<- struct{}) {
+	defer close(written)
+	var err error
+	for data := range events {
+		if err != nil {
+			continue // drain the channel
+		}
+		if _, err = stdin.Write(data); err != nil {
+			clog.Warningf("sending event to receiver %q: %v", p.receiver.Command, err)
+		}
+	}
+}
+
+func (p *Progress) accepts(event string) bool {
+	return len(p.receiver.Events) == 0 || slices.ContainsFunc(p.receiver.Events, func(e string) bool {
+		return strings.EqualFold(strings.TrimSpace(e), event)
+	})
+}
+
+func (p *Progress) startProgram() error {
+	if strings.TrimSpace(p.receiver.Command) == "" {
+		return errors.New("command is empty")
+	}
+	shellCmd := shell.NewCommand(p.receiver.Command, nil)
+	shellCmd.Shell = p.shell
+	binary, args, err := shellCmd.GetShellCommand()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(binary, args...) //nolint:gosec
+	cmd.Env = append(os.Environ(), p.env...)
+	cmd.Stdout = p.stdout
+	cmd.Stderr = p.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	clog.Debugf("started receiver %q (pid %d)", p.receiver.Command, cmd.Process.Pid)
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.events = make(chan []byte, eventBufferSize)
+	p.written = make(chan struct{})
+	p.dropped = 0
+	go p.write(stdin, p.events, p.written)
+	return nil
+}
+
+// Verify interfaces
+var (
+	_ monitor.Receiver        = &Progress{}
+	_ monitor.HookReceiver    = &Progress{}
+	_ monitor.FailureReceiver = &Progress{}
+	_ io.Closer               = &Progress{}
+)
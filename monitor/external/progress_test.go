@@ -0,0 +1,166 @@
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEvents(t *testing.T, filename string) []map[string]any {
+	t.Helper()
+	file, err := os.Open(filename)
+	require.NoError(t, err)
+	defer file.Close()
+
+	events := make([]map[string]any, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := make(map[string]any)
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestSendEvents(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("receiver command is using a unix shell")
+	}
+	output := filepath.Join(t.TempDir(), "events.json")
+	receiver := config.ReceiverSection{Command: "cat > " + output}
+	progress := NewProgress(config.NewProfile(nil, "home"), "nightly", receiver, nil, nil)
+
+	progress.Hook(monitor.HookResult{Type: "run-before", Index: 1, Total: 1, Duration: time.Second})
+	progress.Start("backup")
+	progress.Status(monitor.Status{PercentDone: 0.5})
+	progress.Summary("backup", monitor.Summary{FilesNew: 2}, "some warning", nil)
+	progress.Failure("backup", monitor.Failure{Message: "failed", ExitCode: "1"})
+	require.NoError(t, progress.Close())
+
+	events := readEvents(t, output)
+	require.Len(t, events, 5)
+	for i, event := range []string{"hook", "start", "status", "summary", "error"} {
+		assert.Equal(t, event, events[i]["event"])
+		assert.Equal(t, "home", events[i]["profile"])
+		assert.Equal(t, "nightly", events[i]["group"])
+	}
+	assert.Equal(t, "run-before", events[0]["hook"])
+	assert.Equal(t, "success", events[0]["status"])
+	assert.Equal(t, float64(1), events[0]["duration"])
+	assert.Equal(t, 0.5, events[2]["percent_done"])
+	assert.Equal(t, "backup", events[3]["command"])
+	assert.Equal(t, float64(2), events[3]["files_new"])
+	assert.Equal(t, "some warning", events[3]["stderr"])
+	assert.Equal(t, "failed", events[4]["message"])
+	assert.Equal(t, "1", events[4]["exit_code"])
+}
+
+func TestFilterEvents(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("receiver command is using a unix shell")
+	}
+	output := filepath.Join(t.TempDir(), "events.json")
+	receiver := config.ReceiverSection{Command: "cat > " + output, Events: []string{"summary", " Error "}}
+	progress := NewProgress(config.NewProfile(nil, "home"), "", receiver, nil, nil)
+
+	progress.Start("check")
+	progress.Hook(monitor.HookResult{Type: "run-after"})
+	progress.Summary("check", monitor.Summary{}, "", errors.New("check failed"))
+	require.NoError(t, progress.Close())
+
+	events := readEvents(t, output)
+	require.Len(t, events, 1)
+	assert.Equal(t, "summary", events[0]["event"])
+	assert.Equal(t, "failed", events[0]["status"])
+	assert.Equal(t, "check failed", events[0]["error"])
+	assert.NotContains(t, events[0], "group")
+}
+
+func TestNoEventNoProgram(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "events.json")
+	receiver := config.ReceiverSection{Command: "cat > " + output, Events: []string{"error"}}
+	progress := NewProgress(config.NewProfile(nil, "home"), "", receiver, nil, nil)
+
+	progress.Start("backup")
+	progress.Summary("backup", monitor.Summary{}, "", nil)
+	assert.NoError(t, progress.Close())
+	assert.NoFileExists(t, output)
+}
+
+func TestProgramOutputAndEnvironment(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("receiver command is using a unix shell")
+	}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	receiver := config.ReceiverSection{Command: `echo "out $TEST_RECEIVER"; echo err >&2; cat > /dev/null`}
+	progress := NewProgress(config.NewProfile(nil, "home"), "", receiver, nil, []string{"TEST_RECEIVER=value"}).
+		WithOutput(stdout, stderr)
+
+	progress.Start("backup")
+	require.NoError(t, progress.Close())
+	assert.Equal(t, "out value\n", stdout.String())
+	assert.Equal(t, "err\n", stderr.String())
+}
+
+func TestProgramFailure(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("receiver command is using a unix shell")
+	}
+	receiver := config.ReceiverSection{Command: "exit 3"}
+	progress := NewProgress(config.NewProfile(nil, "home"), "", receiver, nil, nil).
+		WithOutput(&bytes.Buffer{}, &bytes.Buffer{})
+
+	assert.NotPanics(t, func() {
+		progress.Start("backup")
+		for i := 0; i < 100; i++ {
+			progress.Status(monitor.Status{PercentDone: float64(i) / 100})
+		}
+		progress.Summary("backup", monitor.Summary{}, "", nil)
+	})
+	assert.Error(t, progress.Close())
+}
+
+func TestSlowProgramDoesNotBlock(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("receiver command is using a unix shell")
+	}
+	output := filepath.Join(t.TempDir(), "events.json")
+	receiver := config.ReceiverSection{Command: "sleep 1; cat > " + output}
+	progress := NewProgress(config.NewProfile(nil, "home"), "", receiver, nil, nil)
+
+	// enough events to fill both the pipe and the buffer while the program is not reading
+	currentFiles := []string{strings.Repeat("x", 1000)}
+	start := time.Now()
+	progress.Start("backup")
+	for i := 0; i < 1000; i++ {
+		progress.Status(monitor.Status{FilesDone: i, CurrentFiles: currentFiles})
+	}
+	progress.Summary("backup", monitor.Summary{}, "", nil)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Greater(t, progress.dropped, 0)
+	require.NoError(t, progress.Close())
+
+	events := readEvents(t, output)
+	assert.Len(t, events, 1002-progress.dropped)
+	assert.Equal(t, "start", events[0]["event"])
+}
+
+func TestEmptyCommand(t *testing.T) {
+	progress := NewProgress(config.NewProfile(nil, "home"), "", config.ReceiverSection{}, nil, nil)
+	progress.Start("backup")
+	assert.True(t, progress.failed)
+	assert.NoError(t, progress.Close())
+}
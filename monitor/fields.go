@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"maps"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+// Values of the "status" field
+const (
+	StatusFailed = iota
+	StatusWarning
+	StatusSuccess
+)
+
+// Field is a named metric value taken from the summary of a command
+type Field struct {
+	Name  string
+	Value float64
+}
+
+// StatusOf returns the status value of a command result: 0=fail, 1=warning, 2=success
+func StatusOf(result error) int {
+	switch {
+	case IsSuccess(result):
+		return StatusSuccess
+	case IsWarning(result):
+		return StatusWarning
+	default:
+		return StatusFailed
+	}
+}
+
+// SummaryFields returns the metric fields of a command summary, using the same names as the prometheus metrics.
+// Only the backup command reports statistics about files and bytes.
+func SummaryFields(command string, summary Summary, result error) []Field {
+	fields := []Field{
+		{Name: "duration_seconds", Value: summary.Duration.Seconds()},
+		{Name: "status", Value: float64(StatusOf(result))},
+		{Name: "time_seconds", Value: float64(time.Now().Unix())},
+	}
+	if command == constants.CommandBackup {
+		fields = append(fields,
+			Field{Name: "files_new", Value: float64(summary.FilesNew)},
+			Field{Name: "files_changed", Value: float64(summary.FilesChanged)},
+			Field{Name: "files_unmodified", Value: float64(summary.FilesUnmodified)},
+			Field{Name: "dir_new", Value: float64(summary.DirsNew)},
+			Field{Name: "dir_changed", Value: float64(summary.DirsChanged)},
+			Field{Name: "dir_unmodified", Value: float64(summary.DirsUnmodified)},
+			Field{Name: "files_processed", Value: float64(summary.FilesTotal)},
+			Field{Name: "added_bytes", Value: float64(summary.BytesAdded)},
+			Field{Name: "added_bytes_packed", Value: float64(summary.BytesAddedPacked)},
+			Field{Name: "processed_bytes", Value: float64(summary.BytesTotal)},
+		)
+	}
+	return fields
+}
+
+// SummaryTags returns the default tags (profile, group and command) merged with additional tags from the configuration
+func SummaryTags(profile, group, command string, configTags map[string]string) map[string]string {
+	tags := map[string]string{"profile": profile, "command": command}
+	if group != "" {
+		tags["group"] = group
+	}
+	maps.Copy(tags, configTags)
+	return tags
+}
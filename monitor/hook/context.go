@@ -9,6 +9,7 @@ type Context struct {
 	ProfileCommand string
 	Error          ErrorContext
 	Stdout         string
+	Journal        string
 }
 
 type ErrorContext struct {
@@ -0,0 +1,48 @@
+package influx
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+)
+
+// Line formats a point in InfluxDB line protocol (without the final line feed).
+// Tags are sorted by key as recommended for best performance.
+func Line(measurement string, tags map[string]string, fields []monitor.Field, timestamp time.Time) string {
+	line := &strings.Builder{}
+	line.WriteString(measurementEscaper.Replace(measurement))
+
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if key == "" || tags[key] == "" {
+			continue // empty tags are not allowed
+		}
+		line.WriteByte(',')
+		line.WriteString(keyEscaper.Replace(key))
+		line.WriteByte('=')
+		line.WriteString(keyEscaper.Replace(tags[key]))
+	}
+
+	for i, field := range fields {
+		if i == 0 {
+			line.WriteByte(' ')
+		} else {
+			line.WriteByte(',')
+		}
+		line.WriteString(keyEscaper.Replace(field.Name))
+		line.WriteByte('=')
+		line.WriteString(strconv.FormatFloat(field.Value, 'f', -1, 64))
+	}
+
+	line.WriteByte(' ')
+	line.WriteString(strconv.FormatInt(timestamp.UnixNano(), 10))
+	return line.String()
+}
@@ -0,0 +1,46 @@
+package influx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLine(t *testing.T) {
+	timestamp := time.Unix(1700000000, 5)
+	testCases := []struct {
+		name        string
+		measurement string
+		tags        map[string]string
+		fields      []monitor.Field
+		expected    string
+	}{
+		{
+			name:        "simple",
+			measurement: "resticprofile",
+			tags:        map[string]string{"profile": "home", "command": "backup"},
+			fields:      []monitor.Field{{Name: "status", Value: 2}, {Name: "duration_seconds", Value: 1.5}},
+			expected:    "resticprofile,command=backup,profile=home status=2,duration_seconds=1.5 1700000000000000005",
+		},
+		{
+			name:        "escaping",
+			measurement: "my measurement,1",
+			tags:        map[string]string{"profile": "my profile", "a=b": "c,d", "empty": ""},
+			fields:      []monitor.Field{{Name: "files new", Value: 10}},
+			expected:    `my\ measurement\,1,a\=b=c\,d,profile=my\ profile files\ new=10 1700000000000000005`,
+		},
+		{
+			name:        "large value",
+			measurement: "m",
+			fields:      []monitor.Field{{Name: "bytes", Value: 12345678901234}},
+			expected:    "m bytes=12345678901234 1700000000000000005",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, Line(testCase.measurement, testCase.tags, testCase.fields, timestamp))
+		})
+	}
+}
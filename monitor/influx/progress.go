@@ -0,0 +1,54 @@
+package influx
+
+import (
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+// Progress is a monitor.Receiver writing a summary of each command in InfluxDB line protocol
+type Progress struct {
+	profile *config.Profile
+	group   string
+	timeout time.Duration
+}
+
+func NewProgress(profile *config.Profile, group string, timeout time.Duration) *Progress {
+	return &Progress{
+		profile: profile,
+		group:   group,
+		timeout: timeout,
+	}
+}
+
+func (p *Progress) Start(command string) {
+	// nothing to do here
+}
+
+func (p *Progress) Status(status monitor.Status) {
+	// we don't report any progress here
+}
+
+func (p *Progress) Summary(command string, summary monitor.Summary, stderr string, result error) {
+	if p.profile.InfluxDBWrite.Value() == "" {
+		return
+	}
+	measurement := p.profile.InfluxDBMeasurement
+	if measurement == "" {
+		measurement = constants.DefaultInfluxDBMeasurement
+	}
+	tags := monitor.SummaryTags(p.profile.Name, p.group, command, p.profile.InfluxDBTags)
+	line := Line(measurement, tags, monitor.SummaryFields(command, summary, result), time.Now())
+
+	err := Write(p.profile.InfluxDBWrite.Value(), p.profile.InfluxDBToken.Value(), []string{line}, p.timeout)
+	if err != nil {
+		// not important enough to throw an error here
+		clog.Warningf("writing influxdb metrics to %q: %v", p.profile.InfluxDBWrite.String(), err)
+	}
+}
+
+// Verify interface
+var _ monitor.Receiver = &Progress{}
@@ -0,0 +1,99 @@
+package influx
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProfile(target string) *config.Profile {
+	profile := config.NewProfile(nil, "home")
+	profile.InfluxDBWrite = config.NewConfidentialValue(target)
+	return profile
+}
+
+func TestSummaryToFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "metrics.influx")
+	profile := newTestProfile(filename)
+	profile.InfluxDBTags = map[string]string{"host": "server"}
+
+	progress := NewProgress(profile, "nightly", time.Second)
+	progress.Summary("backup", monitor.Summary{Duration: 2 * time.Second, FilesNew: 3, BytesAdded: 1024}, "", nil)
+	progress.Summary("check", monitor.Summary{Duration: time.Second}, "", errors.New("failed"))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	assert.True(t, strings.HasPrefix(lines[0], "resticprofile,command=backup,group=nightly,host=server,profile=home duration_seconds=2,status=2,time_seconds="))
+	assert.Contains(t, lines[0], ",files_new=3,")
+	assert.Contains(t, lines[0], ",added_bytes=1024,")
+
+	assert.True(t, strings.HasPrefix(lines[1], "resticprofile,command=check,group=nightly,host=server,profile=home duration_seconds=1,status=0,time_seconds="))
+	assert.NotContains(t, lines[1], "files_new")
+}
+
+func TestSummaryToUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	profile := newTestProfile("udp://" + listener.LocalAddr().String())
+	profile.InfluxDBMeasurement = "backups"
+	NewProgress(profile, "", time.Second).Summary("prune", monitor.Summary{}, "", nil)
+
+	buffer := make([]byte, 1024)
+	require.NoError(t, listener.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := listener.ReadFrom(buffer)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(buffer[:n]), "backups,command=prune,profile=home duration_seconds=0,status=2,"))
+}
+
+func TestSummaryToHTTP(t *testing.T) {
+	var body, authorization, query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		body = string(content)
+		authorization = r.Header.Get("Authorization")
+		query = r.URL.RawQuery
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	profile := newTestProfile(server.URL + "/api/v2/write?org=home&bucket=backup")
+	profile.InfluxDBToken = config.NewConfidentialValue("secret")
+	NewProgress(profile, "", time.Second).Summary("backup", monitor.Summary{}, "", nil)
+
+	assert.True(t, strings.HasPrefix(body, "resticprofile,command=backup,profile=home "))
+	assert.True(t, strings.HasSuffix(body, "\n"))
+	assert.Equal(t, "Token secret", authorization)
+	assert.Equal(t, "org=home&bucket=backup", query)
+}
+
+func TestWriteHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := Write(server.URL+"/write?db=missing", "", []string{"m v=1 0"}, time.Second)
+	assert.ErrorContains(t, err, "database not found")
+}
+
+func TestWriteUnsupportedScheme(t *testing.T) {
+	err := Write("tcp://localhost:8086", "", []string{"m v=1 0"}, time.Second)
+	assert.ErrorContains(t, err, "unsupported")
+}
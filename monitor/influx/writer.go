@@ -0,0 +1,85 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Write sends lines in InfluxDB line protocol to the target, which can be:
+//   - a file path: lines are appended to the file
+//   - an "udp://host:port" address
+//   - an "http://" or "https://" URL of a write endpoint (v1 "/write" or v2 "/api/v2/write")
+func Write(target, token string, lines []string, timeout time.Duration) error {
+	body := []byte(strings.Join(lines, "\n") + "\n")
+
+	scheme, address, found := strings.Cut(target, "://")
+	if !found {
+		return writeFile(target, body)
+	}
+	switch strings.ToLower(scheme) {
+	case "udp", "udp4", "udp6":
+		return writeUDP(strings.ToLower(scheme), address, body, timeout)
+	case "http", "https":
+		return writeHTTP(target, token, body, timeout)
+	default:
+		return fmt.Errorf("unsupported influxdb target scheme %q", scheme)
+	}
+}
+
+func writeFile(filename string, body []byte) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func writeUDP(network, address string, body []byte, timeout time.Duration) error {
+	conn, err := net.DialTimeout(network, strings.TrimSuffix(address, "/"), timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(body)
+	return err
+}
+
+func writeHTTP(target, token string, body []byte, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("HTTP %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
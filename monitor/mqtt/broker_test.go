@@ -0,0 +1,147 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type message struct {
+	Topic   string
+	Payload string
+	QoS     byte
+	Retain  bool
+}
+
+type connection struct {
+	ClientID, Username, Password string
+}
+
+// testBroker is a local stand-in for an MQTT broker accepting connections and recording published messages
+type testBroker struct {
+	listener    net.Listener
+	returnCode  byte
+	mutex       sync.Mutex
+	connections []connection
+	messages    []message
+	wg          sync.WaitGroup
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	broker := &testBroker{listener: listener}
+	broker.wg.Add(1)
+	go broker.serve()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		broker.wg.Wait()
+	})
+	return broker
+}
+
+func (b *testBroker) URL() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *testBroker) Messages() []message {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]message{}, b.messages...)
+}
+
+func (b *testBroker) Connections() []connection {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]connection{}, b.connections...)
+}
+
+func (b *testBroker) serve() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			defer conn.Close()
+			b.handle(conn)
+		}()
+	}
+}
+
+func (b *testBroker) handle(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		header, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		length, err := readRemainingLength(reader)
+		if err != nil {
+			return
+		}
+		body := make([]byte, length)
+		if _, err = io.ReadFull(reader, body); err != nil {
+			return
+		}
+		switch header & 0xF0 {
+		case packetConnect:
+			b.connect(body)
+			_, _ = conn.Write([]byte{packetConnAck, 2, 0, b.returnCode})
+			if b.returnCode != 0 {
+				return
+			}
+		case packetPublish:
+			qos := header >> 1 & 0x03
+			topic, rest := readString(body)
+			var id []byte
+			if qos > 0 {
+				id, rest = rest[:2], rest[2:]
+			}
+			b.mutex.Lock()
+			b.messages = append(b.messages, message{Topic: topic, Payload: string(rest), QoS: qos, Retain: header&0x01 == 1})
+			b.mutex.Unlock()
+			switch qos {
+			case 1:
+				_, _ = conn.Write(append([]byte{packetPubAck, 2}, id...))
+			case 2:
+				_, _ = conn.Write(append([]byte{packetPubRec, 2}, id...))
+			}
+		case packetPubRel & 0xF0:
+			_, _ = conn.Write(append([]byte{packetPubComp, 2}, body...))
+		case packetDisconnect:
+			return
+		}
+	}
+}
+
+func (b *testBroker) connect(body []byte) {
+	_, rest := readString(body) // protocol name
+	flags := rest[1]
+	rest = rest[4:] // level, flags & keep alive
+	c := connection{}
+	c.ClientID, rest = readString(rest)
+	if flags&0x80 != 0 {
+		c.Username, rest = readString(rest)
+	}
+	if flags&0x40 != 0 {
+		c.Password, _ = readString(rest)
+	}
+	b.mutex.Lock()
+	b.connections = append(b.connections, c)
+	b.mutex.Unlock()
+}
+
+func readString(buffer []byte) (string, []byte) {
+	length := int(binary.BigEndian.Uint16(buffer))
+	return string(buffer[2 : 2+length]), buffer[2+length:]
+}
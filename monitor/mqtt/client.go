@@ -0,0 +1,299 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	packetConnect    byte = 0x10
+	packetConnAck    byte = 0x20
+	packetPublish    byte = 0x30
+	packetPubAck     byte = 0x40
+	packetPubRec     byte = 0x50
+	packetPubRel     byte = 0x62
+	packetPubComp    byte = 0x70
+	packetDisconnect byte = 0xE0
+
+	protocolLevel   byte = 4
+	maxRemainingLen      = 268_435_455
+	defaultPort          = "1883"
+	defaultTLSPort       = "8883"
+)
+
+var connectReturnCodes = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// ClientOptions configures the connection to the broker
+type ClientOptions struct {
+	Broker    string // URL of the broker: tcp://, mqtt://, ssl://, tls:// or mqtts://
+	ClientID  string
+	Username  string
+	Password  string
+	TLSConfig *tls.Config // used when the broker scheme requires TLS
+	Timeout   time.Duration
+}
+
+// Client is a minimal MQTT 3.1.1 client which can only publish messages
+type Client struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	timeout  time.Duration
+	packetID uint16
+}
+
+// Connect opens a new session with the broker
+func Connect(ctx context.Context, options ClientOptions) (*Client, error) {
+	address, useTLS, user, err := parseBroker(options.Broker)
+	if err != nil {
+		return nil, err
+	}
+	// the credentials of the broker URL are used when not configured otherwise
+	if user != nil && options.Username == "" {
+		options.Username = user.Username()
+		if password, ok := user.Password(); ok && options.Password == "" {
+			options.Password = password
+		}
+	}
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	var conn net.Conn
+	if useTLS {
+		tlsConfig := options.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to MQTT broker: %w", err)
+	}
+
+	client := &Client{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		timeout: options.Timeout,
+	}
+	if err = client.connect(options); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// Publish sends a message to the broker. With QoS 1 or 2 the function waits for the broker acknowledgement.
+func (c *Client) Publish(topic string, payload []byte, qos byte, retain bool) error {
+	if qos > 2 {
+		return fmt.Errorf("invalid QoS %d", qos)
+	}
+	header := packetPublish | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	var id uint16
+	if qos > 0 {
+		id = c.nextPacketID()
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, payload...)
+
+	if err := c.write(header, body); err != nil {
+		return err
+	}
+	switch qos {
+	case 1:
+		return c.expect(packetPubAck, id)
+	case 2:
+		if err := c.expect(packetPubRec, id); err != nil {
+			return err
+		}
+		if err := c.write(packetPubRel, binary.BigEndian.AppendUint16(nil, id)); err != nil {
+			return err
+		}
+		return c.expect(packetPubComp, id)
+	}
+	return nil
+}
+
+// Close sends a disconnect packet and closes the connection
+func (c *Client) Close() error {
+	err := c.write(packetDisconnect, nil)
+	return errors.Join(err, c.conn.Close())
+}
+
+func (c *Client) connect(options ClientOptions) error {
+	var flags byte = 0x02 // clean session
+	body := appendString(nil, "MQTT")
+	body = append(body, protocolLevel)
+	if options.Username != "" {
+		flags |= 0x80
+		if options.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, 0) // no keep alive: the session is short lived
+	body = appendString(body, options.ClientID)
+	if options.Username != "" {
+		body = appendString(body, options.Username)
+		if options.Password != "" {
+			body = appendString(body, options.Password)
+		}
+	}
+	if err := c.write(packetConnect, body); err != nil {
+		return err
+	}
+
+	header, payload, err := c.read()
+	if err != nil {
+		return fmt.Errorf("cannot read CONNACK: %w", err)
+	}
+	if header != packetConnAck || len(payload) != 2 {
+		return fmt.Errorf("unexpected packet 0x%02x instead of CONNACK", header)
+	}
+	if code := payload[1]; code != 0 {
+		if message, found := connectReturnCodes[code]; found {
+			return fmt.Errorf("connection refused by MQTT broker: %s", message)
+		}
+		return fmt.Errorf("connection refused by MQTT broker: return code %d", code)
+	}
+	return nil
+}
+
+func (c *Client) nextPacketID() uint16 {
+	c.packetID++
+	if c.packetID == 0 {
+		c.packetID = 1
+	}
+	return c.packetID
+}
+
+// expect reads the next packet and verifies it's an acknowledgement of the expected type and packet ID
+func (c *Client) expect(expected byte, id uint16) error {
+	header, payload, err := c.read()
+	if err != nil {
+		return err
+	}
+	if header&0xF0 != expected&0xF0 || len(payload) < 2 {
+		return fmt.Errorf("unexpected packet 0x%02x instead of 0x%02x", header, expected)
+	}
+	if received := binary.BigEndian.Uint16(payload); received != id {
+		return fmt.Errorf("unexpected packet ID %d instead of %d", received, id)
+	}
+	return nil
+}
+
+func (c *Client) write(header byte, body []byte) error {
+	if len(body) > maxRemainingLen {
+		return fmt.Errorf("MQTT packet too large (%d bytes)", len(body))
+	}
+	packet := make([]byte, 0, len(body)+5)
+	packet = append(packet, header)
+	packet = appendRemainingLength(packet, len(body))
+	packet = append(packet, body...)
+
+	c.setDeadline()
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+func (c *Client) read() (header byte, payload []byte, err error) {
+	c.setDeadline()
+	header, err = c.reader.ReadByte()
+	if err != nil {
+		return
+	}
+	length, err := readRemainingLength(c.reader)
+	if err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	_, err = io.ReadFull(c.reader, payload)
+	return
+}
+
+func (c *Client) setDeadline() {
+	if c.timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+}
+
+// parseBroker returns the network address of the broker, whether TLS is required and the credentials of the URL (if any)
+func parseBroker(broker string) (address string, useTLS bool, user *url.Userinfo, err error) {
+	if !strings.Contains(broker, "://") {
+		broker = "tcp://" + broker
+	}
+	u, err := url.Parse(broker)
+	if err != nil {
+		return "", false, nil, fmt.Errorf("invalid MQTT broker URL: %w", err)
+	}
+	port := defaultPort
+	switch strings.ToLower(u.Scheme) {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS = true
+		port = defaultTLSPort
+	default:
+		return "", false, nil, fmt.Errorf("unsupported MQTT broker scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", false, nil, fmt.Errorf("missing host in MQTT broker URL %q", broker)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, u.User, nil
+}
+
+func appendString(buffer []byte, value string) []byte {
+	buffer = binary.BigEndian.AppendUint16(buffer, uint16(len(value))) //nolint:gosec
+	return append(buffer, value...)
+}
+
+func appendRemainingLength(buffer []byte, length int) []byte {
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		buffer = append(buffer, digit)
+		if length == 0 {
+			return buffer
+		}
+	}
+}
+
+func readRemainingLength(reader io.ByteReader) (int, error) {
+	length, multiplier := 0, 1
+	for range 4 {
+		digit, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		length += int(digit&0x7F) * multiplier
+		if digit&0x80 == 0 {
+			return length, nil
+		}
+		multiplier *= 128
+	}
+	return 0, errors.New("malformed remaining length")
+}
@@ -0,0 +1,109 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+var invalidDiscoveryID = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// discoveryDevice groups all the sensors of a profile in Home Assistant
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+}
+
+// discoveryConfig is the payload of a Home Assistant MQTT discovery message for a sensor
+type discoveryConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	ObjectID          string          `json:"object_id"`
+	StateTopic        string          `json:"state_topic"`
+	ValueTemplate     string          `json:"value_template"`
+	UnitOfMeasurement string          `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string          `json:"device_class,omitempty"`
+	StateClass        string          `json:"state_class,omitempty"`
+	Icon              string          `json:"icon,omitempty"`
+	Device            discoveryDevice `json:"device"`
+}
+
+type discoverySensor struct {
+	key, name, event, template, unit, deviceClass, stateClass, icon string
+}
+
+var discoverySensors = []discoverySensor{
+	{key: "status", name: "status", event: monitor.EventSummary, template: "{{ value_json.status }}", icon: "mdi:backup-restore"},
+	{key: "last_run", name: "last run", event: monitor.EventSummary, template: "{{ value_json.time }}", deviceClass: "timestamp"},
+	{key: "duration", name: "duration", event: monitor.EventSummary, template: "{{ value_json.duration | round(0) }}", unit: "s", deviceClass: "duration", stateClass: "measurement"},
+	{key: "files_new", name: "new files", event: monitor.EventSummary, template: "{{ value_json.files_new }}", stateClass: "measurement", icon: "mdi:file-plus"},
+	{key: "files_changed", name: "changed files", event: monitor.EventSummary, template: "{{ value_json.files_changed }}", stateClass: "measurement", icon: "mdi:file-edit"},
+	{key: "bytes_added", name: "added bytes", event: monitor.EventSummary, template: "{{ value_json.bytes_added }}", unit: "B", deviceClass: "data_size", stateClass: "measurement"},
+	{key: "bytes_total", name: "processed bytes", event: monitor.EventSummary, template: "{{ value_json.bytes_total }}", unit: "B", deviceClass: "data_size", stateClass: "measurement"},
+	{key: "progress", name: "progress", event: monitor.EventStatus, template: "{{ (value_json.percent_done * 100) | round(1) }}", unit: "%", stateClass: "measurement", icon: "mdi:progress-clock"},
+}
+
+// DiscoveryTopic returns the Home Assistant discovery topic of a sensor
+func (p *Progress) DiscoveryTopic(command, key string) string {
+	prefix := p.profile.MqttHomeAssistantPrefix
+	if prefix == "" {
+		prefix = constants.DefaultMqttHomeAssistantPrefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/sensor/" + p.discoveryNodeID() + "/" + discoveryID(command+"_"+key) + "/config"
+}
+
+func (p *Progress) discoveryNodeID() string {
+	return discoveryID("resticprofile_" + p.profile.Name)
+}
+
+// publishDiscovery sends the Home Assistant discovery messages for all the sensors of a command (once per command)
+func (p *Progress) publishDiscovery(command string) {
+	if p.discovered[command] {
+		return
+	}
+	p.discovered[command] = true
+
+	nodeID := p.discoveryNodeID()
+	device := discoveryDevice{
+		Identifiers:  []string{nodeID},
+		Name:         "resticprofile " + p.profile.Name,
+		Manufacturer: "resticprofile",
+		Model:        p.group,
+		SWVersion:    p.version,
+	}
+	for _, sensor := range discoverySensors {
+		if sensor.event == monitor.EventStatus && command != constants.CommandBackup {
+			continue // only backup is sending progress
+		}
+		objectID := discoveryID(nodeID + "_" + command + "_" + sensor.key)
+		payload, err := json.Marshal(discoveryConfig{
+			Name:              command + " " + sensor.name,
+			UniqueID:          objectID,
+			ObjectID:          objectID,
+			StateTopic:        p.Topic(command, sensor.event),
+			ValueTemplate:     sensor.template,
+			UnitOfMeasurement: sensor.unit,
+			DeviceClass:       sensor.deviceClass,
+			StateClass:        sensor.stateClass,
+			Icon:              sensor.icon,
+			Device:            device,
+		})
+		if err != nil {
+			clog.Warningf("encoding Home Assistant discovery for %q: %v", sensor.key, err)
+			continue
+		}
+		// discovery messages are always retained so they survive a restart of Home Assistant
+		p.send(p.DiscoveryTopic(command, sensor.key), payload, true)
+	}
+}
+
+func discoveryID(value string) string {
+	return strings.ToLower(invalidDiscoveryID.ReplaceAllString(value, "_"))
+}
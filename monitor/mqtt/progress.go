@@ -0,0 +1,273 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+const (
+	// minStatusInterval limits the rate of status events sent to the broker
+	minStatusInterval = time.Second
+	connectTimeout    = 10 * time.Second
+	// closeTimeout is the time given to the messages in the queue to be published at the end of the profile run
+	closeTimeout = 10 * time.Second
+	// queueSize is the number of messages waiting to be published before dropping new ones
+	queueSize = 256
+)
+
+// Progress is a monitor.Receiver publishing events to an MQTT broker.
+// Messages are published in the background: a slow or unavailable broker never blocks the profile run.
+type Progress struct {
+	profile    *config.Profile
+	group      string
+	version    string
+	client     *Client
+	lastStatus time.Time
+	command    string
+	discovered map[string]bool
+	messages   chan queuedMessage
+	published  chan struct{}
+	dropped    int
+}
+
+// queuedMessage is a message waiting to be published
+type queuedMessage struct {
+	topic      string
+	payload    []byte
+	retain     bool
+	disconnect bool // end of the session after this message
+}
+
+func NewProgress(profile *config.Profile, group, version string) *Progress {
+	return &Progress{
+		profile:    profile,
+		group:      group,
+		version:    version,
+		discovered: make(map[string]bool),
+	}
+}
+
+func (p *Progress) Start(command string) {
+	if p.profile.MqttBroker.Value() == "" {
+		return
+	}
+	p.command = command
+	if p.profile.MqttHomeAssistant {
+		p.publishDiscovery(command)
+	}
+	p.publish(command, monitor.EventStart, monitor.NewEventHeader(monitor.EventStart, p.profile.Name, p.group, command))
+}
+
+func (p *Progress) Status(status monitor.Status) {
+	if p.profile.MqttBroker.Value() == "" || p.command == "" {
+		return
+	}
+	if time.Since(p.lastStatus) < minStatusInterval {
+		return
+	}
+	p.lastStatus = time.Now()
+	header := monitor.NewEventHeader(monitor.EventStatus, p.profile.Name, p.group, p.command)
+	p.publish(p.command, monitor.EventStatus, monitor.NewStatusEvent(header, status))
+}
+
+func (p *Progress) Summary(command string, summary monitor.Summary, stderr string, result error) {
+	if p.profile.MqttBroker.Value() == "" {
+		return
+	}
+	header := monitor.NewEventHeader(monitor.EventSummary, p.profile.Name, p.group, command)
+	// stderr is not published: it can be too large for a message
+	payload, err := json.Marshal(monitor.NewSummaryEvent(header, summary, "", result))
+	if err != nil {
+		clog.Warningf("encoding MQTT %s event: %v", monitor.EventSummary, err)
+		return
+	}
+	// one session per command
+	p.enqueue(queuedMessage{topic: p.Topic(command, monitor.EventSummary), payload: payload, retain: p.profile.MqttRetain, disconnect: true})
+	p.command = ""
+	p.lastStatus = time.Time{}
+}
+
+// Close waits for the queued messages to be published
+func (p *Progress) Close() error {
+	if p.messages == nil {
+		return nil
+	}
+	if p.dropped > 0 {
+		clog.Warningf("MQTT broker %q is too slow: %d messages were dropped", p.profile.MqttBroker.String(), p.dropped)
+	}
+	close(p.messages)
+	p.messages = nil
+	select {
+	case <-p.published:
+		return nil
+	case <-time.After(closeTimeout):
+		err := fmt.Errorf("messages not published to MQTT broker %q after %s", p.profile.MqttBroker.String(), closeTimeout)
+		clog.Warning(err)
+		return err
+	}
+}
+
+// Topic returns the topic to publish the event of the command to
+func (p *Progress) Topic(command, event string) string {
+	topic := p.profile.MqttTopic
+	if topic == "" {
+		topic = constants.DefaultMqttTopic
+	}
+	topic = os.Expand(topic, func(name string) string {
+		switch strings.ToLower(name) {
+		case "profile":
+			return p.profile.Name
+		case "group":
+			return p.group
+		case "command":
+			return command
+		case "$":
+			return "$" // allow to escape "$" as "$$"
+		}
+		return ""
+	})
+	return strings.TrimSuffix(topic, "/") + "/" + event
+}
+
+func (p *Progress) publish(command, event string, message any) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		clog.Warningf("encoding MQTT %s event: %v", event, err)
+		return
+	}
+	p.send(p.Topic(command, event), payload, p.profile.MqttRetain)
+}
+
+func (p *Progress) send(topic string, payload []byte, retain bool) {
+	p.enqueue(queuedMessage{topic: topic, payload: payload, retain: retain})
+}
+
+// enqueue adds the message to the queue of the background publisher, or drops it when the queue is full
+func (p *Progress) enqueue(message queuedMessage) {
+	if p.messages == nil {
+		p.messages = make(chan queuedMessage, queueSize)
+		p.published = make(chan struct{})
+		p.dropped = 0
+		go p.publishQueue(p.messages, p.published)
+	}
+	select {
+	case p.messages <- message:
+	default:
+		p.dropped++
+	}
+}
+
+// publishQueue publishes the messages until the channel is closed.
+// After a failed connection to the broker, the remaining messages of the run are dropped.
+func (p *Progress) publishQueue(messages <-chan queuedMessage, published chan<- struct{}) {
+	defer close(published)
+	defer p.disconnect()
+	failed := false
+	for message := range messages {
+		if failed {
+			continue // drain the channel
+		}
+		if err := p.publishMessage(message); err != nil {
+			failed = true
+			// not important enough to throw an error here
+			clog.Warningf("publishing to MQTT broker %q: %v", p.profile.MqttBroker.String(), err)
+			continue
+		}
+		if message.disconnect {
+			p.disconnect()
+		}
+	}
+}
+
+// publishMessage publishes the message, connecting to the broker when needed. The error is only returned when the broker cannot be reached.
+func (p *Progress) publishMessage(message queuedMessage) error {
+	qos := byte(min(max(p.profile.MqttQoS, 0), 2)) //nolint:gosec
+	for attempt := 0; attempt < 2; attempt++ {
+		if p.client == nil {
+			client, err := Connect(context.Background(), p.clientOptions())
+			if err != nil {
+				return err
+			}
+			p.client = client
+		}
+		err := p.client.Publish(message.topic, message.payload, qos, message.retain)
+		if err == nil {
+			return nil
+		}
+		// the connection may have been dropped by the broker: try again on a new session
+		p.disconnect()
+		if attempt > 0 {
+			clog.Warningf("publishing to MQTT topic %q: %v", message.topic, err)
+		}
+	}
+	return nil
+}
+
+func (p *Progress) disconnect() {
+	if p.client != nil {
+		_ = p.client.Close()
+		p.client = nil
+	}
+}
+
+func (p *Progress) clientOptions() ClientOptions {
+	clientID := p.profile.MqttClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("resticprofile-%s-%d", p.profile.Name, os.Getpid())
+	}
+	return ClientOptions{
+		Broker:    p.profile.MqttBroker.Value(),
+		ClientID:  clientID,
+		Username:  p.profile.MqttUsername,
+		Password:  p.profile.MqttPassword.Value(),
+		TLSConfig: p.tlsConfig(),
+		Timeout:   connectTimeout,
+	}
+}
+
+func (p *Progress) tlsConfig() *tls.Config {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: p.profile.MqttSkipTLS, //nolint:gosec
+	}
+	if p.profile.MqttCACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if caCert, err := os.ReadFile(p.profile.MqttCACert); err != nil {
+			clog.Warningf("cannot load CA certificate: %s", err)
+		} else if !pool.AppendCertsFromPEM(caCert) {
+			clog.Warningf("invalid certificate: %q", p.profile.MqttCACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if p.profile.MqttTLSClientCert != "" {
+		// same as restic: the file contains both the certificate and the private key
+		certificate, err := tls.LoadX509KeyPair(p.profile.MqttTLSClientCert, p.profile.MqttTLSClientCert)
+		if err != nil {
+			clog.Warningf("cannot load TLS client certificate: %s", err)
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+	}
+	return tlsConfig
+}
+
+// Verify interfaces
+var (
+	_ monitor.Receiver = &Progress{}
+	_ io.Closer        = &Progress{}
+)
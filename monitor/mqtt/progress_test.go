@@ -0,0 +1,232 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProfile(broker string) *config.Profile {
+	profile := config.NewProfile(nil, "home")
+	profile.MqttBroker = config.NewConfidentialValue(broker)
+	return profile
+}
+
+func TestTopic(t *testing.T) {
+	testCases := []struct {
+		template, expected string
+	}{
+		{template: "", expected: "resticprofile/home/backup/start"},
+		{template: "backups/$PROFILE/$command/", expected: "backups/home/backup/start"},
+		{template: "$group/${profile}", expected: "nightly/home/start"},
+		{template: "cost$$/$unknown", expected: "cost$/start"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.template, func(t *testing.T) {
+			profile := newTestProfile("localhost")
+			profile.MqttTopic = testCase.template
+			progress := NewProgress(profile, "nightly", "1.0")
+			assert.Equal(t, testCase.expected, progress.Topic("backup", monitor.EventStart))
+		})
+	}
+}
+
+func TestPublishEvents(t *testing.T) {
+	broker := newTestBroker(t)
+	profile := newTestProfile(broker.URL())
+	profile.MqttQoS = 1
+	profile.MqttRetain = true
+	profile.MqttUsername = "user"
+	profile.MqttPassword = config.NewConfidentialValue("secret")
+
+	progress := NewProgress(profile, "", "1.0")
+	progress.Start("backup")
+	progress.Status(monitor.Status{PercentDone: 0.5, TotalFiles: 10, FilesDone: 5})
+	progress.Status(monitor.Status{PercentDone: 0.6}) // too soon after the first status
+	progress.Summary("backup", monitor.Summary{Duration: 3 * time.Second, FilesNew: 2, BytesAdded: 100}, "", nil)
+	require.NoError(t, progress.Close())
+
+	require.Eventually(t, func() bool { return len(broker.Messages()) == 3 }, time.Second, 10*time.Millisecond)
+	messages := broker.Messages()
+
+	assert.Equal(t, "resticprofile/home/backup/start", messages[0].Topic)
+	assert.Equal(t, "resticprofile/home/backup/status", messages[1].Topic)
+	assert.Equal(t, "resticprofile/home/backup/summary", messages[2].Topic)
+	for _, message := range messages {
+		assert.Equal(t, byte(1), message.QoS)
+		assert.True(t, message.Retain)
+	}
+
+	status := make(map[string]any)
+	require.NoError(t, json.Unmarshal([]byte(messages[1].Payload), &status))
+	assert.Equal(t, 0.5, status["percent_done"])
+	assert.Equal(t, float64(5), status["files_done"])
+
+	summary := make(map[string]any)
+	require.NoError(t, json.Unmarshal([]byte(messages[2].Payload), &summary))
+	assert.Equal(t, "success", summary["status"])
+	assert.Equal(t, float64(3), summary["duration"])
+	assert.Equal(t, float64(2), summary["files_new"])
+	assert.NotContains(t, summary, "error")
+
+	// one session for the whole command
+	assert.Equal(t, []connection{{ClientID: progress.clientOptions().ClientID, Username: "user", Password: "secret"}}, broker.Connections())
+}
+
+func TestPublishFailure(t *testing.T) {
+	broker := newTestBroker(t)
+	progress := NewProgress(newTestProfile(broker.URL()), "", "1.0")
+	progress.Start("check")
+	progress.Summary("check", monitor.Summary{}, "stderr", errors.New("check failed"))
+
+	require.Eventually(t, func() bool { return len(broker.Messages()) == 2 }, time.Second, 10*time.Millisecond)
+	summary := make(map[string]any)
+	require.NoError(t, json.Unmarshal([]byte(broker.Messages()[1].Payload), &summary))
+	assert.Equal(t, "failed", summary["status"])
+	assert.Equal(t, "check failed", summary["error"])
+}
+
+func TestHomeAssistantDiscovery(t *testing.T) {
+	broker := newTestBroker(t)
+	profile := newTestProfile(broker.URL())
+	profile.Name = "My Profile"
+	profile.MqttHomeAssistant = true
+
+	progress := NewProgress(profile, "", "1.0")
+	progress.Start("backup")
+	progress.Summary("backup", monitor.Summary{}, "", nil)
+	progress.Start("backup") // discovery is only sent once per command
+	progress.Summary("backup", monitor.Summary{}, "", nil)
+
+	expected := len(discoverySensors) + 4
+	require.Eventually(t, func() bool { return len(broker.Messages()) == expected }, time.Second, 10*time.Millisecond)
+
+	discovery := make([]message, 0)
+	for _, message := range broker.Messages() {
+		if strings.HasPrefix(message.Topic, "homeassistant/") {
+			discovery = append(discovery, message)
+		}
+	}
+	require.Len(t, discovery, len(discoverySensors))
+
+	assert.Equal(t, "homeassistant/sensor/resticprofile_my_profile/backup_status/config", discovery[0].Topic)
+	assert.True(t, discovery[0].Retain)
+
+	cfg := discoveryConfig{}
+	require.NoError(t, json.Unmarshal([]byte(discovery[0].Payload), &cfg))
+	assert.Equal(t, "resticprofile/My Profile/backup/summary", cfg.StateTopic)
+	assert.Equal(t, "resticprofile_my_profile_backup_status", cfg.UniqueID)
+	assert.Equal(t, []string{"resticprofile_my_profile"}, cfg.Device.Identifiers)
+	assert.Equal(t, "1.0", cfg.Device.SWVersion)
+}
+
+func TestNoProgressSensorOutsideBackup(t *testing.T) {
+	broker := newTestBroker(t)
+	profile := newTestProfile(broker.URL())
+	profile.MqttHomeAssistant = true
+	profile.MqttHomeAssistantPrefix = "ha/"
+
+	progress := NewProgress(profile, "", "1.0")
+	progress.Start("check")
+	progress.Summary("check", monitor.Summary{}, "", nil)
+
+	expected := len(discoverySensors) - 1 + 2
+	require.Eventually(t, func() bool { return len(broker.Messages()) == expected }, time.Second, 10*time.Millisecond)
+	for _, message := range broker.Messages() {
+		assert.NotContains(t, message.Topic, "progress")
+	}
+	assert.Equal(t, "ha/sensor/resticprofile_home/check_status/config", broker.Messages()[0].Topic)
+}
+
+func TestBrokerUnavailable(t *testing.T) {
+	broker := newTestBroker(t)
+	url := broker.URL()
+	_ = broker.listener.Close()
+
+	progress := NewProgress(newTestProfile(url), "", "1.0")
+	assert.NotPanics(t, func() {
+		progress.Start("backup")
+		progress.Summary("backup", monitor.Summary{}, "", nil)
+		assert.NoError(t, progress.Close())
+	})
+}
+
+func TestNoReconnectAfterConnectionFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// the broker closes all connections before the CONNACK
+	accepted := atomic.Int32{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			_ = conn.Close()
+		}
+	}()
+
+	progress := NewProgress(newTestProfile("tcp://"+listener.Addr().String()), "", "1.0")
+	for _, command := range []string{"backup", "check"} {
+		progress.Start(command)
+		progress.Status(monitor.Status{PercentDone: 0.5})
+		progress.Summary(command, monitor.Summary{}, "", nil)
+	}
+	require.NoError(t, progress.Close())
+	assert.Equal(t, int32(1), accepted.Load())
+}
+
+func TestSlowBrokerDoesNotBlock(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// the broker accepts connections but never answers
+	mutex := sync.Mutex{}
+	connections := make([]net.Conn, 0, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			connections = append(connections, conn)
+			mutex.Unlock()
+		}
+	}()
+
+	profile := newTestProfile("tcp://" + listener.Addr().String())
+	profile.MqttQoS = 1
+	progress := NewProgress(profile, "", "1.0")
+	start := time.Now()
+	progress.Start("backup")
+	for range queueSize + 10 {
+		progress.lastStatus = time.Time{}
+		progress.Status(monitor.Status{PercentDone: 0.5})
+	}
+	progress.Summary("backup", monitor.Summary{}, "", nil)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Positive(t, progress.dropped)
+
+	// the broker goes away: the messages left in the queue are dropped
+	_ = listener.Close()
+	mutex.Lock()
+	for _, conn := range connections {
+		_ = conn.Close()
+	}
+	mutex.Unlock()
+	assert.NoError(t, progress.Close())
+}
@@ -0,0 +1,259 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tracesPath  = "/v1/traces"
+	metricsPath = "/v1/metrics"
+	scopeName   = "github.com/creativeprojects/resticprofile"
+	spanKind    = 1 // SPAN_KIND_INTERNAL
+)
+
+// Exporter sends traces and metrics to an OpenTelemetry collector using OTLP over HTTP with the JSON encoding
+type Exporter struct {
+	endpoint string
+	headers  map[string]string
+	resource []Attribute
+	version  string
+	client   *http.Client
+}
+
+// NewExporter creates an exporter to the base URL of an OTLP/HTTP endpoint, e.g. "http://localhost:4318".
+// The resource attributes describe the entity producing the telemetry.
+func NewExporter(endpoint string, headers map[string]string, resource []Attribute, version string, timeout time.Duration) *Exporter {
+	return &Exporter{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		headers:  headers,
+		resource: resource,
+		version:  version,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Export sends all the spans and metrics of the tracer. Spans still running are ended now.
+func (e *Exporter) Export(ctx context.Context, tracer *Tracer) error {
+	tracer.mu.Lock()
+	traces := e.encodeTraces(tracer)
+	var metrics *exportMetricsRequest
+	if len(tracer.metrics) > 0 {
+		metrics = e.encodeMetrics(tracer)
+	}
+	tracer.mu.Unlock()
+
+	err := e.post(ctx, tracesPath, traces)
+	if metrics != nil {
+		err = errors.Join(err, e.post(ctx, metricsPath, metrics))
+	}
+	return err
+}
+
+func (e *Exporter) post(ctx context.Context, path string, request any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("HTTP %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+func (e *Exporter) encodeTraces(tracer *Tracer) *exportTraceRequest {
+	now := time.Now()
+	spans := make([]otlpSpan, 0, len(tracer.spans))
+	for _, span := range tracer.spans {
+		end := span.end
+		if end.IsZero() {
+			end = now
+		}
+		encoded := otlpSpan{
+			TraceID:           hex.EncodeToString(tracer.traceID[:]),
+			SpanID:            hex.EncodeToString(span.id[:]),
+			Name:              span.name,
+			Kind:              spanKind,
+			StartTimeUnixNano: unixNano(span.start),
+			EndTimeUnixNano:   unixNano(end),
+			Attributes:        encodeAttributes(span.attributes),
+			Status:            otlpStatus{Code: span.status, Message: span.message},
+		}
+		if span.parentID != [8]byte{} {
+			encoded.ParentSpanID = hex.EncodeToString(span.parentID[:])
+		}
+		spans = append(spans, encoded)
+	}
+	return &exportTraceRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource:   otlpResource{Attributes: encodeAttributes(e.resource)},
+			ScopeSpans: []scopeSpans{{Scope: e.scope(), Spans: spans}},
+		}},
+	}
+}
+
+func (e *Exporter) encodeMetrics(tracer *Tracer) *exportMetricsRequest {
+	metrics := make([]otlpMetric, 0)
+	index := make(map[string]int)
+	for _, metric := range tracer.metrics {
+		i, found := index[metric.Name]
+		if !found {
+			i = len(metrics)
+			index[metric.Name] = i
+			metrics = append(metrics, otlpMetric{Name: metric.Name, Unit: metric.Unit})
+		}
+		metrics[i].Gauge.DataPoints = append(metrics[i].Gauge.DataPoints, otlpDataPoint{
+			Attributes:   encodeAttributes(metric.Attributes),
+			TimeUnixNano: unixNano(metric.Time),
+			AsDouble:     metric.Value,
+		})
+	}
+	return &exportMetricsRequest{
+		ResourceMetrics: []resourceMetrics{{
+			Resource:     otlpResource{Attributes: encodeAttributes(e.resource)},
+			ScopeMetrics: []scopeMetrics{{Scope: e.scope(), Metrics: metrics}},
+		}},
+	}
+}
+
+func (e *Exporter) scope() otlpScope {
+	return otlpScope{Name: scopeName, Version: e.version}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func encodeAttributes(attributes []Attribute) []otlpKeyValue {
+	encoded := make([]otlpKeyValue, 0, len(attributes))
+	for _, attribute := range attributes {
+		value := otlpAnyValue{}
+		switch v := attribute.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case bool:
+			value.BoolValue = &v
+		case int:
+			i := strconv.Itoa(v)
+			value.IntValue = &i
+		case int64:
+			i := strconv.FormatInt(v, 10)
+			value.IntValue = &i
+		case float64:
+			value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		encoded = append(encoded, otlpKeyValue{Key: attribute.Key, Value: value})
+	}
+	return encoded
+}
+
+// JSON encoding of the OTLP protobuf messages: https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type exportTraceRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   otlpResource `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type exportMetricsRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     otlpResource   `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type scopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name  string    `json:"name"`
+	Unit  string    `json:"unit,omitempty"`
+	Gauge otlpGauge `json:"gauge"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	AsDouble     float64        `json:"asDouble"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
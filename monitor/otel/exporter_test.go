@@ -0,0 +1,161 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type collector struct {
+	mu       sync.Mutex
+	requests map[string][]byte
+	headers  http.Header
+	status   int
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+	c := &collector{requests: make(map[string][]byte), status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.requests[r.URL.Path] = body
+		c.headers = r.Header.Clone()
+		w.WriteHeader(c.status)
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func (c *collector) decode(t *testing.T, path string, target any) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	body, found := c.requests[path]
+	require.Truef(t, found, "no request on %s", path)
+	require.NoError(t, json.Unmarshal(body, target))
+}
+
+func TestExportTraces(t *testing.T) {
+	c, server := newCollector(t)
+
+	tracer := NewTracer()
+	root := tracer.Start("root", String("resticprofile.profile", "root"))
+	start := time.Unix(1700000000, 0)
+	child := root.ChildAt("restic backup", start, Int("resticprofile.attempt", 1), Float("restic.files_new", 2), Bool("dry", false))
+	child.EndAt(start.Add(time.Second), errors.New("backup failed"))
+	root.End(nil)
+
+	exporter := NewExporter(server.URL+"/", map[string]string{"Authorization": "Bearer token"}, Resource(map[string]string{"service.name": "resticprofile", "host.name": "host"}), "1.0", time.Second)
+	require.NoError(t, exporter.Export(context.Background(), tracer))
+
+	assert.Equal(t, "application/json", c.headers.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", c.headers.Get("Authorization"))
+
+	request := exportTraceRequest{}
+	c.decode(t, tracesPath, &request)
+	require.Len(t, request.ResourceSpans, 1)
+	resource := request.ResourceSpans[0].Resource.Attributes
+	require.Len(t, resource, 2)
+	assert.Equal(t, "host.name", resource[0].Key)
+	assert.Equal(t, "service.name", resource[1].Key)
+	assert.Equal(t, "resticprofile", *resource[1].Value.StringValue)
+
+	require.Len(t, request.ResourceSpans[0].ScopeSpans, 1)
+	scope := request.ResourceSpans[0].ScopeSpans[0]
+	assert.Equal(t, otlpScope{Name: scopeName, Version: "1.0"}, scope.Scope)
+	require.Len(t, scope.Spans, 2)
+
+	rootSpan, childSpan := scope.Spans[0], scope.Spans[1]
+	assert.Equal(t, tracer.TraceID(), rootSpan.TraceID)
+	assert.Len(t, rootSpan.TraceID, 32)
+	assert.Len(t, rootSpan.SpanID, 16)
+	assert.Empty(t, rootSpan.ParentSpanID)
+	assert.Equal(t, otlpStatus{}, rootSpan.Status)
+
+	assert.Equal(t, tracer.TraceID(), childSpan.TraceID)
+	assert.Equal(t, rootSpan.SpanID, childSpan.ParentSpanID)
+	assert.Equal(t, "restic backup", childSpan.Name)
+	assert.Equal(t, "1700000000000000000", childSpan.StartTimeUnixNano)
+	assert.Equal(t, "1700000001000000000", childSpan.EndTimeUnixNano)
+	assert.Equal(t, otlpStatus{Code: statusError, Message: "backup failed"}, childSpan.Status)
+	require.Len(t, childSpan.Attributes, 3)
+	assert.Equal(t, "1", *childSpan.Attributes[0].Value.IntValue)
+	assert.Equal(t, 2.0, *childSpan.Attributes[1].Value.DoubleValue)
+	assert.False(t, *childSpan.Attributes[2].Value.BoolValue)
+
+	// no metric recorded
+	c.mu.Lock()
+	assert.NotContains(t, c.requests, metricsPath)
+	c.mu.Unlock()
+}
+
+func TestExportMetrics(t *testing.T) {
+	c, server := newCollector(t)
+
+	tracer := NewTracer()
+	tracer.Start("root").End(nil)
+	at := time.Unix(1700000000, 0)
+	tracer.RecordMetric(Metric{Name: "resticprofile.status", Unit: "1", Value: 2, Time: at, Attributes: []Attribute{String("resticprofile.command", "backup")}})
+	tracer.RecordMetric(Metric{Name: "resticprofile.duration_seconds", Unit: "s", Value: 1.5, Time: at})
+	tracer.RecordMetric(Metric{Name: "resticprofile.status", Unit: "1", Value: 0, Time: at, Attributes: []Attribute{String("resticprofile.command", "check")}})
+
+	require.NoError(t, NewExporter(server.URL, nil, nil, "", time.Second).Export(context.Background(), tracer))
+
+	request := exportMetricsRequest{}
+	c.decode(t, metricsPath, &request)
+	require.Len(t, request.ResourceMetrics, 1)
+	require.Len(t, request.ResourceMetrics[0].ScopeMetrics, 1)
+	metrics := request.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "resticprofile.status", metrics[0].Name)
+	assert.Equal(t, "1", metrics[0].Unit)
+	require.Len(t, metrics[0].Gauge.DataPoints, 2)
+	assert.Equal(t, 2.0, metrics[0].Gauge.DataPoints[0].AsDouble)
+	assert.Equal(t, "backup", *metrics[0].Gauge.DataPoints[0].Attributes[0].Value.StringValue)
+	assert.Equal(t, "check", *metrics[0].Gauge.DataPoints[1].Attributes[0].Value.StringValue)
+	assert.Equal(t, "1700000000000000000", metrics[0].Gauge.DataPoints[0].TimeUnixNano)
+
+	assert.Equal(t, "resticprofile.duration_seconds", metrics[1].Name)
+	require.Len(t, metrics[1].Gauge.DataPoints, 1)
+	assert.Equal(t, 1.5, metrics[1].Gauge.DataPoints[0].AsDouble)
+}
+
+func TestExportUnfinishedSpan(t *testing.T) {
+	c, server := newCollector(t)
+
+	tracer := NewTracer()
+	tracer.Start("root")
+	require.NoError(t, NewExporter(server.URL, nil, nil, "", time.Second).Export(context.Background(), tracer))
+
+	request := exportTraceRequest{}
+	c.decode(t, tracesPath, &request)
+	span := request.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	start, err := strconv.ParseInt(span.StartTimeUnixNano, 10, 64)
+	require.NoError(t, err)
+	end, err := strconv.ParseInt(span.EndTimeUnixNano, 10, 64)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, end, start)
+}
+
+func TestExportError(t *testing.T) {
+	c, server := newCollector(t)
+	c.status = http.StatusBadRequest
+
+	tracer := NewTracer()
+	tracer.Start("root").End(nil)
+	err := NewExporter(server.URL, nil, nil, "", time.Second).Export(context.Background(), tracer)
+	assert.ErrorContains(t, err, "400 Bad Request")
+}
@@ -0,0 +1,153 @@
+package otel
+
+import (
+	"errors"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+// Progress is a monitor.Receiver adding the spans of a profile run under the profile span
+type Progress struct {
+	span     *Span
+	profile  string
+	group    string
+	command  *Span
+	attempts map[string]int
+}
+
+// NewProgress creates a receiver adding spans under the span of the profile
+func NewProgress(profile *config.Profile, group string, span *Span) *Progress {
+	return &Progress{
+		span:     span,
+		profile:  profile.Name,
+		group:    group,
+		attempts: make(map[string]int),
+	}
+}
+
+func (p *Progress) Start(command string) {
+	p.attempts[command]++
+	p.command = p.span.Child("restic "+command,
+		String("resticprofile.command", command),
+		Int("resticprofile.attempt", int64(p.attempts[command])),
+	)
+}
+
+func (p *Progress) Status(monitor.Status) {
+	// progress is not traced
+}
+
+func (p *Progress) Summary(command string, summary monitor.Summary, _ string, result error) {
+	now := time.Now()
+	span := p.command
+	if span == nil {
+		span = p.span.ChildAt("restic "+command, now.Add(-summary.Duration), String("resticprofile.command", command))
+	}
+	p.command = nil
+
+	fields := monitor.SummaryFields(command, summary, result)
+	attributes := []Attribute{
+		String("resticprofile.status", monitor.StatusName(result)),
+		Int("process.exit.code", int64(exitCode(result))),
+	}
+	for _, field := range fields {
+		if field.Name == "status" || field.Name == "time_seconds" {
+			continue
+		}
+		attributes = append(attributes, Float("restic."+field.Name, field.Value))
+	}
+	span.SetAttributes(attributes...)
+	span.EndAt(now, result)
+
+	tags := monitor.SummaryTags(p.profile, p.group, command, nil)
+	metricAttributes := make([]Attribute, 0, len(tags))
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		metricAttributes = append(metricAttributes, String("resticprofile."+key, tags[key]))
+	}
+	for _, field := range fields {
+		p.span.tracer.RecordMetric(Metric{
+			Name:       "resticprofile." + field.Name,
+			Unit:       unitOf(field.Name),
+			Value:      field.Value,
+			Time:       now,
+			Attributes: metricAttributes,
+		})
+	}
+}
+
+func (p *Progress) Hook(result monitor.HookResult) {
+	now := time.Now()
+	attributes := []Attribute{
+		Int("resticprofile.hook.index", int64(result.Index)),
+		Int("resticprofile.hook.total", int64(result.Total)),
+	}
+	if result.Command != "" {
+		attributes = append(attributes, String("resticprofile.hook.section", result.Command))
+	}
+	p.parent().ChildAt(result.Type, now.Add(-result.Duration), attributes...).EndAt(now, result.Error)
+}
+
+func (p *Progress) Wait(wait monitor.Wait) {
+	attributes := []Attribute{String("resticprofile.command", wait.Command)}
+	if wait.Lock != "" {
+		attributes = append(attributes, String("resticprofile.lock", wait.Lock))
+	}
+	p.span.ChildAt(wait.Reason+" wait", wait.Start, attributes...).EndAt(wait.Start.Add(wait.Duration), nil)
+}
+
+func (p *Progress) Failure(_ string, failure monitor.Failure) {
+	attributes := []Attribute{String("error.message", failure.Message)}
+	if failure.ExitCode != "" {
+		attributes = append(attributes, String("resticprofile.error.exit_code", failure.ExitCode))
+	}
+	if failure.CommandLine != "" {
+		attributes = append(attributes, String("resticprofile.error.command_line", failure.CommandLine))
+	}
+	p.span.SetAttributes(attributes...)
+}
+
+// parent returns the span of the running restic command, or the profile span when no command is running
+func (p *Progress) parent() *Span {
+	if p.command != nil {
+		return p.command
+	}
+	return p.span
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	exitErr := &exec.ExitError{}
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func unitOf(field string) string {
+	switch {
+	case strings.HasSuffix(field, "_seconds"):
+		return "s"
+	case strings.HasSuffix(field, "_bytes") || strings.Contains(field, "_bytes_"):
+		return "By"
+	case field == "status":
+		return "1"
+	default:
+		return "{" + strings.SplitN(field, "_", 2)[0] + "}"
+	}
+}
+
+// Verify interfaces
+var (
+	_ monitor.Receiver        = &Progress{}
+	_ monitor.HookReceiver    = &Progress{}
+	_ monitor.WaitReceiver    = &Progress{}
+	_ monitor.FailureReceiver = &Progress{}
+)
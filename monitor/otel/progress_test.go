@@ -0,0 +1,122 @@
+package otel
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findSpan(t *testing.T, tracer *Tracer, name string) *Span {
+	t.Helper()
+	for _, span := range tracer.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not found", "no span named %q", name)
+	return nil
+}
+
+func attribute(span *Span, key string) any {
+	for _, attribute := range span.attributes {
+		if attribute.Key == key {
+			return attribute.Value
+		}
+	}
+	return nil
+}
+
+func TestProgressSpans(t *testing.T) {
+	tracer := NewTracer()
+	root := tracer.Start("home")
+	progress := NewProgress(config.NewProfile(nil, "home"), "nightly", root)
+
+	progress.Hook(monitor.HookResult{Type: "run-before", Index: 1, Total: 1, Duration: time.Second})
+	progress.Wait(monitor.Wait{Reason: monitor.WaitLock, Command: "backup", Lock: "/tmp/home.lock", Start: time.Now().Add(-time.Minute), Duration: time.Minute})
+	progress.Start("backup")
+	progress.Hook(monitor.HookResult{Type: "stream-error", Command: "backup", Index: 1, Total: 1})
+	progress.Summary("backup", monitor.Summary{Duration: 3 * time.Second, FilesNew: 2}, "", nil)
+	progress.Failure("backup", monitor.Failure{Message: "run-after failed", ExitCode: "1"})
+
+	require.Len(t, tracer.spans, 5)
+	for _, span := range tracer.spans[1:] {
+		assert.False(t, span.end.IsZero(), span.name)
+		assert.Equal(t, statusUnset, span.status, span.name)
+	}
+
+	hook := findSpan(t, tracer, "run-before")
+	assert.Equal(t, root.id, hook.parentID)
+	assert.Equal(t, time.Second, hook.end.Sub(hook.start))
+
+	wait := findSpan(t, tracer, "lock wait")
+	assert.Equal(t, root.id, wait.parentID)
+	assert.Equal(t, time.Minute, wait.end.Sub(wait.start))
+	assert.Equal(t, "/tmp/home.lock", attribute(wait, "resticprofile.lock"))
+
+	command := findSpan(t, tracer, "restic backup")
+	assert.Equal(t, root.id, command.parentID)
+	assert.Equal(t, int64(1), attribute(command, "resticprofile.attempt"))
+	assert.Equal(t, "success", attribute(command, "resticprofile.status"))
+	assert.Equal(t, int64(0), attribute(command, "process.exit.code"))
+	assert.Equal(t, 2.0, attribute(command, "restic.files_new"))
+	assert.Equal(t, 3.0, attribute(command, "restic.duration_seconds"))
+
+	// hook running during the command
+	streamError := findSpan(t, tracer, "stream-error")
+	assert.Equal(t, command.id, streamError.parentID)
+	assert.Equal(t, "backup", attribute(streamError, "resticprofile.hook.section"))
+
+	assert.Equal(t, "run-after failed", attribute(root, "error.message"))
+	assert.Equal(t, "1", attribute(root, "resticprofile.error.exit_code"))
+
+	metrics := make(map[string]Metric)
+	for _, metric := range tracer.metrics {
+		metrics[metric.Name] = metric
+	}
+	assert.Len(t, metrics, len(monitor.SummaryFields("backup", monitor.Summary{}, nil)))
+	assert.Equal(t, 2.0, metrics["resticprofile.files_new"].Value)
+	assert.Equal(t, "{files}", metrics["resticprofile.files_new"].Unit)
+	assert.Equal(t, "By", metrics["resticprofile.added_bytes_packed"].Unit)
+	assert.Equal(t, "s", metrics["resticprofile.duration_seconds"].Unit)
+	assert.Equal(t, []Attribute{
+		String("resticprofile.command", "backup"),
+		String("resticprofile.group", "nightly"),
+		String("resticprofile.profile", "home"),
+	}, metrics["resticprofile.status"].Attributes)
+}
+
+func TestProgressRetry(t *testing.T) {
+	tracer := NewTracer()
+	root := tracer.Start("home")
+	progress := NewProgress(config.NewProfile(nil, "home"), "", root)
+
+	progress.Start("check")
+	progress.Summary("check", monitor.Summary{}, "", errors.New("repository is already locked"))
+	progress.Wait(monitor.Wait{Reason: monitor.WaitRetry, Command: "check", Start: time.Now(), Duration: time.Second})
+	progress.Start("check")
+	progress.Summary("check", monitor.Summary{}, "", nil)
+
+	require.Len(t, tracer.spans, 4)
+	first, wait, second := tracer.spans[1], tracer.spans[2], tracer.spans[3]
+	assert.Equal(t, int64(1), attribute(first, "resticprofile.attempt"))
+	assert.Equal(t, statusError, first.status)
+	assert.Equal(t, "repository is already locked", first.message)
+	assert.Equal(t, int64(-1), attribute(first, "process.exit.code"))
+	assert.Equal(t, "retry wait", wait.name)
+	assert.Equal(t, int64(2), attribute(second, "resticprofile.attempt"))
+	assert.Equal(t, statusUnset, second.status)
+}
+
+func TestSummaryWithoutStart(t *testing.T) {
+	tracer := NewTracer()
+	progress := NewProgress(config.NewProfile(nil, "home"), "", tracer.Start("home"))
+
+	progress.Summary("forget", monitor.Summary{Duration: time.Minute}, "", nil)
+	span := findSpan(t, tracer, "restic forget")
+	assert.Equal(t, time.Minute, span.end.Sub(span.start))
+}
@@ -0,0 +1,156 @@
+package otel
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+// Attribute is a key-value pair attached to a span, a metric or the resource.
+// Supported values are string, bool, int, int64 and float64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Float(key string, value float64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Resource returns the attributes of the resource sorted by key
+func Resource(attributes map[string]string) []Attribute {
+	resource := make([]Attribute, 0, len(attributes))
+	for _, key := range slices.Sorted(maps.Keys(attributes)) {
+		resource = append(resource, String(key, attributes[key]))
+	}
+	return resource
+}
+
+// Metric is a gauge value recorded during the run
+type Metric struct {
+	Name       string
+	Unit       string
+	Value      float64
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// Tracer keeps all the spans and metrics of a run in memory until they are exported
+type Tracer struct {
+	mu      sync.Mutex
+	traceID [16]byte
+	spans   []*Span
+	metrics []Metric
+}
+
+func NewTracer() *Tracer {
+	t := &Tracer{}
+	_, _ = rand.Read(t.traceID[:])
+	return t
+}
+
+// TraceID returns the hex encoded ID of the trace
+func (t *Tracer) TraceID() string {
+	return hex.EncodeToString(t.traceID[:])
+}
+
+// Start creates the root span of the trace
+func (t *Tracer) Start(name string, attributes ...Attribute) *Span {
+	return t.newSpan(name, nil, time.Now(), attributes)
+}
+
+// RecordMetric adds a gauge value to export with the trace
+func (t *Tracer) RecordMetric(metric Metric) {
+	if metric.Time.IsZero() {
+		metric.Time = time.Now()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics = append(t.metrics, metric)
+}
+
+func (t *Tracer) newSpan(name string, parent *Span, start time.Time, attributes []Attribute) *Span {
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		start:      start,
+		attributes: attributes,
+	}
+	_, _ = rand.Read(span.id[:])
+	if parent != nil {
+		span.parentID = parent.id
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, span)
+	return span
+}
+
+// Span status codes as defined by OpenTelemetry
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// Span is a timed operation of the run
+type Span struct {
+	tracer     *Tracer
+	id         [8]byte
+	parentID   [8]byte
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes []Attribute
+	status     int
+	message    string
+}
+
+// Child starts a new span under this one
+func (s *Span) Child(name string, attributes ...Attribute) *Span {
+	return s.tracer.newSpan(name, s, time.Now(), attributes)
+}
+
+// ChildAt creates a new span under this one, which started in the past
+func (s *Span) ChildAt(name string, start time.Time, attributes ...Attribute) *Span {
+	return s.tracer.newSpan(name, s, start, attributes)
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.attributes = append(s.attributes, attributes...)
+}
+
+// End ends the span now. A restic warning is not considered as an error.
+func (s *Span) End(err error) {
+	s.EndAt(time.Now(), err)
+}
+
+// EndAt ends the span at the specified time. A restic warning is not considered as an error.
+func (s *Span) EndAt(end time.Time, err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.end = end
+	if err != nil && monitor.StatusOf(err) == monitor.StatusFailed {
+		s.status = statusError
+		s.message = err.Error()
+	}
+}
@@ -0,0 +1,157 @@
+package statsd
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+const (
+	FormatStatsD    = "statsd"
+	FormatDogStatsD = "dogstatsd"
+
+	defaultPort = "8125"
+	// maxPacketSize keeps packets below the common MTU to avoid fragmentation
+	maxPacketSize = 1432
+)
+
+var (
+	invalidNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+	tagEscaper            = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+)
+
+// Progress is a monitor.Receiver sending a summary of each command as StatsD or DogStatsD gauges
+type Progress struct {
+	profile *config.Profile
+	group   string
+	timeout time.Duration
+}
+
+func NewProgress(profile *config.Profile, group string, timeout time.Duration) *Progress {
+	return &Progress{
+		profile: profile,
+		group:   group,
+		timeout: timeout,
+	}
+}
+
+func (p *Progress) Start(command string) {
+	// nothing to do here
+}
+
+func (p *Progress) Status(status monitor.Status) {
+	// we don't report any progress here
+}
+
+func (p *Progress) Summary(command string, summary monitor.Summary, stderr string, result error) {
+	if p.profile.StatsDAddress == "" {
+		return
+	}
+	packets := p.Packets(command, monitor.SummaryFields(command, summary, result))
+	if err := p.send(packets); err != nil {
+		// not important enough to throw an error here
+		clog.Warningf("sending statsd metrics to %q: %v", p.profile.StatsDAddress, err)
+	}
+}
+
+// Packets returns the gauges of all the fields, grouped in packets small enough to be sent over UDP.
+//
+// With the plain "statsd" format, the profile, group and command are part of the metric name as tags are not supported:
+// "<prefix>.<group>.<profile>.<command>.<field>". With "dogstatsd", all the tags are sent with each metric.
+func (p *Progress) Packets(command string, fields []monitor.Field) []string {
+	prefix := p.profile.StatsDPrefix
+	if prefix == "" {
+		prefix = constants.DefaultStatsDPrefix
+	}
+
+	suffix := ""
+	if strings.EqualFold(p.profile.StatsDFormat, FormatDogStatsD) {
+		suffix = dogStatsDTags(monitor.SummaryTags(p.profile.Name, p.group, command, p.profile.StatsDTags))
+	} else {
+		prefix = joinName(prefix, p.group, p.profile.Name, command)
+	}
+
+	packets := make([]string, 0, 1)
+	packet := &strings.Builder{}
+	for _, field := range fields {
+		metric := joinName(prefix, field.Name) + ":" + strconv.FormatFloat(field.Value, 'f', -1, 64) + "|g" + suffix
+		if packet.Len() > 0 && packet.Len()+1+len(metric) > maxPacketSize {
+			packets = append(packets, packet.String())
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(metric)
+	}
+	if packet.Len() > 0 {
+		packets = append(packets, packet.String())
+	}
+	return packets
+}
+
+func (p *Progress) send(packets []string) error {
+	network, address := "udp", p.profile.StatsDAddress
+	if scheme, rest, found := strings.Cut(address, "://"); found {
+		network, address = strings.ToLower(scheme), rest
+	}
+	switch network {
+	case "udp", "udp4", "udp6":
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, defaultPort)
+		}
+	case "unixgram":
+	default:
+		return fmt.Errorf("unsupported statsd network %q", network)
+	}
+
+	conn, err := net.DialTimeout(network, address, p.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for _, packet := range packets {
+		if _, err = conn.Write([]byte(packet)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinName(parts ...string) string {
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.Trim(invalidNameCharacters.ReplaceAllString(part, "_"), ".")
+		if part != "" {
+			names = append(names, part)
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+func dogStatsDTags(tags map[string]string) string {
+	list := make([]string, 0, len(tags))
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if key == "" || tags[key] == "" {
+			continue
+		}
+		list = append(list, tagEscaper.Replace(key)+":"+tagEscaper.Replace(tags[key]))
+	}
+	if len(list) == 0 {
+		return ""
+	}
+	return "|#" + strings.Join(list, ",")
+}
+
+// Verify interface
+var _ monitor.Receiver = &Progress{}
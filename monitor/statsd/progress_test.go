@@ -0,0 +1,81 @@
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketsStatsD(t *testing.T) {
+	profile := config.NewProfile(nil, "my profile")
+	profile.StatsDTags = map[string]string{"ignored": "in plain statsd"}
+	progress := NewProgress(profile, "nightly", time.Second)
+
+	packets := progress.Packets("backup", []monitor.Field{{Name: "status", Value: 2}, {Name: "duration_seconds", Value: 1.25}})
+	assert.Equal(t, []string{
+		"resticprofile.nightly.my_profile.backup.status:2|g\n" +
+			"resticprofile.nightly.my_profile.backup.duration_seconds:1.25|g",
+	}, packets)
+}
+
+func TestPacketsDogStatsD(t *testing.T) {
+	profile := config.NewProfile(nil, "home")
+	profile.StatsDFormat = FormatDogStatsD
+	profile.StatsDPrefix = "backup.restic"
+	profile.StatsDTags = map[string]string{"env": "prod", "bad": "a|b,c"}
+	progress := NewProgress(profile, "", time.Second)
+
+	packets := progress.Packets("check", []monitor.Field{{Name: "status", Value: 0}})
+	assert.Equal(t, []string{"backup.restic.status:0|g|#bad:a_b_c,command:check,env:prod,profile:home"}, packets)
+}
+
+func TestPacketsSplit(t *testing.T) {
+	progress := NewProgress(config.NewProfile(nil, "home"), "", time.Second)
+	fields := make([]monitor.Field, 200)
+	for i := range fields {
+		fields[i] = monitor.Field{Name: fmt.Sprintf("field_%d", i), Value: float64(i)}
+	}
+	packets := progress.Packets("backup", fields)
+	assert.Greater(t, len(packets), 1)
+	count := 0
+	for _, packet := range packets {
+		assert.LessOrEqual(t, len(packet), maxPacketSize)
+		count += len(strings.Split(packet, "\n"))
+	}
+	assert.Equal(t, len(fields), count)
+}
+
+func TestSummary(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	profile := config.NewProfile(nil, "home")
+	profile.StatsDAddress = "udp://" + listener.LocalAddr().String()
+	NewProgress(profile, "", time.Second).Summary("backup", monitor.Summary{FilesNew: 7}, "", nil)
+
+	buffer := make([]byte, maxPacketSize)
+	require.NoError(t, listener.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := listener.ReadFrom(buffer)
+	require.NoError(t, err)
+	lines := strings.Split(string(buffer[:n]), "\n")
+	assert.Contains(t, lines, "resticprofile.home.backup.status:2|g")
+	assert.Contains(t, lines, "resticprofile.home.backup.files_new:7|g")
+}
+
+func TestSummaryDefaultPort(t *testing.T) {
+	profile := config.NewProfile(nil, "home")
+	profile.StatsDAddress = "localhost"
+	progress := NewProgress(profile, "", time.Second)
+	assert.NoError(t, progress.send([]string{"test:1|g"}))
+
+	profile.StatsDAddress = "tcp://localhost:8125"
+	assert.ErrorContains(t, progress.send([]string{"test:1|g"}), "unsupported")
+}
@@ -25,6 +25,7 @@ type ownCommand struct {
 	hideInCompletion  bool                       // don't display the command in completion
 	noProfile         bool                       // true if the command doesn't need a profile name
 	experimental      bool                       // display a warning when using this command
+	schedulable       bool                       // true if the command can run on schedule from its profile section
 	flags             map[string]string          // own command flags should be simple enough to be handled manually for now
 }
 
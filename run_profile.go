@@ -3,15 +3,22 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/external"
+	"github.com/creativeprojects/resticprofile/monitor/influx"
+	"github.com/creativeprojects/resticprofile/monitor/mqtt"
+	"github.com/creativeprojects/resticprofile/monitor/otel"
 	"github.com/creativeprojects/resticprofile/monitor/prom"
+	"github.com/creativeprojects/resticprofile/monitor/statsd"
 	"github.com/creativeprojects/resticprofile/monitor/status"
 )
 
@@ -26,7 +33,7 @@ import (
 //
 // Returns:
 //   - error: An error if the profile or group run fails, or if the requested profile is not found.
-func startProfileOrGroup(ctx *Context, runProfile func(ctx *Context) error) error {
+func startProfileOrGroup(ctx *Context, runProfile func(ctx *Context) error) (err error) {
 	// Catch CTR-C keypress, or other signal sent by a service manager (systemd)
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGABRT)
@@ -43,8 +50,23 @@ func startProfileOrGroup(ctx *Context, runProfile func(ctx *Context) error) erro
 	notifyStart()
 	defer notifyStop()
 
+	// OpenTelemetry trace of the profile or group run
+	var rootSpan *otel.Span
+	if ctx.global != nil && ctx.global.OtelEndpoint != "" && !ctx.flags.dryRun {
+		tracer := otel.NewTracer()
+		rootSpan = tracer.Start(ctx.request.profile, otel.String("resticprofile.command", ctx.request.command))
+		defer func() {
+			rootSpan.End(err)
+			exportTrace(ctx.global, tracer)
+		}()
+	}
+
 	if ctx.config.HasProfile(ctx.request.profile) {
 		// Single profile run
+		if rootSpan != nil {
+			rootSpan.SetAttributes(otel.String("resticprofile.profile", ctx.request.profile))
+			ctx = ctx.WithTraceSpan(rootSpan)
+		}
 		err := runProfile(ctx)
 		if err != nil {
 			return err
@@ -59,6 +81,9 @@ func startProfileOrGroup(ctx *Context, runProfile func(ctx *Context) error) erro
 		if group != nil && len(group.Profiles) > 0 {
 			// profile name is the group name
 			groupName := ctx.request.profile
+			if rootSpan != nil {
+				rootSpan.SetAttributes(otel.String("resticprofile.group", groupName))
+			}
 
 			for i, profileName := range group.Profiles {
 				if goCtx.Err() != nil {
@@ -67,7 +92,13 @@ func startProfileOrGroup(ctx *Context, runProfile func(ctx *Context) error) erro
 				}
 				clog.Debugf("[%d/%d] starting profile '%s' from group '%s'", i+1, len(group.Profiles), profileName, groupName)
 				ctx = ctx.WithProfile(profileName).WithGroup(groupName)
+				if rootSpan != nil {
+					ctx = ctx.WithTraceSpan(rootSpan.Child(profileName, otel.String("resticprofile.profile", profileName)))
+				}
 				err = runProfile(ctx)
+				if ctx.traceSpan != nil {
+					ctx.traceSpan.End(err)
+				}
 				if err != nil {
 					if group.ContinueOnError.IsTrue() || (ctx.global.GroupContinueOnError && group.ContinueOnError.IsUndefined()) {
 						// keep going to the next profile
@@ -143,6 +174,7 @@ func runProfile(ctx *Context) error {
 		return err
 	}
 	ctx.profile = profile
+	setLogContext(profile.Name, ctx.request.group, ctx.command)
 
 	displayDeprecationNotices(profile)
 	ctx.config.DisplayConfigurationIssues()
@@ -185,6 +217,10 @@ func runProfile(ctx *Context) error {
 		// this is a scheduled profile
 		loadScheduledProfile(ctx)
 	}
+	if ctx.scheduleEntry != nil {
+		// environment of the schedule (from the profile or the group)
+		profile.AddEnvironment(ctx.scheduleEntry.CommandEnv)
+	}
 
 	wrapper := newResticWrapper(ctx)
 
@@ -201,6 +237,28 @@ func runProfile(ctx *Context) error {
 	if profile.PrometheusPush != "" || profile.PrometheusSaveToFile != "" {
 		wrapper.addProgress(prom.NewProgress(profile, prom.NewMetrics(profile.Name, ctx.request.group, version, ctx.global.ResticVersion, profile.PrometheusLabels)))
 	}
+	if profile.MqttBroker.HasValue() {
+		wrapper.addProgress(mqtt.NewProgress(profile, ctx.request.group, version))
+	}
+	if profile.InfluxDBWrite.HasValue() {
+		wrapper.addProgress(influx.NewProgress(profile, ctx.request.group, ctx.global.SenderTimeout))
+	}
+	if profile.StatsDAddress != "" {
+		wrapper.addProgress(statsd.NewProgress(profile, ctx.request.group, ctx.global.SenderTimeout))
+	}
+	if notifier := newSystemdNotifier(profile); notifier != nil {
+		wrapper.addProgress(notifier)
+	}
+	if ctx.traceSpan != nil {
+		wrapper.addProgress(otel.NewProgress(profile, ctx.request.group, ctx.traceSpan))
+	}
+	if len(profile.Receivers) > 0 {
+		env := append(wrapper.getEnvironment(false), wrapper.getProfileEnvironment()...)
+		for _, receiver := range profile.Receivers {
+			wrapper.addProgress(external.NewProgress(profile, ctx.request.group, receiver, wrapper.getShell(), env).
+				WithOutput(ctx.terminal.Stdout(), ctx.terminal.Stderr()))
+		}
+	}
 
 	err = wrapper.runProfile()
 	if err != nil {
@@ -209,6 +267,49 @@ func runProfile(ctx *Context) error {
 	return nil
 }
 
+// exportTrace sends the trace and metrics of the run to the OpenTelemetry collector
+func exportTrace(global *config.Global, tracer *otel.Tracer) {
+	resource := map[string]string{
+		"service.name":    "resticprofile",
+		"service.version": version,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		resource["host.name"] = hostname
+	}
+	maps.Copy(resource, global.OtelResource)
+
+	exporter := otel.NewExporter(global.OtelEndpoint, global.OtelHeaders, otel.Resource(resource), version, global.SenderTimeout)
+	if err := exporter.Export(context.Background(), tracer); err != nil {
+		// not important enough to throw an error here
+		clog.Warningf("sending trace %s to OpenTelemetry collector: %v", tracer.TraceID(), err)
+		return
+	}
+	clog.Debugf("trace %s sent to OpenTelemetry collector", tracer.TraceID())
+}
+
 func loadScheduledProfile(ctx *Context) {
-	ctx.schedule = ctx.profile.Schedules()[ctx.command]
+	scheduleName, _, _ := strings.Cut(ctx.request.schedule, "@")
+	ctx.schedule = ctx.profile.Schedules()[scheduleName]
+	loadScheduledCredentials(ctx)
+}
+
+// loadScheduledCredentials reads the password from the credential loaded by systemd, instead of the password file
+func loadScheduledCredentials(ctx *Context) {
+	if ctx.schedule == nil || !ctx.schedule.SystemdLoadCredential.IsTrue() {
+		return
+	}
+	dir := os.Getenv(constants.EnvCredentialsDir)
+	if dir == "" {
+		clog.Debugf("no systemd credential available for %q", ctx.request.schedule)
+		return
+	}
+	if file := filepath.Join(dir, constants.CredentialPassword); exists(file) {
+		clog.Debugf("using password from systemd credential %q", constants.CredentialPassword)
+		ctx.profile.PasswordFile = file
+	}
+}
+
+func exists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
 }
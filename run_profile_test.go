@@ -2,10 +2,18 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -99,3 +107,116 @@ func TestStartProfileOrGroup(t *testing.T) {
 		assert.Equal(t, 1, calls)
 	})
 }
+
+func TestStartGroupWithTrace(t *testing.T) {
+	configContent := `version = "2"
+        [profiles.profile1]
+         repository = "test-repo"
+        [profiles.profile2]
+         repository = "test-repo"
+        [groups.group1]
+         profiles = ["profile1", "profile2"]
+         continue-on-error = true
+    `
+	cfg, err := config.Load(bytes.NewBufferString(configContent), config.FormatTOML)
+	require.NoError(t, err)
+
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			body, _ := io.ReadAll(r.Body)
+			received <- body
+		}
+	}))
+	defer server.Close()
+
+	ctx := &Context{
+		config: cfg,
+		global: &config.Global{OtelEndpoint: server.URL, SenderTimeout: time.Second},
+		request: Request{
+			profile: "group1",
+			command: "backup",
+		},
+	}
+	spans := make([]string, 0)
+	err = startProfileOrGroup(ctx, func(ctx *Context) error {
+		require.NotNil(t, ctx.traceSpan)
+		spans = append(spans, ctx.request.profile)
+		if ctx.request.profile == "profile2" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"profile1", "profile2"}, spans)
+
+	var body []byte
+	select {
+	case body = <-received:
+	default:
+		require.Fail(t, "no trace received")
+	}
+	request := struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Status       struct {
+						Code int `json:"code"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}{}
+	require.NoError(t, json.Unmarshal(body, &request))
+
+	exported := request.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, exported, 3)
+	assert.Equal(t, "group1", exported[0].Name)
+	assert.Empty(t, exported[0].ParentSpanID)
+	assert.Equal(t, "profile1", exported[1].Name)
+	assert.Equal(t, exported[0].SpanID, exported[1].ParentSpanID)
+	assert.Equal(t, 0, exported[1].Status.Code)
+	assert.Equal(t, "profile2", exported[2].Name)
+	assert.Equal(t, exported[0].SpanID, exported[2].ParentSpanID)
+	assert.Equal(t, 2, exported[2].Status.Code)
+}
+
+func TestLoadScheduledCredentials(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString(`
+[default]
+password-file = "/etc/restic/password"
+
+[default.backup]
+schedule = "daily"
+schedule-systemd-load-credential = true
+
+[default.check]
+schedule = "daily"
+`), "toml")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	credential := filepath.Join(dir, constants.CredentialPassword)
+	require.NoError(t, os.WriteFile(credential, []byte("secret"), 0o600))
+	t.Setenv(constants.EnvCredentialsDir, dir)
+
+	load := func(schedule string) *config.Profile {
+		profile, err := cfg.GetProfile("default")
+		require.NoError(t, err)
+		ctx := &Context{profile: profile, request: Request{schedule: schedule}}
+		loadScheduledProfile(ctx)
+		require.NotNil(t, ctx.schedule)
+		return profile
+	}
+
+	passwordFile := load("check@default").PasswordFile
+	assert.NotEqual(t, credential, passwordFile)
+	assert.Equal(t, credential, load("backup@default").PasswordFile)
+
+	// not started by systemd
+	t.Setenv(constants.EnvCredentialsDir, "")
+	assert.Equal(t, passwordFile, load("backup@default").PasswordFile)
+}
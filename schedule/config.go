@@ -2,32 +2,44 @@ package schedule
 
 import (
 	"strings"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/constants"
 )
 
 // Config contains all information to schedule a profile command
 type Config struct {
-	ProfileName        string
-	CommandName        string // restic command
-	Schedules          []string
-	Permission         string
-	RunLevel           string
-	WorkingDirectory   string
-	Command            string // path to resticprofile executable
-	Arguments          CommandArguments
-	Environment        []string
-	JobDescription     string
-	TimerDescription   string
-	Priority           string // Priority is either "background" or "standard"
-	ConfigFile         string
-	Flags              map[string]string // flags added to the command line
-	AfterNetworkOnline bool
-	SystemdDropInFiles []string
-	Log                string
-	HideWindow         bool
-	StartWhenAvailable bool
-	removeOnly         bool
+	ProfileName           string
+	CommandName           string // restic command
+	Schedules             []string
+	Permission            string
+	RunLevel              string
+	WorkingDirectory      string
+	Command               string // path to resticprofile executable
+	Arguments             CommandArguments
+	Environment           []string
+	JobDescription        string
+	TimerDescription      string
+	Priority              string // Priority is either "background" or "standard"
+	ConfigFile            string
+	Flags                 map[string]string // flags added to the command line
+	AfterNetworkOnline    bool
+	SystemdDropInFiles    []string
+	Log                   string
+	HideWindow            bool
+	StartWhenAvailable    bool
+	RandomizedDelay       time.Duration     // maximum random delay before starting the job
+	FixedRandomDelay      bool              // same random delay for every run on this host
+	CatchUp               bool              // run a missed schedule as soon as possible (crond only)
+	SystemdHardening      bool              // sandbox the service (systemd only)
+	SystemdReadWritePaths []string          // paths staying writable in the sandbox
+	SystemdMemoryMax      string            // MemoryMax resource control
+	SystemdCPUQuota       string            // CPUQuota resource control
+	SystemdIOWeight       int               // IOWeight resource control
+	SystemdCredentials    map[string]string // LoadCredential: ID to file path
+	SystemdWatchdog       time.Duration     // WatchdogSec: maximum time without progress
+	SystemdOnFailure      bool              // notify the failure with a companion unit
+	removeOnly            bool
 }
 
 // NewRemoveOnlyConfig creates a job config that may be used to call Job.Remove() on a scheduled job
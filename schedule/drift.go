@@ -0,0 +1,159 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/calendar"
+)
+
+// DriftState is the difference between a job declared in the configuration and the job installed in the scheduler
+type DriftState string
+
+const (
+	DriftMissing  DriftState = "missing"  // declared in the configuration but not installed
+	DriftOrphaned DriftState = "orphaned" // installed but no longer declared in the configuration
+	DriftChanged  DriftState = "changed"  // installed with different settings
+)
+
+// Drift is a job installed in the scheduler which doesn't match the configuration
+type Drift struct {
+	State       DriftState
+	ProfileName string
+	CommandName string
+	Declared    *Config  // nil when orphaned
+	Installed   *Config  // nil when missing
+	Changes     []string // description of the changed settings
+}
+
+// CompareJobs returns the differences between the jobs declared in the configuration and the jobs installed in the scheduler.
+// Settings not reported by the scheduler (empty in the installed job) are not compared.
+func CompareJobs(declared []*Config, installed []Config) []Drift {
+	drifts := make([]Drift, 0)
+	matched := make([]bool, len(installed))
+	for _, job := range declared {
+		index := findInstalledJob(installed, job)
+		if index < 0 {
+			drifts = append(drifts, Drift{State: DriftMissing, ProfileName: job.ProfileName, CommandName: job.CommandName, Declared: job})
+			continue
+		}
+		matched[index] = true
+		if changes := compareJob(job, &installed[index]); len(changes) > 0 {
+			drifts = append(drifts, Drift{State: DriftChanged, ProfileName: job.ProfileName, CommandName: job.CommandName, Declared: job, Installed: &installed[index], Changes: changes})
+		}
+	}
+	for index := range installed {
+		if !matched[index] {
+			job := &installed[index]
+			drifts = append(drifts, Drift{State: DriftOrphaned, ProfileName: job.ProfileName, CommandName: job.CommandName, Installed: job})
+		}
+	}
+	return drifts
+}
+
+// CompareJobFiles adds to the drifts the installed jobs with files different from the files the scheduler would write
+// for the declared jobs: it catches the settings not reported by the scheduler (arguments, environment, options of the unit, etc.).
+// The files are not compared when the handler cannot preview them.
+func CompareJobFiles(handler Handler, declared []*Config, installed []Config, drifts []Drift) []Drift {
+	for _, job := range declared {
+		index := findInstalledJob(installed, job)
+		if index < 0 {
+			continue // missing
+		}
+		files, err := NewJob(handler, job).Preview(nil)
+		if errors.Is(err, ErrPreviewNotSupported) {
+			return drifts
+		}
+		if err != nil {
+			clog.Debugf("cannot compare the files of job %s/%s: %s", job.ProfileName, job.CommandName, err)
+			continue
+		}
+		var changes []string
+		for _, file := range files {
+			if !sameLines(file.Current, file.Content) {
+				changes = append(changes, fmt.Sprintf("file %q", file.Path))
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		if driftIndex := slices.IndexFunc(drifts, func(drift Drift) bool { return drift.Declared == job }); driftIndex >= 0 {
+			drifts[driftIndex].Changes = append(drifts[driftIndex].Changes, changes...)
+		} else {
+			drifts = append(drifts, Drift{State: DriftChanged, ProfileName: job.ProfileName, CommandName: job.CommandName, Declared: job, Installed: &installed[index], Changes: changes})
+		}
+	}
+	return drifts
+}
+
+// sameLines returns true when both contents have the same lines, in any order
+// (a scheduler like crond may write the lines of a job at another place)
+func sameLines(a, b string) bool {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	slices.Sort(linesA)
+	slices.Sort(linesB)
+	return slices.Equal(linesA, linesB)
+}
+
+// findInstalledJob returns the index of the installed job of the declared job, or -1 when not installed
+func findInstalledJob(installed []Config, job *Config) int {
+	return slices.IndexFunc(installed, func(cfg Config) bool {
+		return strings.EqualFold(cfg.ProfileName, job.ProfileName) && cfg.CommandName == job.CommandName
+	})
+}
+
+func compareJob(declared, installed *Config) []string {
+	var changes []string
+	if len(installed.Schedules) > 0 {
+		from, to := normalizeSchedules(installed.Schedules), normalizeSchedules(declared.Schedules)
+		// schedulers without timezone support install the schedules converted to local time
+		if !slices.Equal(from, to) && !slices.Equal(from, normalizeLocalSchedules(declared.Schedules)) {
+			changes = append(changes, fmt.Sprintf("schedules %q -> %q", from, to))
+		}
+	}
+	if installed.Command != "" && installed.Command != declared.Command {
+		changes = append(changes, fmt.Sprintf("executable %q -> %q", installed.Command, declared.Command))
+	}
+	if installed.Priority != "" && !strings.EqualFold(installed.Priority, declared.GetPriority()) {
+		changes = append(changes, fmt.Sprintf("priority %q -> %q", installed.Priority, declared.GetPriority()))
+	}
+	return changes
+}
+
+// normalizeSchedules returns the sorted normalized form of the schedules
+func normalizeSchedules(schedules []string) []string {
+	normalized := make([]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		event := calendar.NewEvent()
+		if err := event.Parse(schedule); err == nil {
+			schedule = event.String()
+		}
+		if !slices.Contains(normalized, schedule) {
+			normalized = append(normalized, schedule)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
+// normalizeLocalSchedules returns the sorted normalized form of the schedules converted to local time
+func normalizeLocalSchedules(schedules []string) []string {
+	local := make([]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		event := calendar.NewEvent()
+		if err := event.Parse(schedule); err == nil && event.Location != nil {
+			if events, err := event.ConvertTo(time.Local, event.Next(time.Now())); err == nil {
+				for _, converted := range events {
+					local = append(local, converted.String())
+				}
+				continue
+			}
+		}
+		local = append(local, schedule)
+	}
+	return normalizeSchedules(local)
+}
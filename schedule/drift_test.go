@@ -0,0 +1,144 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareJobs(t *testing.T) {
+	declared := []*Config{
+		{ProfileName: "home", CommandName: "backup", Schedules: []string{"*:00,30"}, Command: "/usr/bin/resticprofile"},
+		{ProfileName: "home", CommandName: "check", Schedules: []string{"daily"}, Command: "/usr/bin/resticprofile"},
+		{ProfileName: "home", CommandName: "prune", Schedules: []string{"weekly"}, Command: "/usr/bin/resticprofile", Priority: "background"},
+		{ProfileName: "Docs", CommandName: "backup", Schedules: []string{"daily"}, Command: "/usr/bin/resticprofile"},
+	}
+	installed := []Config{
+		// same schedule in another form
+		{ProfileName: "home", CommandName: "backup", Schedules: []string{"*-*-* *:00,30:00"}, Command: "/usr/bin/resticprofile"},
+		{ProfileName: "home", CommandName: "prune", Schedules: []string{"monthly"}, Command: "/usr/local/bin/resticprofile", Priority: "standard"},
+		{ProfileName: "home", CommandName: "forget", Schedules: []string{"daily"}},
+		// the scheduler doesn't report the schedules
+		{ProfileName: "docs", CommandName: "backup", Command: "/usr/bin/resticprofile"},
+	}
+
+	drifts := CompareJobs(declared, installed)
+	require.Len(t, drifts, 3)
+
+	assert.Equal(t, DriftMissing, drifts[0].State)
+	assert.Equal(t, "check", drifts[0].CommandName)
+	assert.Same(t, declared[1], drifts[0].Declared)
+	assert.Nil(t, drifts[0].Installed)
+
+	assert.Equal(t, DriftChanged, drifts[1].State)
+	assert.Equal(t, "prune", drifts[1].CommandName)
+	assert.Equal(t, []string{
+		`schedules ["*-*-01 00:00:00"] -> ["Mon *-*-* 00:00:00"]`,
+		`executable "/usr/local/bin/resticprofile" -> "/usr/bin/resticprofile"`,
+		`priority "standard" -> "background"`,
+	}, drifts[1].Changes)
+
+	assert.Equal(t, DriftOrphaned, drifts[2].State)
+	assert.Equal(t, "forget", drifts[2].CommandName)
+	assert.Nil(t, drifts[2].Declared)
+	assert.Same(t, &installed[2], drifts[2].Installed)
+}
+
+func TestCompareJobsInSync(t *testing.T) {
+	declared := []*Config{{ProfileName: "home", CommandName: "backup", Schedules: []string{"daily", "12:00", "daily"}}}
+	installed := []Config{{ProfileName: "home", CommandName: "backup", Schedules: []string{"*-*-* 12:00:00", "*-*-* 00:00:00"}}}
+	assert.Empty(t, CompareJobs(declared, installed))
+	assert.Empty(t, CompareJobs(nil, nil))
+}
+
+func TestCompareJobsWithTimezone(t *testing.T) {
+	local := calendar.NewEvent()
+	require.NoError(t, local.Parse("08:00 UTC"))
+	events, err := local.ConvertTo(time.Local, local.Next(time.Now()))
+	require.NoError(t, err)
+	localSchedules := make([]string, 0, len(events))
+	for _, event := range events {
+		localSchedules = append(localSchedules, event.String())
+	}
+
+	declared := []*Config{{ProfileName: "home", CommandName: "backup", Schedules: []string{"08:00 UTC"}}}
+	// installed natively (systemd)
+	assert.Empty(t, CompareJobs(declared, []Config{{ProfileName: "home", CommandName: "backup", Schedules: []string{"*-*-* 08:00:00 UTC"}}}))
+	// installed in local time (crond)
+	assert.Empty(t, CompareJobs(declared, []Config{{ProfileName: "home", CommandName: "backup", Schedules: localSchedules}}))
+	// different timezone
+	assert.Len(t, CompareJobs(declared, []Config{{ProfileName: "home", CommandName: "backup", Schedules: []string{"*-*-* 08:00:00 Asia/Tokyo"}}}), 1)
+}
+
+// previewHandler renders the files of the jobs from a map, by command name
+type previewHandler struct {
+	Handler
+	files map[string][]FileChange
+}
+
+func (h previewHandler) DetectSchedulePermission(permission Permission) (Permission, bool) {
+	return permission, true
+}
+
+func (h previewHandler) ParseSchedules(_ []string) ([]*calendar.Event, error) {
+	return nil, nil
+}
+
+func (h previewHandler) PreviewJob(job *Config, _ []*calendar.Event, _ Permission, _ map[string]string) ([]FileChange, error) {
+	return h.files[job.CommandName], nil
+}
+
+func TestCompareJobFiles(t *testing.T) {
+	declared := []*Config{
+		{ProfileName: "home", CommandName: "backup", Schedules: []string{"daily"}},
+		{ProfileName: "home", CommandName: "check", Schedules: []string{"weekly"}},
+		{ProfileName: "home", CommandName: "prune", Schedules: []string{"monthly"}},
+		{ProfileName: "home", CommandName: "forget", Schedules: []string{"daily"}},
+	}
+	installed := []Config{
+		{ProfileName: "home", CommandName: "backup", Schedules: []string{"daily"}},
+		{ProfileName: "home", CommandName: "check", Schedules: []string{"daily"}},
+		{ProfileName: "home", CommandName: "prune", Schedules: []string{"monthly"}},
+	}
+	handler := previewHandler{files: map[string][]FileChange{
+		// same lines in another order
+		"backup": {{Path: "crontab", Current: "first\nsecond\n", Content: "second\nfirst\n"}},
+		"check": {
+			{Path: "check.service", Current: "ExecStart=check", Content: "ExecStart=check --flag"},
+			{Path: "check.timer", Current: "OnCalendar=daily", Content: "OnCalendar=weekly"},
+		},
+		"prune":  {{Path: "prune.service", Current: "Environment=\"A=1\"", Content: "Environment=\"A=2\""}},
+		"forget": {{Path: "forget.service", Content: "ExecStart=forget"}},
+	}}
+
+	drifts := CompareJobFiles(handler, declared, installed, CompareJobs(declared, installed))
+	require.Len(t, drifts, 3)
+
+	assert.Equal(t, DriftChanged, drifts[0].State)
+	assert.Equal(t, "check", drifts[0].CommandName)
+	assert.Equal(t, []string{
+		`schedules ["*-*-* 00:00:00"] -> ["Mon *-*-* 00:00:00"]`,
+		`file "check.service"`,
+		`file "check.timer"`,
+	}, drifts[0].Changes)
+
+	assert.Equal(t, DriftMissing, drifts[1].State)
+	assert.Equal(t, "forget", drifts[1].CommandName)
+
+	assert.Equal(t, DriftChanged, drifts[2].State)
+	assert.Equal(t, "prune", drifts[2].CommandName)
+	assert.Same(t, declared[2], drifts[2].Declared)
+	assert.Same(t, &installed[2], drifts[2].Installed)
+	assert.Equal(t, []string{`file "prune.service"`}, drifts[2].Changes)
+}
+
+func TestCompareJobFilesNotSupported(t *testing.T) {
+	declared := []*Config{{ProfileName: "home", CommandName: "backup", Schedules: []string{"daily"}}}
+	installed := []Config{{ProfileName: "home", CommandName: "backup"}}
+	// the handler cannot render the files of a job
+	handler := struct{ Handler }{}
+	assert.Empty(t, CompareJobFiles(handler, declared, installed, nil))
+}
@@ -6,4 +6,5 @@ import "errors"
 var (
 	ErrScheduledJobNotFound   = errors.New("scheduled job not found")
 	ErrScheduledJobNotRunning = errors.New("scheduled job is not running")
+	ErrPreviewNotSupported    = errors.New("dry-run is not supported by this scheduler")
 )
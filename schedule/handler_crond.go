@@ -20,6 +20,14 @@ import (
 
 var crontabBinary = "crontab"
 
+// catchUpFlag is the flag of the crontab entry starting the missed runs of a job
+const catchUpFlag = "--catch-up"
+
+var (
+	_ Handler   = &HandlerCrond{}
+	_ Previewer = &HandlerCrond{}
+)
+
 // HandlerCrond is a handler for crond scheduling
 type HandlerCrond struct {
 	config SchedulerCrond
@@ -57,7 +65,7 @@ func (h *HandlerCrond) Close() {
 }
 
 func (h *HandlerCrond) ParseSchedules(schedules []string) ([]*calendar.Event, error) {
-	return parseSchedules(schedules)
+	return parseLocalSchedules(schedules)
 }
 
 func (h *HandlerCrond) DisplaySchedules(profile, command string, schedules []string) error {
@@ -76,7 +84,37 @@ func (h *HandlerCrond) DisplayStatus(profileName string) error {
 
 // CreateJob is creating the crontab
 func (h *HandlerCrond) CreateJob(job *Config, schedules []*calendar.Event, permission Permission) error {
-	entries := make([]crond.Entry, len(schedules))
+	err := h.newCrontab(job, schedules).Rewrite()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PreviewJob returns the crontab with the entries of the job, without saving it.
+// The entries are added to the pending crontab when the entries of other jobs were previewed before.
+func (h *HandlerCrond) PreviewJob(job *Config, schedules []*calendar.Event, permission Permission, pending map[string]string) ([]FileChange, error) {
+	path := h.config.CrontabFile
+	if path == "" {
+		path = "crontab"
+	}
+	crontab := h.newCrontab(job, schedules)
+	current, found := pending[path]
+	var content string
+	var err error
+	if found {
+		content, err = crontab.RenderFrom(current)
+	} else {
+		current, content, err = crontab.Render()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []FileChange{{Path: path, Current: current, Content: content}}, nil
+}
+
+func (h *HandlerCrond) newCrontab(job *Config, schedules []*calendar.Event) *crond.Crontab {
+	entries := make([]crond.Entry, len(schedules), len(schedules)+1)
 	for i, event := range schedules {
 		entries[i] = crond.NewEntry(
 			event,
@@ -86,6 +124,20 @@ func (h *HandlerCrond) CreateJob(job *Config, schedules []*calendar.Event, permi
 			job.Command+" "+job.Arguments.String(),
 			job.WorkingDirectory,
 		)
+	}
+	if job.CatchUp {
+		// hourly entry starting the job only when a scheduled run was missed
+		arguments := NewCommandArguments(append([]string{catchUpFlag}, job.Arguments.RawArgs()...))
+		entries = append(entries, crond.NewEntry(
+			catchUpEvent(),
+			job.ConfigFile,
+			job.ProfileName,
+			job.CommandName,
+			job.Command+" "+arguments.String(),
+			job.WorkingDirectory,
+		))
+	}
+	for i := range entries {
 
 		switch h.config.Username {
 		case "", "-":
@@ -95,15 +147,10 @@ func (h *HandlerCrond) CreateJob(job *Config, schedules []*calendar.Event, permi
 			entries[i] = entries[i].WithUser(h.config.Username)
 		}
 	}
-	crontab := crond.NewCrontab(entries).
+	return crond.NewCrontab(entries).
 		SetFile(h.config.CrontabFile).
 		SetBinary(h.config.CrontabBinary).
 		SetFs(h.fs)
-	err := crontab.Rewrite()
-	if err != nil {
-		return err
-	}
-	return nil
 }
 
 func (h *HandlerCrond) RemoveJob(job *Config, permission Permission) error {
@@ -155,24 +202,29 @@ func (h *HandlerCrond) Scheduled(profileName string) ([]Config, error) {
 			permission = constants.SchedulePermissionSystem
 		}
 
-		if index := slices.IndexFunc(configs, func(cfg Config) bool {
+		args := shell.SplitArguments(entry.CommandLine())
+		index := slices.IndexFunc(configs, func(cfg Config) bool {
 			return cfg.ProfileName == profileName && cfg.CommandName == commandName && cfg.ConfigFile == configFile
-		}); index >= 0 {
-			configs[index].Schedules = append(configs[index].Schedules, entry.Event().String())
-		} else {
-			commandLine := entry.CommandLine()
-			args := shell.SplitArguments(commandLine)
+		})
+		if index < 0 {
 			configs = append(configs, Config{
 				ProfileName:      profileName,
 				CommandName:      commandName,
 				ConfigFile:       configFile,
-				Schedules:        []string{entry.Event().String()},
+				Schedules:        []string{},
 				Command:          args[0],
-				Arguments:        NewCommandArguments(args[1:]),
+				Arguments:        NewCommandArguments(args[1:]).Trim([]string{catchUpFlag}),
 				WorkingDirectory: entry.WorkDir(),
 				Permission:       permission,
 			})
+			index = len(configs) - 1
+		}
+		if slices.Contains(args, catchUpFlag) {
+			// the catch-up entry is not one of the schedules of the job
+			configs[index].CatchUp = true
+			continue
 		}
+		configs[index].Schedules = append(configs[index].Schedules, entry.Event().String())
 	}
 	return configs, configsErr
 }
@@ -214,6 +266,15 @@ func (h *HandlerCrond) CheckPermission(user user.User, p Permission) (bool, erro
 	}
 }
 
+// catchUpEvent returns the hourly event of the crontab entry catching up missed runs.
+// It doesn't start at the top of the hour, where most of the schedules are.
+func catchUpEvent() *calendar.Event {
+	return calendar.NewEvent(func(e *calendar.Event) {
+		e.Minute.MustAddValue(30)
+		e.Second.MustAddValue(0)
+	})
+}
+
 // init registers HandlerCrond
 func init() {
 	AddHandlerProvider(func(config SchedulerConfig, fallback bool) Handler {
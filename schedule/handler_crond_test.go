@@ -92,6 +92,54 @@ func TestCreateReadDeleteCrondSchedules(t *testing.T) {
 	assert.Empty(t, scheduled)
 }
 
+func TestPreviewCrondSchedule(t *testing.T) {
+	hourly := calendar.NewEvent(func(e *calendar.Event) {
+		e.Minute.MustAddValue(0)
+		e.Second.MustAddValue(0)
+	})
+	job := Config{
+		ProfileName:      "self",
+		CommandName:      "check",
+		Command:          "/bin/resticprofile",
+		Arguments:        NewCommandArguments([]string{"--no-ansi", "--config", "examples/dev.yaml", "run-schedule", "check@self"}),
+		WorkingDirectory: "/resticprofile",
+		Schedules:        []string{"*-*-* *:00:00"},
+		ConfigFile:       "examples/dev.yaml",
+		Permission:       "user",
+	}
+
+	tempFile := filepath.Join(t.TempDir(), "crontab")
+	handler := NewHandler(SchedulerCrond{
+		CrontabFile: tempFile,
+		Username:    "user",
+	}).(*HandlerCrond)
+	handler.fs = afero.NewMemMapFs()
+
+	changes, err := handler.PreviewJob(&job, []*calendar.Event{hourly}, PermissionUserBackground, nil)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, tempFile, changes[0].Path)
+	assert.Empty(t, changes[0].Current)
+	assert.Contains(t, changes[0].Content, "00 * * * *\tuser\tcd /resticprofile && /bin/resticprofile --no-ansi --config examples/dev.yaml run-schedule check@self\n")
+
+	// the entries of another job are added to the pending crontab
+	other := job
+	other.CommandName = "backup"
+	other.Arguments = NewCommandArguments([]string{"--no-ansi", "--config", "examples/dev.yaml", "run-schedule", "backup@self"})
+	pending := map[string]string{tempFile: changes[0].Content}
+	changes, err = handler.PreviewJob(&other, []*calendar.Event{hourly}, PermissionUserBackground, pending)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, pending[tempFile], changes[0].Current)
+	assert.Contains(t, changes[0].Content, "run-schedule check@self\n")
+	assert.Contains(t, changes[0].Content, "run-schedule backup@self\n")
+
+	// nothing was saved
+	exists, err := afero.Exists(handler.fs, tempFile)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
 func TestDetectPermissionCrond(t *testing.T) {
 	t.Parallel()
 
@@ -294,3 +342,74 @@ func TestNeedsUserInCronEntry(t *testing.T) {
 		})
 	}
 }
+
+func TestPreviewCrondScheduleWithRepetition(t *testing.T) {
+	job := Config{
+		ProfileName:      "self",
+		CommandName:      "backup",
+		Command:          "/bin/resticprofile",
+		Arguments:        NewCommandArguments([]string{"run-schedule", "backup@self"}),
+		WorkingDirectory: "/resticprofile",
+		Schedules:        []string{"*:0/15", "*-*-1/10 03:00"},
+		Permission:       "user",
+	}
+
+	handler := NewHandler(SchedulerCrond{
+		CrontabFile: filepath.Join(t.TempDir(), "crontab"),
+		Username:    "user",
+	}).(*HandlerCrond)
+	handler.fs = afero.NewMemMapFs()
+
+	schedules, err := handler.ParseSchedules(job.Schedules)
+	require.NoError(t, err)
+	changes, err := handler.PreviewJob(&job, schedules, PermissionUserBackground, nil)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Contains(t, changes[0].Content, "00,15,30,45 * * * *\tuser\t")
+	assert.Contains(t, changes[0].Content, "00 03 01,11,21,31 * *\tuser\t")
+
+	_, err = handler.ParseSchedules([]string{"*-*~01"})
+	assert.Error(t, err)
+}
+
+func TestCreateReadDeleteCrondScheduleWithCatchUp(t *testing.T) {
+	job := Config{
+		ProfileName:      "self",
+		CommandName:      "backup",
+		Command:          "/bin/resticprofile",
+		Arguments:        NewCommandArguments([]string{"--no-ansi", "--config", "examples/dev.yaml", "run-schedule", "backup@self"}),
+		WorkingDirectory: "/resticprofile",
+		Schedules:        []string{"*-*-* 02:00:00"},
+		ConfigFile:       "examples/dev.yaml",
+		Permission:       "user",
+		CatchUp:          true,
+	}
+
+	tempFile := filepath.Join(t.TempDir(), "crontab")
+	handler := NewHandler(SchedulerCrond{
+		CrontabFile: tempFile,
+		Username:    "user",
+	}).(*HandlerCrond)
+	handler.fs = afero.NewMemMapFs()
+
+	schedules, err := handler.ParseSchedules(job.Schedules)
+	require.NoError(t, err)
+	changes, err := handler.PreviewJob(&job, schedules, PermissionUserBackground, nil)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Contains(t, changes[0].Content, "00 02 * * *\tuser\tcd /resticprofile && /bin/resticprofile --no-ansi --config examples/dev.yaml run-schedule backup@self\n")
+	assert.Contains(t, changes[0].Content, "30 * * * *\tuser\tcd /resticprofile && /bin/resticprofile --catch-up --no-ansi --config examples/dev.yaml run-schedule backup@self\n")
+
+	require.NoError(t, handler.CreateJob(&job, schedules, PermissionUserBackground))
+
+	// the catch-up entry is not listed as a schedule
+	scheduled, err := handler.Scheduled("")
+	require.NoError(t, err)
+	assert.Equal(t, []Config{job}, scheduled)
+
+	// both entries are removed
+	require.NoError(t, handler.RemoveJob(&job, PermissionUserBackground))
+	scheduled, err = handler.Scheduled("")
+	require.NoError(t, err)
+	assert.Empty(t, scheduled)
+}
@@ -61,7 +61,7 @@ func (h *HandlerLaunchd) Close() {
 }
 
 func (h *HandlerLaunchd) ParseSchedules(schedules []string) ([]*calendar.Event, error) {
-	return parseSchedules(schedules)
+	return parseLocalSchedules(schedules)
 }
 
 func (h *HandlerLaunchd) DisplaySchedules(profile, command string, schedules []string) error {
@@ -37,6 +37,9 @@ const (
 	flagNow          = "--now"
 	unitNotFound     = "not-found"
 
+	runScheduleCommand   = "run-schedule"
+	notifyFailureCommand = "notify-failure"
+
 	// https://www.freedesktop.org/software/systemd/man/systemctl.html#Exit%20status
 	codeStatusNotRunning   = 3
 	codeStatusUnitNotFound = 4
@@ -84,14 +87,19 @@ func (h *HandlerSystemd) Close() {
 	}
 }
 
-// ParseSchedules always returns nil on systemd
+// ParseSchedules always returns nil on systemd: it only checks the cron expressions can be converted
 func (h *HandlerSystemd) ParseSchedules(schedules []string) ([]*calendar.Event, error) {
-	return nil, nil
+	_, err := convertCronSchedules(schedules)
+	return nil, err
 }
 
 // DisplaySchedules displays the schedules through the systemd-analyze command
 func (h *HandlerSystemd) DisplaySchedules(profile, command string, schedules []string) error {
-	return displaySystemdSchedules(term.Get(), profile, command, schedules)
+	converted, err := convertCronSchedules(schedules)
+	if err != nil {
+		return err
+	}
+	return displaySystemdSchedules(term.Get(), profile, command, converted)
 }
 
 // DisplayStatus displays the status of all the timers installed on that profile. Example:
@@ -155,26 +163,7 @@ func (h *HandlerSystemd) CreateJob(job *Config, schedules []*calendar.Event, per
 	}
 
 	unit := systemd.NewUnit(u)
-	err := unit.Generate(systemd.Config{
-		CommandLine:          job.Command + " --no-prio " + job.Arguments.String(),
-		Environment:          job.Environment,
-		WorkingDirectory:     job.WorkingDirectory,
-		Title:                job.ProfileName,
-		SubTitle:             job.CommandName,
-		JobDescription:       job.JobDescription,
-		TimerDescription:     job.TimerDescription,
-		Schedules:            job.Schedules,
-		UnitType:             unitType,
-		Priority:             job.GetPriority(),
-		UnitFile:             h.config.UnitTemplate,
-		TimerFile:            h.config.TimerTemplate,
-		AfterNetworkOnline:   job.AfterNetworkOnline,
-		DropInFiles:          job.SystemdDropInFiles,
-		Nice:                 h.config.Nice,
-		IOSchedulingClass:    h.config.IONiceClass,
-		IOSchedulingPriority: h.config.IONiceLevel,
-		User:                 user,
-	})
+	err := unit.Generate(h.getSystemdConfig(job, unitType, user))
 	if err != nil {
 		return err
 	}
@@ -205,6 +194,96 @@ func (h *HandlerSystemd) CreateJob(job *Config, schedules []*calendar.Event, per
 	return nil
 }
 
+// PreviewJob returns the unit, timer and drop-in files of the job, without installing them
+func (h *HandlerSystemd) PreviewJob(job *Config, schedules []*calendar.Event, permission Permission, _ map[string]string) ([]FileChange, error) {
+	u := user.Current()
+	unitType, user := permissionToSystemd(u, permission)
+	if unitType == systemd.UserUnit && job.AfterNetworkOnline {
+		return nil, fmt.Errorf("after-network-online is not available for \"user_logged_on\" permission schedules")
+	}
+
+	files, err := systemd.NewUnit(u).Render(h.getSystemdConfig(job, unitType, user))
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]FileChange, 0, len(files))
+	for _, file := range files {
+		current, err := os.ReadFile(file.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		changes = append(changes, FileChange{Path: file.Path, Current: string(current), Content: string(file.Content)})
+	}
+	return changes, nil
+}
+
+func (h *HandlerSystemd) getSystemdConfig(job *Config, unitType systemd.UnitType, user string) systemd.Config {
+	return systemd.Config{
+		CommandLine:          job.Command + systemdFlags(job, h.timerHasRandomizedDelay()) + job.Arguments.String(),
+		Environment:          job.Environment,
+		WorkingDirectory:     job.WorkingDirectory,
+		Title:                job.ProfileName,
+		SubTitle:             job.CommandName,
+		JobDescription:       job.JobDescription,
+		TimerDescription:     job.TimerDescription,
+		Schedules:            systemdSchedules(job.Schedules),
+		UnitType:             unitType,
+		Priority:             job.GetPriority(),
+		UnitFile:             h.config.UnitTemplate,
+		TimerFile:            h.config.TimerTemplate,
+		AfterNetworkOnline:   job.AfterNetworkOnline,
+		DropInFiles:          job.SystemdDropInFiles,
+		Nice:                 h.config.Nice,
+		IOSchedulingClass:    h.config.IONiceClass,
+		IOSchedulingPriority: h.config.IONiceLevel,
+		User:                 user,
+		RandomizedDelay:      job.RandomizedDelay,
+		FixedRandomDelay:     job.FixedRandomDelay,
+		Hardening:            job.SystemdHardening,
+		ReadWritePaths:       job.SystemdReadWritePaths,
+		MemoryMax:            job.SystemdMemoryMax,
+		CPUQuota:             job.SystemdCPUQuota,
+		IOWeight:             job.SystemdIOWeight,
+		Credentials:          job.SystemdCredentials,
+		Watchdog:             job.SystemdWatchdog,
+		OnFailureCommandLine: failureCommandLine(job),
+	}
+}
+
+// failureCommandLine returns the command line notifying the failure of the job,
+// or an empty string when the job doesn't notify its failures
+func failureCommandLine(job *Config) string {
+	if !job.SystemdOnFailure {
+		return ""
+	}
+	args := slices.Clone(job.Arguments.RawArgs())
+	index := slices.Index(args, runScheduleCommand)
+	if index < 0 {
+		return ""
+	}
+	args[index] = notifyFailureCommand
+	return job.Command + " " + NewCommandArguments(args).String()
+}
+
+// systemdFlags returns the resticprofile flags telling the job what systemd already does:
+// setting the priority, and delaying the start of the job (when the timer has a randomized delay)
+func systemdFlags(job *Config, timerDelay bool) string {
+	if job.RandomizedDelay > 0 && timerDelay {
+		return " --no-prio --no-random-delay "
+	}
+	return " --no-prio "
+}
+
+// timerHasRandomizedDelay returns true when the timer template sets "RandomizedDelaySec" (the default template does).
+// Otherwise the start of the job is delayed by resticprofile.
+func (h *HandlerSystemd) timerHasRandomizedDelay() bool {
+	if h.config.TimerTemplate == "" {
+		return true
+	}
+	content, err := os.ReadFile(h.config.TimerTemplate)
+	return err == nil && strings.Contains(string(content), "RandomizedDelaySec")
+}
+
 // RemoveJob is disabling the systemd unit and deleting the timer and service files
 func (h *HandlerSystemd) RemoveJob(job *Config, permission Permission) error {
 	u := user.Current()
@@ -274,6 +353,7 @@ func (h *HandlerSystemd) removeJobFiles(job *Config, unitType systemd.UnitType,
 	obsoletes := []string{
 		path.Join(systemdPath, timerFile),
 		path.Join(systemdPath, serviceFile),
+		path.Join(systemdPath, systemd.GetFailureServiceFile(job.ProfileName, job.CommandName)),
 		path.Join(systemdPath, timerDropInDir),
 		path.Join(systemdPath, dropInDir),
 	}
@@ -407,7 +487,8 @@ func (h *HandlerSystemd) addReloadHook(unitType systemd.UnitType) {
 }
 
 var (
-	_ Handler = &HandlerSystemd{}
+	_ Handler   = &HandlerSystemd{}
+	_ Previewer = &HandlerSystemd{}
 )
 
 // permissionToSystemd translates the internal Permission type into the corresponding
@@ -621,7 +702,7 @@ func toScheduleConfig(systemdConfig systemd.Config) Config {
 		CommandName:      systemdConfig.SubTitle,
 		WorkingDirectory: systemdConfig.WorkingDirectory,
 		Command:          command,
-		Arguments:        args.Trim([]string{"--no-prio"}),
+		Arguments:        args.Trim([]string{"--no-prio", "--no-random-delay"}),
 		JobDescription:   systemdConfig.JobDescription,
 		Environment:      systemdConfig.Environment,
 		Permission:       systemdConfigPermission(systemdConfig),
@@ -652,6 +733,34 @@ func systemctlCommand(args ...string) (*exec.Cmd, error) {
 	return exec.CommandContext(context.TODO(), binary, args...), nil
 }
 
+// convertCronSchedules converts the cron expressions into the systemd calendar format
+func convertCronSchedules(schedules []string) ([]string, error) {
+	converted := make([]string, len(schedules))
+	for index, schedule := range schedules {
+		converted[index] = schedule
+		if !calendar.IsCron(schedule) {
+			continue
+		}
+		event := calendar.NewEvent()
+		err := event.Parse(schedule)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", schedule, err)
+		}
+		converted[index] = event.String()
+	}
+	return converted, nil
+}
+
+// systemdSchedules returns the schedules in the systemd calendar format,
+// the invalid cron expressions are left as is for systemd to report them
+func systemdSchedules(schedules []string) []string {
+	converted, err := convertCronSchedules(schedules)
+	if err != nil {
+		return schedules
+	}
+	return converted
+}
+
 func displaySystemdSchedules(terminal *term.Terminal, profile, command string, schedules []string) error {
 	binary, err := exec.LookPath(analyzeBinary)
 	if err != nil {
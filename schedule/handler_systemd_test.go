@@ -5,7 +5,9 @@ package schedule
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/calendar"
 	"github.com/creativeprojects/resticprofile/constants"
@@ -282,6 +284,99 @@ func TestDisplaySystemdSchedules(t *testing.T) {
 	assert.Contains(t, output, "Normalized form: *-*-* 00:00:00")
 }
 
+func TestConvertCronSchedules(t *testing.T) {
+	converted, err := convertCronSchedules([]string{"daily", "cron: */15 2-5 * * 1-5", "@weekly"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"daily", "Mon..Fri *-*-* 02..05:00,15,30,45:00", "Sun *-*-* 00:00:00"}, converted)
+
+	_, err = convertCronSchedules([]string{"cron: * * *"})
+	assert.Error(t, err)
+}
+
+func TestSystemdConfigWithCronSchedules(t *testing.T) {
+	handler := NewHandler(SchedulerSystemd{}).(*HandlerSystemd)
+	job := &Config{
+		ProfileName: "profile",
+		CommandName: "backup",
+		Command:     "/bin/resticprofile",
+		Arguments:   NewCommandArguments(nil),
+		Schedules:   []string{"cron: 0 2 * * *"},
+	}
+	config := handler.getSystemdConfig(job, systemd.UserUnit, "")
+	assert.Equal(t, []string{"*-*-* 02:00:00"}, config.Schedules)
+}
+
+func TestSystemdConfigWithRandomizedDelay(t *testing.T) {
+	handler := NewHandler(SchedulerSystemd{}).(*HandlerSystemd)
+	job := &Config{
+		ProfileName: "profile",
+		CommandName: "backup",
+		Command:     "/bin/resticprofile",
+		Arguments:   NewCommandArguments([]string{"run-schedule", "backup@profile"}),
+		Schedules:   []string{"daily"},
+	}
+	config := handler.getSystemdConfig(job, systemd.SystemUnit, "")
+	assert.Equal(t, "/bin/resticprofile --no-prio run-schedule backup@profile", config.CommandLine)
+	assert.Zero(t, config.RandomizedDelay)
+
+	job.RandomizedDelay = 15 * time.Minute
+	job.FixedRandomDelay = true
+	config = handler.getSystemdConfig(job, systemd.SystemUnit, "")
+	assert.Equal(t, "/bin/resticprofile --no-prio --no-random-delay run-schedule backup@profile", config.CommandLine)
+	assert.Equal(t, 15*time.Minute, config.RandomizedDelay)
+	assert.True(t, config.FixedRandomDelay)
+}
+
+func TestSystemdConfigWithRandomizedDelayAndTimerTemplate(t *testing.T) {
+	job := &Config{
+		ProfileName:     "profile",
+		CommandName:     "backup",
+		Command:         "/bin/resticprofile",
+		Arguments:       NewCommandArguments([]string{"run-schedule", "backup@profile"}),
+		Schedules:       []string{"daily"},
+		RandomizedDelay: 15 * time.Minute,
+	}
+	testCases := []struct {
+		template    string
+		commandLine string
+	}{
+		{
+			template:    "[Timer]\n{{ range .OnCalendar }}OnCalendar={{ . }}\n{{ end }}{{ if .RandomizedDelaySec }}RandomizedDelaySec={{ .RandomizedDelaySec }}\n{{ end }}",
+			commandLine: "/bin/resticprofile --no-prio --no-random-delay run-schedule backup@profile",
+		},
+		{
+			// the delay is left to resticprofile
+			template:    "[Timer]\n{{ range .OnCalendar }}OnCalendar={{ . }}\n{{ end }}",
+			commandLine: "/bin/resticprofile --no-prio run-schedule backup@profile",
+		},
+	}
+	for _, testCase := range testCases {
+		timerTemplate := filepath.Join(t.TempDir(), "timer.tmpl")
+		require.NoError(t, os.WriteFile(timerTemplate, []byte(testCase.template), 0o600))
+		handler := NewHandler(SchedulerSystemd{TimerTemplate: timerTemplate}).(*HandlerSystemd)
+		config := handler.getSystemdConfig(job, systemd.SystemUnit, "")
+		assert.Equal(t, testCase.commandLine, config.CommandLine)
+	}
+}
+
+func TestSystemdConfigWithOnFailure(t *testing.T) {
+	handler := NewHandler(SchedulerSystemd{}).(*HandlerSystemd)
+	job := &Config{
+		ProfileName: "profile",
+		CommandName: "backup",
+		Command:     "/bin/resticprofile",
+		Arguments:   NewCommandArguments([]string{"--config", "/etc/profiles.yaml", "run-schedule", "backup@profile"}),
+		Schedules:   []string{"daily"},
+	}
+	config := handler.getSystemdConfig(job, systemd.SystemUnit, "")
+	assert.Empty(t, config.OnFailureCommandLine)
+
+	job.SystemdOnFailure = true
+	config = handler.getSystemdConfig(job, systemd.SystemUnit, "")
+	assert.Equal(t, "/bin/resticprofile --config /etc/profiles.yaml notify-failure backup@profile", config.OnFailureCommandLine)
+	assert.Equal(t, "/bin/resticprofile --no-prio --config /etc/profiles.yaml run-schedule backup@profile", config.CommandLine)
+}
+
 func TestCloseHandlerRunsDaemonReload(t *testing.T) {
 	handler := NewHandler(SchedulerSystemd{}).(*HandlerSystemd)
 	require.NoError(t, handler.Init())
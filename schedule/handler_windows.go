@@ -30,7 +30,7 @@ func (h *HandlerWindows) Close() {
 
 // ParseSchedules into *calendar.Event
 func (h *HandlerWindows) ParseSchedules(schedules []string) ([]*calendar.Event, error) {
-	return parseSchedules(schedules)
+	return parseLocalSchedules(schedules)
 }
 
 // DisplaySchedules via term output
@@ -71,6 +71,25 @@ func (j *Job) Create() error {
 	return nil
 }
 
+// Preview returns the files the scheduler would write to create the job, without creating it.
+// The pending contents (by path) are the files rendered for the jobs previewed before this one (can be nil).
+func (j *Job) Preview(pending map[string]string) ([]FileChange, error) {
+	if j.RemoveOnly() {
+		return nil, ErrJobCanBeRemovedOnly
+	}
+	previewer, ok := j.handler.(Previewer)
+	if !ok {
+		return nil, ErrPreviewNotSupported
+	}
+
+	permission := j.getSchedulePermission(PermissionFromConfig(j.config.Permission))
+	schedules, err := j.handler.ParseSchedules(j.config.Schedules)
+	if err != nil {
+		return nil, err
+	}
+	return previewer.PreviewJob(j.config, schedules, permission, pending)
+}
+
 // Remove a job
 func (j *Job) Remove() error {
 	permission := PermissionFromConfig(j.config.Permission)
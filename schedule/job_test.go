@@ -82,3 +82,16 @@ func TestCreateJobErrorCreate(t *testing.T) {
 	err := job.Create()
 	require.Error(t, err)
 }
+
+func TestPreviewJobNotSupported(t *testing.T) {
+	handler := mocks.NewHandler(t)
+
+	job := schedule.NewJob(handler, &schedule.Config{
+		ProfileName: "profile",
+		CommandName: "backup",
+		Schedules:   []string{},
+	})
+
+	_, err := job.Preview(nil)
+	require.ErrorIs(t, err, schedule.ErrPreviewNotSupported)
+}
@@ -0,0 +1,17 @@
+package schedule
+
+import "github.com/creativeprojects/resticprofile/calendar"
+
+// FileChange is a file written (or removed) by the scheduler when creating a job
+type FileChange struct {
+	Path    string
+	Current string // content currently installed, empty when the file doesn't exist
+	Content string // content to install, empty when the file is removed
+}
+
+// Previewer is implemented by the handlers able to render the files of a job without installing it.
+// The pending contents (by path) are the files rendered for the previous jobs: a file shared by several jobs
+// (like a crontab) is rendered from its pending content instead of the content currently installed.
+type Previewer interface {
+	PreviewJob(job *Config, schedules []*calendar.Event, permission Permission, pending map[string]string) ([]FileChange, error)
+}
@@ -40,6 +40,41 @@ func parseSchedules(schedules []string) ([]*calendar.Event, error) {
 	return events, nil
 }
 
+// parseLocalSchedules creates a *calendar.Event in local time from a string, for the schedulers without timezone support.
+// An event in another timezone is converted into one or more events in local time. The conversion is refused when
+// the time difference changes during the year: the installed job would run at the wrong time after the next daylight saving time change.
+func parseLocalSchedules(schedules []string) ([]*calendar.Event, error) {
+	events, err := parseSchedules(schedules)
+	if err != nil {
+		return events, err
+	}
+	now := time.Now()
+	localEvents := make([]*calendar.Event, 0, len(events))
+	for _, event := range events {
+		if event.Day.IsReverse() {
+			return localEvents, fmt.Errorf("schedule %q: the last days of the month (~) are only supported by systemd", event.Input())
+		}
+		if event.Location == nil {
+			localEvents = append(localEvents, event)
+			continue
+		}
+		if event.OffsetChanges(time.Local, now) {
+			return localEvents, fmt.Errorf("schedule %q: the time difference between %s and the local time changes during the year (daylight saving time): this timezone is only supported by systemd, use a schedule in local time instead",
+				event.Input(), event.Location)
+		}
+		at := event.Next(now)
+		if at.IsZero() {
+			at = now
+		}
+		converted, err := event.ConvertTo(time.Local, at)
+		if err != nil {
+			return localEvents, fmt.Errorf("schedule %q: %w", event.Input(), err)
+		}
+		localEvents = append(localEvents, converted...)
+	}
+	return localEvents, nil
+}
+
 func displayParsedSchedules(terminal *term.Terminal, profile, command string, events []*calendar.Event) {
 	now := time.Now().Round(time.Second)
 	for index, event := range events {
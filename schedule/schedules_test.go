@@ -3,6 +3,7 @@ package schedule
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/stretchr/testify/assert"
@@ -70,3 +71,49 @@ func TestDisplayParseSchedulesIndexAndTotal(t *testing.T) {
 	assert.Contains(t, output, "schedule 2/3")
 	assert.Contains(t, output, "schedule 3/3")
 }
+
+func TestParseLocalSchedules(t *testing.T) {
+	events, err := parseLocalSchedules([]string{"daily", "Mon..Fri 08:00 UTC"})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(events), 2)
+	assert.Equal(t, "*-*-* 00:00:00", events[0].String())
+
+	// all the converted events are in local time
+	for _, event := range events {
+		assert.Nil(t, event.Location)
+	}
+	if _, offset := time.Now().Zone(); offset == 0 {
+		assert.Equal(t, "Mon..Fri *-*-* 08:00:00", events[1].String())
+	}
+
+	_, err = parseLocalSchedules([]string{"*-*-01 00:30 Pacific/Kiritimati"})
+	if _, offset := time.Now().Zone(); offset != 14*3600 {
+		assert.Error(t, err)
+	}
+}
+
+func TestParseLocalSchedulesWithDaylightSavingTime(t *testing.T) {
+	defaultLocal := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = defaultLocal }()
+
+	_, err := parseLocalSchedules([]string{"daily Europe/Paris"})
+	assert.ErrorContains(t, err, "only supported by systemd")
+
+	// no daylight saving time in Japan
+	events, err := parseLocalSchedules([]string{"daily Asia/Tokyo"})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "*-*-* 15:00:00", events[0].String())
+}
+
+func TestParseLocalSchedulesWithRepetition(t *testing.T) {
+	events, err := parseLocalSchedules([]string{"*:0/15", "*-*-1/10 03:00"})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "*-*-* *:00,15,30,45:00", events[0].String())
+	assert.Equal(t, "*-*-01,11,21,31 03:00:00", events[1].String())
+
+	_, err = parseLocalSchedules([]string{"*-*~01 03:00"})
+	assert.ErrorContains(t, err, "only supported by systemd")
+}
@@ -3,13 +3,16 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/schedule"
 	"github.com/creativeprojects/resticprofile/util"
+	"github.com/creativeprojects/resticprofile/util/diff"
 )
 
 var scheduleJobs = func(handler schedule.Handler, configs []*config.Schedule) error {
@@ -29,49 +32,124 @@ var scheduleJobs = func(handler schedule.Handler, configs []*config.Schedule) er
 	defer handler.Close()
 
 	for _, cfg := range configs {
-		scheduleConfig := scheduleToConfig(cfg)
-		scheduleName := scheduleConfig.CommandName + "@" + scheduleConfig.ProfileName
-
-		if scheduleConfig.ConfigFile != "" {
-			absConfig := scheduleConfig.ConfigFile
-			if !filepath.IsAbs(absConfig) {
-				absConfig = filepath.Join(currentDir, absConfig)
-			}
-			scheduleConfig.ConfigFile = filepath.Clean(absConfig)
+		scheduleConfig := newScheduleConfig(cfg, currentDir, binary)
+		job := schedule.NewJob(handler, scheduleConfig)
+		err = job.Create()
+		if err != nil {
+			return fmt.Errorf("error creating job %s/%s: %w",
+				scheduleConfig.ProfileName,
+				scheduleConfig.CommandName,
+				err)
 		}
+		clog.Infof("scheduled job %s/%s created", scheduleConfig.ProfileName, scheduleConfig.CommandName)
+	}
+	return nil
+}
 
-		args := []string{
-			"--no-ansi",
-			"--config",
-			scheduleConfig.ConfigFile,
-			"run-schedule",
-			scheduleName,
+// previewJobs displays the files the scheduler would write for the jobs of all the profiles, as a diff against the files currently installed.
+// A file shared by several jobs (like a crontab) is displayed once, with the changes of all the jobs.
+func previewJobs(allJobs []profileJobs, output io.Writer) error {
+	preview := &jobsPreview{pending: make(map[string]string)}
+	for _, j := range allJobs {
+		if err := preview.add(schedule.NewHandler(j.schedulerConfig), j.jobs); err != nil {
+			return err
 		}
+	}
 
-		wd := currentDir
-		if scheduleConfig.ConfigFile != "" {
-			wd = filepath.Dir(scheduleConfig.ConfigFile)
+	for _, file := range preview.files {
+		fromName, toName := file.Path, file.Path
+		if file.Current == "" {
+			fromName = os.DevNull
+		}
+		if file.Content == "" {
+			toName = os.DevNull
+		}
+		if unified := diff.Unified(fromName, toName, file.Current, file.Content); unified != "" {
+			_, _ = fmt.Fprintln(output, unified)
+		} else {
+			_, _ = fmt.Fprintf(output, "%s: no change\n\n", file.Path)
 		}
+	}
+	return nil
+}
 
-		scheduleConfig.SetCommand(wd, binary, args)
-		scheduleConfig.JobDescription =
-			fmt.Sprintf("resticprofile %s for profile %s in %s", scheduleConfig.CommandName, scheduleConfig.ProfileName, scheduleConfig.ConfigFile)
-		scheduleConfig.TimerDescription =
-			fmt.Sprintf("%s timer for profile %s in %s", scheduleConfig.CommandName, scheduleConfig.ProfileName, scheduleConfig.ConfigFile)
+// jobsPreview accumulates the files rendered for the jobs
+type jobsPreview struct {
+	files   []schedule.FileChange // content currently installed and content after creating all the jobs
+	pending map[string]string     // content after creating the jobs previewed so far, by path
+}
 
-		job := schedule.NewJob(handler, scheduleConfig)
-		err = job.Create()
+func (p *jobsPreview) add(handler schedule.Handler, configs []*config.Schedule) error {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	binary, err := util.Executable()
+	if err != nil {
+		return err
+	}
+
+	err = handler.Init()
+	if err != nil {
+		return err
+	}
+	defer handler.Close()
+
+	for _, cfg := range configs {
+		scheduleConfig := newScheduleConfig(cfg, currentDir, binary)
+		changes, err := schedule.NewJob(handler, scheduleConfig).Preview(p.pending)
 		if err != nil {
-			return fmt.Errorf("error creating job %s/%s: %w",
+			return fmt.Errorf("error previewing job %s/%s: %w",
 				scheduleConfig.ProfileName,
 				scheduleConfig.CommandName,
 				err)
 		}
-		clog.Infof("scheduled job %s/%s created", scheduleConfig.ProfileName, scheduleConfig.CommandName)
+		for _, change := range changes {
+			p.pending[change.Path] = change.Content
+			if index := slices.IndexFunc(p.files, func(file schedule.FileChange) bool { return file.Path == change.Path }); index >= 0 {
+				p.files[index].Content = change.Content
+			} else {
+				p.files = append(p.files, change)
+			}
+		}
 	}
 	return nil
 }
 
+// newScheduleConfig converts the schedule into a job config calling resticprofile from binary
+func newScheduleConfig(cfg *config.Schedule, currentDir, binary string) *schedule.Config {
+	scheduleConfig := scheduleToConfig(cfg)
+	scheduleName := scheduleConfig.CommandName + "@" + scheduleConfig.ProfileName
+
+	if scheduleConfig.ConfigFile != "" {
+		absConfig := scheduleConfig.ConfigFile
+		if !filepath.IsAbs(absConfig) {
+			absConfig = filepath.Join(currentDir, absConfig)
+		}
+		scheduleConfig.ConfigFile = filepath.Clean(absConfig)
+	}
+
+	args := []string{
+		"--no-ansi",
+		"--config",
+		scheduleConfig.ConfigFile,
+		"run-schedule",
+		scheduleName,
+	}
+
+	wd := currentDir
+	if scheduleConfig.ConfigFile != "" {
+		wd = filepath.Dir(scheduleConfig.ConfigFile)
+	}
+
+	scheduleConfig.SetCommand(wd, binary, args)
+	scheduleConfig.JobDescription =
+		fmt.Sprintf("resticprofile %s for profile %s in %s", scheduleConfig.CommandName, scheduleConfig.ProfileName, scheduleConfig.ConfigFile)
+	scheduleConfig.TimerDescription =
+		fmt.Sprintf("%s timer for profile %s in %s", scheduleConfig.CommandName, scheduleConfig.ProfileName, scheduleConfig.ConfigFile)
+	return scheduleConfig
+}
+
 func removeJobs(handler schedule.Handler, configs []*config.Schedule) error {
 	err := handler.Init()
 	if err != nil {
@@ -193,24 +271,35 @@ func scheduleToConfig(sched *config.Schedule) *schedule.Config {
 		return schedule.NewRemoveOnlyConfig(origin.Name, origin.Command)
 	}
 	return &schedule.Config{
-		ProfileName:        origin.Name,
-		CommandName:        origin.Command,
-		Schedules:          sched.Schedules,
-		Permission:         sched.Permission,
-		RunLevel:           sched.RunLevel,
-		WorkingDirectory:   "",
-		Command:            "",
-		Arguments:          schedule.NewCommandArguments(nil),
-		Environment:        sched.Environment,
-		JobDescription:     "",
-		TimerDescription:   "",
-		Priority:           sched.Priority,
-		ConfigFile:         sched.ConfigFile,
-		Flags:              sched.Flags,
-		AfterNetworkOnline: sched.AfterNetworkOnline.IsTrue(),
-		SystemdDropInFiles: sched.SystemdDropInFiles,
-		HideWindow:         sched.HideWindow.IsTrue(),
-		Log:                sched.Log,
-		StartWhenAvailable: sched.StartWhenAvailable.IsTrue(),
+		ProfileName:           origin.Name,
+		CommandName:           origin.Command,
+		Schedules:             sched.Schedules,
+		Permission:            sched.Permission,
+		RunLevel:              sched.RunLevel,
+		WorkingDirectory:      "",
+		Command:               "",
+		Arguments:             schedule.NewCommandArguments(nil),
+		Environment:           sched.Environment,
+		JobDescription:        "",
+		TimerDescription:      "",
+		Priority:              sched.Priority,
+		ConfigFile:            sched.ConfigFile,
+		Flags:                 sched.Flags,
+		AfterNetworkOnline:    sched.AfterNetworkOnline.IsTrue(),
+		SystemdDropInFiles:    sched.SystemdDropInFiles,
+		HideWindow:            sched.HideWindow.IsTrue(),
+		Log:                   sched.Log,
+		StartWhenAvailable:    sched.StartWhenAvailable.IsTrue(),
+		RandomizedDelay:       sched.GetRandomizedDelay(),
+		FixedRandomDelay:      sched.FixedRandomDelay.IsTrue(),
+		CatchUp:               sched.CatchUp.IsTrue(),
+		SystemdHardening:      sched.SystemdHardening.IsTrue(),
+		SystemdReadWritePaths: sched.SystemdReadWritePaths,
+		SystemdMemoryMax:      sched.SystemdMemoryMax,
+		SystemdCPUQuota:       sched.SystemdCPUQuota,
+		SystemdIOWeight:       sched.SystemdIOWeight,
+		SystemdCredentials:    sched.Credentials,
+		SystemdWatchdog:       sched.SystemdWatchdog.Value(),
+		SystemdOnFailure:      sched.SystemdOnFailure.IsTrue(),
 	}
 }
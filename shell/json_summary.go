@@ -28,11 +28,33 @@ type ResticJsonSummary struct {
 	SnapshotID          string  `json:"snapshot_id"`
 }
 
+type ResticJsonStatus struct {
+	MessageType  string   `json:"message_type"`
+	PercentDone  float64  `json:"percent_done"`
+	TotalFiles   int      `json:"total_files"`
+	FilesDone    int      `json:"files_done"`
+	TotalBytes   int64    `json:"total_bytes"`
+	BytesDone    int64    `json:"bytes_done"`
+	ErrorCount   int      `json:"error_count"`
+	CurrentFiles []string `json:"current_files"`
+}
+
 // ScanBackupJson should populate the backup summary values from the output of the --json flag
-var ScanBackupJson ScanOutput = func(r io.Reader, summary *monitor.Summary, w io.Writer) error {
+var ScanBackupJson = NewScanBackupJson(nil)
+
+// NewScanBackupJson returns a ScanOutput populating the backup summary values from the output of the --json flag,
+// and sending the status messages to the status callback (when not nil)
+func NewScanBackupJson(status func(monitor.Status)) ScanOutput {
+	return func(r io.Reader, summary *monitor.Summary, w io.Writer) error {
+		return scanBackupJson(r, summary, w, status)
+	}
+}
+
+func scanBackupJson(r io.Reader, summary *monitor.Summary, w io.Writer, status func(monitor.Status)) error {
 	bogusPrefix := []byte("\r\x1b[2K")
 	jsonPrefix := []byte(`{"message_type":"`)
 	summaryPrefix := []byte(`{"message_type":"summary",`)
+	statusPrefix := []byte(`{"message_type":"status",`)
 	jsonSuffix := []byte("}")
 	eol := "\n"
 	if runtime.GOOS == "windows" {
@@ -59,6 +81,21 @@ var ScanBackupJson ScanOutput = func(r io.Reader, summary *monitor.Summary, w io
 				summary.BytesAdded = jsonSummary.DataAdded
 				summary.BytesAddedPacked = jsonSummary.DataAddedPacked
 				summary.BytesTotal = jsonSummary.TotalBytesProcessed
+			} else if status != nil && bytes.HasPrefix(line, statusPrefix) {
+				jsonStatus := ResticJsonStatus{}
+				err := json.Unmarshal(line, &jsonStatus)
+				if err != nil {
+					continue
+				}
+				status(monitor.Status{
+					PercentDone:  jsonStatus.PercentDone,
+					TotalFiles:   jsonStatus.TotalFiles,
+					FilesDone:    jsonStatus.FilesDone,
+					TotalBytes:   jsonStatus.TotalBytes,
+					BytesDone:    jsonStatus.BytesDone,
+					ErrorCount:   jsonStatus.ErrorCount,
+					CurrentFiles: jsonStatus.CurrentFiles,
+				})
 			}
 			continue
 		}
@@ -68,6 +68,29 @@ func TestScanJsonSummary(t *testing.T) {
 	assert.Equal(t, 236, summary.FilesTotal)
 }
 
+func TestScanJsonStatus(t *testing.T) {
+	t.Parallel()
+
+	resticOutput := `{"message_type":"status","percent_done":0,"total_files":1,"total_bytes":10244}
+{"message_type":"status","percent_done":0.5,"total_files":213,"files_done":13,"total_bytes":362948126,"bytes_done":181474063,"error_count":1,"current_files":["/source/file"]}
+{"message_type":"summary","files_new":213,"total_files_processed":236,"total_bytes_processed":362948126,"snapshot_id":"6daa8ef6"}
+`
+	statuses := make([]monitor.Status, 0, 2)
+	summary := &monitor.Summary{}
+	output := &strings.Builder{}
+	err := NewScanBackupJson(func(status monitor.Status) {
+		statuses = append(statuses, status)
+	})(strings.NewReader(resticOutput), summary, output)
+	require.NoError(t, err)
+
+	assert.Empty(t, output.String())
+	assert.Equal(t, 213, summary.FilesNew)
+	assert.Equal(t, []monitor.Status{
+		{TotalFiles: 1, TotalBytes: 10244},
+		{PercentDone: 0.5, TotalFiles: 213, FilesDone: 13, TotalBytes: 362948126, BytesDone: 181474063, ErrorCount: 1, CurrentFiles: []string{"/source/file"}},
+	}, statuses)
+}
+
 func TestScanJsonError(t *testing.T) {
 	t.Parallel()
 
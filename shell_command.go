@@ -58,8 +58,8 @@ func runShellCommand(command shellCommandDefinition) (summary monitor.Summary, s
 	shellCmd := shell.NewSignalledCommand(command.command, command.args, command.sigChan)
 
 	shellCmd.Shell = command.shell
-	shellCmd.Stdout = command.stdout
-	shellCmd.Stderr = command.stderr
+	shellCmd.Stdout = watchOutput(command.stdout)
+	shellCmd.Stderr = watchOutput(command.stderr)
 
 	if command.dryRun {
 		shellBinary, args, commandErr := shellCmd.GetShellCommand()
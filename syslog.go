@@ -17,6 +17,7 @@ import (
 
 type Syslog struct {
 	writer *syslog.Writer
+	fields *logFields // messages are sent as JSON when set
 }
 
 func NewSyslogHandler(writer *syslog.Writer) *Syslog {
@@ -25,11 +26,20 @@ func NewSyslogHandler(writer *syslog.Writer) *Syslog {
 	}
 }
 
+// WithJSON sends messages as JSON objects containing the fields of the run
+func (l *Syslog) WithJSON(fields *logFields) *Syslog {
+	l.fields = fields
+	return l
+}
+
 func (l *Syslog) LogEntry(entry clog.LogEntry) error {
 	if l.writer == nil {
 		return errors.New("invalid syslog writer")
 	}
 	message := entry.GetMessage()
+	if l.fields != nil {
+		message = string(l.fields.encode(entry.Level, "", message))
+	}
 	switch entry.Level {
 	case clog.LevelDebug:
 		return l.writer.Debug(message)
@@ -74,11 +84,15 @@ func (s *tokenWriter) Write(p []byte) (n int, err error) {
 	return
 }
 
-func getSyslogHandler(scheme, hostPort string) (handler *Syslog, writer io.Writer, err error) {
+func getSyslogHandler(scheme, hostPort, logFormat string, options syslogOptions) (handler LogCloser, writer io.Writer, err error) {
+	defaultPort := DefaultSyslogPort
 	switch scheme {
 	case "udp", "tcp":
 	case "syslog-tcp":
 		scheme = "tcp"
+	case "syslog-tls":
+		scheme = "tls"
+		defaultPort = DefaultSyslogTLSPort
 	case "syslog":
 		if len(hostPort) == 0 {
 			scheme = "local"
@@ -89,20 +103,37 @@ func getSyslogHandler(scheme, hostPort string) (handler *Syslog, writer io.Write
 		err = fmt.Errorf("unsupported syslog URL scheme %q", scheme)
 		return
 	}
+	if scheme != "local" {
+		if _, _, e := net.SplitHostPort(hostPort); e != nil && strings.Contains(e.Error(), "missing port") {
+			hostPort = net.JoinHostPort(hostPort, defaultPort)
+		}
+	}
+
+	// log/syslog only speaks RFC 3164 over plain UDP and TCP
+	if scheme == "tls" || (scheme != "local" && options.format != "" && options.format != syslogFormatRFC3164) {
+		var sender *SyslogSender
+		sender, err = newSyslogSender(scheme, hostPort, logFormat, options)
+		if err != nil {
+			err = fmt.Errorf("cannot open syslog logger: %w", err)
+			return
+		}
+		return sender, &tokenWriter{separator: []byte("\n"), target: sender}, nil
+	}
 
 	var logger *syslog.Writer
 	if scheme == "local" {
 		logger, err = syslog.New(syslog.LOG_USER|syslog.LOG_NOTICE, constants.ApplicationName)
 	} else {
-		if _, _, e := net.SplitHostPort(hostPort); e != nil && strings.Contains(e.Error(), "missing port") {
-			hostPort = net.JoinHostPort(hostPort, DefaultSyslogPort)
-		}
 		logger, err = syslog.Dial(scheme, hostPort, syslog.LOG_USER|syslog.LOG_NOTICE, constants.ApplicationName)
 	}
 
 	if err == nil {
 		writer = &tokenWriter{separator: []byte("\n"), target: logger}
-		handler = NewSyslogHandler(logger)
+		syslogHandler := NewSyslogHandler(logger)
+		if logFormat == constants.LogFormatJSON {
+			syslogHandler.WithJSON(jsonLogFields)
+		}
+		handler = syslogHandler
 	} else {
 		err = fmt.Errorf("cannot open syslog logger: %w", err)
 	}
@@ -0,0 +1,263 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+const (
+	DefaultSyslogTLSPort = "6514"
+
+	syslogFormatRFC3164 = "rfc3164"
+	syslogFormatRFC5424 = "rfc5424"
+
+	syslogFacilityUser = 1
+	syslogDialTimeout  = 10 * time.Second
+	syslogWriteTimeout = 10 * time.Second
+
+	// syslogStructuredDataID uses the private enterprise number reserved for documentation (RFC 5612)
+	syslogStructuredDataID = "resticprofile@32473"
+)
+
+// SyslogSender sends log entries to a remote syslog server over UDP, TCP or TLS (RFC 5425), formatted as RFC 3164 or RFC 5424.
+// Messages sent over TCP and TLS are framed with octet counting (RFC 6587).
+type SyslogSender struct {
+	mutex     sync.Mutex
+	network   string // udp, tcp or tls
+	address   string
+	tlsConfig *tls.Config
+	format    string
+	hostname  string
+	pid       int
+	fields    *logFields // run context, added as structured data (RFC 5424)
+	json      bool       // messages are JSON records
+	conn      net.Conn
+	closed    bool
+}
+
+func newSyslogSender(network, address, logFormat string, options syslogOptions) (*SyslogSender, error) {
+	format := options.format
+	if format == "" {
+		format = syslogFormatRFC3164
+		if network == "tls" {
+			format = syslogFormatRFC5424
+		}
+	}
+	if format != syslogFormatRFC3164 && format != syslogFormatRFC5424 {
+		return nil, fmt.Errorf("unsupported syslog format %q", options.format)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	sender := &SyslogSender{
+		network:  network,
+		address:  address,
+		format:   format,
+		hostname: hostname,
+		pid:      os.Getpid(),
+		fields:   jsonLogFields,
+		json:     logFormat == constants.LogFormatJSON,
+	}
+	if network == "tls" {
+		host, _, _ := net.SplitHostPort(address)
+		sender.tlsConfig, err = syslogTLSConfig(host, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// connect now to report a configuration error early
+	if err = sender.connect(); err != nil {
+		return nil, err
+	}
+	return sender, nil
+}
+
+func syslogTLSConfig(serverName string, options syslogOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+	if options.caCertificate != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		caCert, err := os.ReadFile(options.caCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load CA certificate: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("invalid certificate: %q", options.caCertificate)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if options.clientCertificate != "" {
+		// same as restic: the file contains both the certificate and the private key
+		certificate, err := tls.LoadX509KeyPair(options.clientCertificate, options.clientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+func (s *SyslogSender) connect() (err error) {
+	dialer := &net.Dialer{Timeout: syslogDialTimeout}
+	if s.network == "tls" {
+		s.conn, err = tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+	} else {
+		s.conn, err = dialer.Dial(s.network, s.address)
+	}
+	if err != nil {
+		s.conn = nil
+		err = fmt.Errorf("cannot connect to syslog server: %w", err)
+	}
+	return
+}
+
+// LogEntry sends a log entry with the severity of its level
+func (s *SyslogSender) LogEntry(entry clog.LogEntry) error {
+	message := entry.GetMessage()
+	if s.json {
+		message = string(s.fields.encode(entry.Level, "", message))
+	}
+	return s.send(syslogSeverity(entry.Level), "-", message)
+}
+
+// Write sends a line of command output with the "notice" severity
+func (s *SyslogSender) Write(p []byte) (int, error) {
+	message := strings.TrimRight(string(p), "\r\n")
+	if strings.TrimSpace(message) == "" {
+		return len(p), nil
+	}
+	return len(p), s.send(syslogSeverity(-1), "output", message)
+}
+
+func (s *SyslogSender) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSender) send(severity int, messageID, message string) error {
+	packet := s.format5424(severity, messageID, message)
+	if s.format == syslogFormatRFC3164 {
+		packet = s.format3164(severity, message)
+	}
+	if s.network != "udp" {
+		// octet counting framing
+		packet = strconv.Itoa(len(packet)) + " " + packet
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return errors.New("syslog sender is closed")
+	}
+	var err error
+	// try again on a new connection if the server closed it
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.connect(); err != nil {
+				return err
+			}
+		}
+		_ = s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+		if _, err = s.conn.Write([]byte(packet)); err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// format5424 formats a message as "<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG"
+func (s *SyslogSender) format5424(severity int, messageID, message string) string {
+	priority := syslogFacilityUser*8 + severity
+	s.fields.mutex.RLock()
+	params := [][2]string{
+		{"profile", s.fields.profile},
+		{"group", s.fields.group},
+		{"command", s.fields.command},
+		{"run_id", s.fields.runID},
+	}
+	s.fields.mutex.RUnlock()
+
+	data := &strings.Builder{}
+	data.WriteString("[" + syslogStructuredDataID)
+	for _, param := range params {
+		if param[1] != "" {
+			data.WriteString(" " + param[0] + "=\"" + escapeSDParam(param[1]) + "\"")
+		}
+	}
+	data.WriteString("]")
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		priority,
+		time.Now().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname,
+		constants.ApplicationName,
+		s.pid,
+		messageID,
+		data.String(),
+		message,
+	)
+}
+
+// format3164 formats a message as "<PRI>TIMESTAMP HOSTNAME TAG[PID]: MSG"
+func (s *SyslogSender) format3164(severity int, message string) string {
+	priority := syslogFacilityUser*8 + severity
+	return fmt.Sprintf("<%d>%s %s %s[%d]: %s",
+		priority,
+		time.Now().Format(time.Stamp),
+		s.hostname,
+		constants.ApplicationName,
+		s.pid,
+		message,
+	)
+}
+
+// escapeSDParam escapes '"', '\' and ']' in a structured data parameter value
+func escapeSDParam(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+func syslogSeverity(level clog.LogLevel) int {
+	switch level {
+	case clog.LevelTrace, clog.LevelDebug:
+		return 7
+	case clog.LevelInfo:
+		return 6
+	case clog.LevelWarning:
+		return 4
+	case clog.LevelError:
+		return 3
+	default:
+		return 5 // notice
+	}
+}
+
+var _ LogCloser = &SyslogSender{}
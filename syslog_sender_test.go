@@ -0,0 +1,238 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateCertificate creates a self-signed certificate valid for 127.0.0.1, both as a server and a client.
+// It returns the TLS certificate and the path of a PEM file containing the certificate and its private key.
+func generateCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "resticprofile test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "cert.pem")
+	require.NoError(t, os.WriteFile(filename, append(certPEM, keyPEM...), 0o600))
+	return certificate, filename
+}
+
+// readFrame reads one octet counted message (RFC 6587)
+func readFrame(reader *bufio.Reader) (string, error) {
+	length, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(length))
+	if err != nil {
+		return "", err
+	}
+	message := make([]byte, size)
+	_, err = io.ReadFull(reader, message)
+	return string(message), err
+}
+
+func TestSyslogTLS(t *testing.T) {
+	certificate, certFile := generateCertificate(t)
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for range 3 {
+			message, err := readFrame(reader)
+			if err != nil {
+				return
+			}
+			messages <- message
+		}
+	}()
+
+	fields := jsonLogFields
+	fields.set(`home "1"`, "nightly", "backup")
+	defer fields.set("", "", "")
+
+	handler, writer, err := getSyslogHandler("syslog-tls", listener.Addr().String(), constants.LogFormatText, syslogOptions{
+		caCertificate:     certFile,
+		clientCertificate: certFile,
+	})
+	require.NoError(t, err)
+	defer handler.Close()
+
+	require.NoError(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelWarning, Format: "value is %d", Values: []any{10}}))
+	_, err = writer.Write([]byte("restic output\n\nsecond line"))
+	require.NoError(t, err)
+
+	received := make([]string, 0, 3)
+	for range 3 {
+		select {
+		case message := <-messages:
+			received = append(received, message)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timeout waiting for syslog messages", "received %v", received)
+		}
+	}
+
+	// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+	parts := strings.SplitN(received[0], " ", 7)
+	require.Len(t, parts, 7)
+	assert.Equal(t, "<12>1", parts[0]) // user.warning
+	_, err = time.Parse(time.RFC3339Nano, parts[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "resticprofile", parts[3])
+	assert.Equal(t, strconv.Itoa(os.Getpid()), parts[4])
+	assert.Equal(t, "-", parts[5])
+	assert.Equal(t, `[resticprofile@32473 profile="home \"1\"" group="nightly" command="backup" run_id="`+fields.runID+`"] value is 10`, parts[6])
+
+	assert.True(t, strings.HasPrefix(received[1], "<13>1 "), received[1]) // user.notice
+	assert.True(t, strings.HasSuffix(received[1], " output [resticprofile@32473 profile=\"home \\\"1\\\"\" group=\"nightly\" command=\"backup\" run_id=\""+fields.runID+"\"] restic output"), received[1])
+	assert.True(t, strings.HasSuffix(received[2], "] second line"), received[2])
+}
+
+func TestSyslogTLSUnknownAuthority(t *testing.T) {
+	certificate, _ := generateCertificate(t)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	_, _, err = getSyslogHandler("syslog-tls", listener.Addr().String(), "", syslogOptions{})
+	assert.ErrorContains(t, err, "certificate")
+}
+
+func TestSyslogRFC5424OverUDP(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	handler, _, err := getSyslogHandler("udp", server.LocalAddr().String(), constants.LogFormatJSON, syslogOptions{format: syslogFormatRFC5424})
+	require.NoError(t, err)
+	defer handler.Close()
+	require.IsType(t, &SyslogSender{}, handler)
+
+	require.NoError(t, handler.LogEntry(clog.LogEntry{Level: clog.LevelError, Format: "failed"}))
+
+	buffer := make([]byte, 4096)
+	require.NoError(t, server.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := server.ReadFrom(buffer)
+	require.NoError(t, err)
+	message := string(buffer[:n])
+	// no framing over UDP
+	assert.True(t, strings.HasPrefix(message, "<11>1 "), message) // user.err
+	assert.Contains(t, message, `] {"time":`)
+	assert.Contains(t, message, `"message":"failed"}`)
+}
+
+func TestSyslogRFC3164OverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if message, err := readFrame(bufio.NewReader(conn)); err == nil {
+			messages <- message
+		}
+	}()
+
+	sender, err := newSyslogSender("tcp", listener.Addr().String(), "", syslogOptions{})
+	require.NoError(t, err)
+	defer sender.Close()
+	require.NoError(t, sender.LogEntry(clog.LogEntry{Level: clog.LevelInfo, Format: "hello"}))
+
+	select {
+	case message := <-messages:
+		assert.True(t, strings.HasPrefix(message, "<14>"), message) // user.info
+		assert.True(t, strings.HasSuffix(message, " resticprofile["+strconv.Itoa(os.Getpid())+"]: hello"), message)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timeout waiting for syslog message")
+	}
+
+	require.NoError(t, sender.Close())
+	assert.Error(t, sender.LogEntry(clog.LogEntry{Level: clog.LevelInfo, Format: "closed"}))
+}
+
+func TestSyslogSenderInvalidOptions(t *testing.T) {
+	_, err := newSyslogSender("udp", "127.0.0.1:514", "", syslogOptions{format: "rfc1234"})
+	assert.ErrorContains(t, err, `unsupported syslog format "rfc1234"`)
+
+	_, err = newSyslogSender("tls", "127.0.0.1:6514", "", syslogOptions{caCertificate: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "cannot load CA certificate")
+}
+
+func TestEscapeSDParam(t *testing.T) {
+	assert.Equal(t, `a\"b\\c\]d`, escapeSDParam(`a"b\c]d`))
+	assert.Equal(t, "plain", escapeSDParam("plain"))
+}
@@ -7,7 +7,7 @@ import (
 	"io"
 )
 
-func getSyslogHandler(scheme, hostPort string) (_ LogCloser, _ io.Writer, err error) {
+func getSyslogHandler(scheme, hostPort, logFormat string, _ syslogOptions) (_ LogCloser, _ io.Writer, err error) {
 	err = errors.New("syslog is not supported on Windows")
 	return
 }
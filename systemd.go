@@ -3,22 +3,61 @@
 package main
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
 	"github.com/coreos/go-systemd/v22/daemon"
 	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+const (
+	// minimum interval between two STATUS messages sent from the restic progress
+	minSystemdStatusInterval = time.Second
+	// added to the lock wait when extending the timeout of the unit
+	extendTimeoutMargin = 30 * time.Second
+)
+
+var (
+	// can be replaced in tests
+	sdNotify          = daemon.SdNotify
+	sdWatchdogEnabled = daemon.SdWatchdogEnabled
+
+	// stops the watchdog pings of notifyStart
+	stopWatchdog chan struct{}
+	// time of the last sign of activity of the run (in unix nanoseconds): the watchdog is only pinged after some activity
+	lastActivity atomic.Int64
+	// the run is waiting (on a lock or before a retry) until this time (in unix nanoseconds)
+	waitingUntil atomic.Int64
+	// the activity of a backup comes from its progress instead of its output
+	activityFromProgress atomic.Bool
+	// the backup reported 100%: restic is saving the index and the snapshot without any progress
+	progressFinished atomic.Bool
 )
 
 func notifyStart() {
-	ok, err := daemon.SdNotify(false, daemon.SdNotifyReady)
+	ok, err := sdNotify(false, daemon.SdNotifyReady)
 	if err != nil {
 		clog.Errorf("cannot notify systemd: %w", err)
 	}
 	if ok {
 		clog.Debug("running as a systemd unit: sending 'ready' status")
+		notifyActivity()
+		startWatchdog()
 	}
 }
 
 func notifyStop() {
-	ok, err := daemon.SdNotify(false, daemon.SdNotifyStopping)
+	if stopWatchdog != nil {
+		close(stopWatchdog)
+		stopWatchdog = nil
+	}
+	ok, err := sdNotify(false, daemon.SdNotifyStopping)
 	if err != nil {
 		clog.Errorf("cannot notify systemd: %w", err)
 	}
@@ -26,3 +65,159 @@ func notifyStop() {
 		clog.Debug("running as a systemd unit: sending 'stopping' status")
 	}
 }
+
+// startWatchdog pings the systemd watchdog at half its interval, when the unit has a "WatchdogSec".
+// The watchdog is only pinged when the run showed some activity since the previous tick (output of the commands,
+// progress of a backup), while waiting on a lock and while a backup is saving its index and snapshot.
+func startWatchdog() {
+	interval, err := sdWatchdogEnabled(false)
+	if err != nil {
+		clog.Errorf("cannot read systemd watchdog: %w", err)
+		return
+	}
+	if interval <= 0 {
+		return
+	}
+	clog.Debugf("running as a systemd unit: sending watchdog pings every %s", interval/2)
+	stop := make(chan struct{})
+	stopWatchdog = stop
+	go func() {
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		var lastTick int64
+		for {
+			select {
+			case <-stop:
+				return
+			case tick := <-ticker.C:
+				now := tick.UnixNano()
+				if isAlive(lastTick, now) {
+					notifySystemd(daemon.SdNotifyWatchdog)
+				}
+				lastTick = now
+			}
+		}
+	}()
+}
+
+// isAlive returns true when the run showed some activity since the previous tick of the watchdog,
+// is waiting on a lock or is saving the snapshot of a backup
+func isAlive(lastTick, now int64) bool {
+	return lastActivity.Load() > lastTick || waitingUntil.Load() > now || progressFinished.Load()
+}
+
+// notifyActivity records a sign of life of the run for the watchdog
+func notifyActivity() {
+	lastActivity.Store(time.Now().UnixNano())
+}
+
+// watchOutput returns a writer recording the output of a command as a sign of life for the watchdog.
+// The output is left untouched when the watchdog is not running (it may be a terminal).
+func watchOutput(output io.Writer) io.Writer {
+	if stopWatchdog == nil || output == nil {
+		return output
+	}
+	return &activityWriter{output: output}
+}
+
+type activityWriter struct {
+	output io.Writer
+}
+
+func (w *activityWriter) Write(p []byte) (int, error) {
+	// a stuck backup keeps writing the same progress: its activity comes from the progress instead
+	if !activityFromProgress.Load() {
+		notifyActivity()
+	}
+	return w.output.Write(p)
+}
+
+// notifyWait tells systemd the run is waiting (on a lock or before a retry), extending the timeout of the unit
+func notifyWait(status string, delay time.Duration) {
+	waitingUntil.Store(time.Now().Add(delay).UnixNano())
+	notifySystemd(
+		"STATUS="+status,
+		fmt.Sprintf("EXTEND_TIMEOUT_USEC=%d", (delay+extendTimeoutMargin).Microseconds()),
+		daemon.SdNotifyWatchdog,
+	)
+}
+
+func notifySystemd(states ...string) {
+	if _, err := sdNotify(false, strings.Join(states, "\n")); err != nil {
+		clog.Debugf("cannot notify systemd: %s", err)
+	}
+}
+
+// systemdNotifier sends the progress of the profile to systemd (displayed by "systemctl status")
+type systemdNotifier struct {
+	profile          string
+	command          string
+	lastStatus       time.Time
+	lastProgress     [4]int64
+	progressReported bool
+}
+
+// newSystemdNotifier returns a progress receiver when running as a systemd unit, nil otherwise
+func newSystemdNotifier(profile *config.Profile) monitor.Receiver {
+	if os.Getenv("NOTIFY_SOCKET") == "" {
+		return nil
+	}
+	return &systemdNotifier{profile: profile.Name}
+}
+
+func (n *systemdNotifier) Start(command string) {
+	n.command = command
+	n.lastStatus = time.Time{}
+	n.progressReported = false
+	activityFromProgress.Store(false)
+	progressFinished.Store(false)
+	notifyActivity()
+	notifySystemd(fmt.Sprintf("STATUS=profile %s: running %s", n.profile, command))
+}
+
+func (n *systemdNotifier) Status(status monitor.Status) {
+	// restic only reports the progress of a backup with the extended status:
+	// from now on, a backup making no progress is killed by the watchdog
+	activityFromProgress.Store(true)
+	progress := [4]int64{int64(status.TotalFiles), int64(status.FilesDone), status.TotalBytes, status.BytesDone}
+	if !n.progressReported || progress != n.lastProgress {
+		n.lastProgress = progress
+		n.progressReported = true
+		notifyActivity()
+	}
+	// no more progress after 100%: restic is saving the index and the snapshot
+	progressFinished.Store(status.PercentDone >= 1)
+	if time.Since(n.lastStatus) >= minSystemdStatusInterval {
+		n.lastStatus = time.Now()
+		notifySystemd(fmt.Sprintf("STATUS=profile %s: %s %.1f%% done, %d/%d files, %s/%s",
+			n.profile, n.command, status.PercentDone*100, status.FilesDone, status.TotalFiles, formatBytes(status.BytesDone), formatBytes(status.TotalBytes)))
+	}
+}
+
+func (n *systemdNotifier) Summary(command string, _ monitor.Summary, _ string, result error) {
+	activityFromProgress.Store(false)
+	progressFinished.Store(false)
+	notifyActivity()
+	status := "finished"
+	if !monitor.IsSuccess(result) {
+		status = "failed"
+		if monitor.IsWarning(result) {
+			status = "finished with warnings"
+		}
+	}
+	notifySystemd(fmt.Sprintf("STATUS=profile %s: %s %s", n.profile, command, status))
+}
+
+// formatBytes displays a size in binary units
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -6,13 +6,13 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
-	"io"
 	"io/fs"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/creativeprojects/clog"
+	"github.com/spf13/afero"
 )
 
 var (
@@ -51,64 +51,46 @@ func (u Unit) IsTimerDropIn(file string) bool {
 	return false
 }
 
-func (u Unit) createDropIns(dir string, files []string) error {
-	if err := u.fs.MkdirAll(dir, 0o755); err != nil {
-		return err
-	}
-
+// renderDropIns returns the drop-in files to copy into dir, and the orphaned drop-in files to remove from dir
+func (u Unit) renderDropIns(dir string, files []string) ([]File, error) {
 	fileBasenamesOwned := make(map[string]struct{})
 	for _, file := range files {
 		fileBasenamesOwned[getOwnedName(filepath.Base(file))] = struct{}{}
 	}
 
-	d, err := u.fs.Open(dir)
-	if err != nil {
-		return err
-	}
-
-	entries, err := d.Readdir(-1)
-	if err != nil {
-		return err
-	}
-
-	for _, f := range entries {
-		if f.IsDir() {
-			continue
+	rendered := make([]File, 0, len(files))
+	if d, err := u.fs.Open(dir); err == nil {
+		entries, err := d.Readdir(-1)
+		_ = d.Close()
+		if err != nil {
+			return nil, err
 		}
-		createdByUs := ownedDropInRegex.MatchString(f.Name())
-		_, notOrphaned := fileBasenamesOwned[f.Name()]
-		if createdByUs && !notOrphaned {
-			orphanPath := filepath.Join(dir, f.Name())
-			clog.Debugf("deleting orphaned drop-in file %v", orphanPath)
-			if err := u.fs.Remove(orphanPath); err != nil {
-				return err
+		for _, f := range entries {
+			if f.IsDir() {
+				continue
+			}
+			createdByUs := ownedDropInRegex.MatchString(f.Name())
+			_, notOrphaned := fileBasenamesOwned[f.Name()]
+			if createdByUs && !notOrphaned {
+				rendered = append(rendered, File{Path: filepath.Join(dir, f.Name())})
 			}
 		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
 	}
 
 	for _, dropInFilePath := range files {
-		dropInFileBase := filepath.Base(dropInFilePath)
 		// change the extension to `.resticprofile.conf`
 		// to signify it wasn't created outside of resticprofile, i.e. we own it
-		dropInFileOwned := getOwnedName(dropInFileBase)
-		dstPath := filepath.Join(dir, dropInFileOwned)
-		clog.Debugf("writing %v", dstPath)
-		dst, err := u.fs.Create(dstPath)
-		if err != nil {
-			return err
-		}
-		defer dst.Close()
-
-		src, err := u.fs.Open(dropInFilePath)
+		dropInFileOwned := getOwnedName(filepath.Base(dropInFilePath))
+		content, err := afero.ReadFile(u.fs, dropInFilePath)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer src.Close()
-
-		if _, err := io.Copy(dst, src); err != nil {
-			return err
+		if content == nil {
+			content = []byte{}
 		}
+		rendered = append(rendered, File{Path: filepath.Join(dir, dropInFileOwned), Content: content})
 	}
-
-	return nil
+	return rendered, nil
 }
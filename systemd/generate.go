@@ -6,11 +6,12 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/constants"
@@ -26,6 +27,8 @@ const (
 
 	systemdUnitDefaultTmpl = `[Unit]
 Description={{ .JobDescription }}
+{{ if .OnFailure }}OnFailure={{ .OnFailure }}
+{{ end -}}
 {{ if .AfterNetworkOnline }}After=network-online.target
 {{ end }}
 [Service]
@@ -45,6 +48,39 @@ ExecStart={{ .CommandLine }}
 {{ range .Environment -}}
 Environment="{{ . }}"
 {{ end -}}
+{{ range .LoadCredentials -}}
+LoadCredential={{ . }}
+{{ end -}}
+{{ if .MemoryMax }}MemoryMax={{ .MemoryMax }}
+{{ end -}}
+{{ if .CPUQuota }}CPUQuota={{ .CPUQuota }}
+{{ end -}}
+{{ if .IOWeight }}IOWeight={{ .IOWeight }}
+{{ end -}}
+{{ if .WatchdogSec }}WatchdogSec={{ .WatchdogSec }}
+{{ end -}}
+{{ if .Hardening }}ProtectSystem=full
+ProtectHome=read-only
+PrivateTmp=true
+NoNewPrivileges=true
+{{ range .ReadWritePaths -}}
+ReadWritePaths=-{{ . }}
+{{ end -}}
+{{ end -}}
+`
+
+	systemdFailureUnitTmpl = `[Unit]
+Description=Notify the failure of {{ .JobDescription }}
+
+[Service]
+Type=oneshot
+WorkingDirectory={{ .WorkingDirectory }}
+ExecStart={{ .OnFailureCommandLine }}
+{{ if .User }}User={{ .User }}
+{{ end -}}
+{{ range .Environment -}}
+Environment="{{ . }}"
+{{ end -}}
 `
 
 	systemdTimerDefaultTmpl = `[Unit]
@@ -56,7 +92,10 @@ OnCalendar={{ . }}
 {{ end -}}
 Unit={{ .SystemdProfile }}
 Persistent=true
-
+{{ if .RandomizedDelaySec }}RandomizedDelaySec={{ .RandomizedDelaySec }}
+{{ end -}}
+{{ if .FixedRandomDelay }}FixedRandomDelay=true
+{{ end }}
 [Install]
 WantedBy=timers.target
 `
@@ -88,6 +127,17 @@ type templateInfo struct {
 	IOSchedulingClass    int
 	IOSchedulingPriority int
 	User                 string
+	RandomizedDelaySec   int
+	FixedRandomDelay     bool
+	Hardening            bool
+	ReadWritePaths       []string
+	MemoryMax            string
+	CPUQuota             string
+	IOWeight             int
+	LoadCredentials      []string
+	OnFailure            string
+	OnFailureCommandLine string
+	WatchdogSec          int
 }
 
 // Config for generating systemd unit and timer files
@@ -111,6 +161,16 @@ type Config struct {
 	IOSchedulingClass    int
 	IOSchedulingPriority int
 	User                 string
+	RandomizedDelay      time.Duration
+	FixedRandomDelay     bool
+	Hardening            bool     // ProtectSystem, ProtectHome, PrivateTmp and NoNewPrivileges
+	ReadWritePaths       []string // paths staying writable with Hardening
+	MemoryMax            string
+	CPUQuota             string
+	IOWeight             int
+	Credentials          map[string]string // LoadCredential: ID to file path
+	OnFailureCommandLine string            // command started by the OnFailure unit (no unit when empty)
+	Watchdog             time.Duration     // WatchdogSec
 }
 
 type Unit struct {
@@ -125,20 +185,72 @@ func NewUnit(user user.User) Unit {
 	}
 }
 
+// File is a unit, timer or drop-in file generated for a job
+type File struct {
+	Path    string
+	Content []byte // nil when the file is removed
+}
+
 // Generate systemd unit
 func (u Unit) Generate(config Config) error {
-	var err error
-	systemdProfile := GetServiceFile(config.Title, config.SubTitle)
-	timerProfile := GetTimerFile(config.Title, config.SubTitle)
-
 	systemdUserDir := systemdSystemDir
 	if config.UnitType == UserUnit {
+		var err error
 		systemdUserDir, err = u.GetUserDir()
 		if err != nil {
 			return err
 		}
 	}
 
+	files, err := u.Render(config)
+	if err != nil {
+		return err
+	}
+
+	dropInDirs := []string{
+		filepath.Join(systemdUserDir, GetTimerFileDropInDir(config.Title, config.SubTitle)),
+		filepath.Join(systemdUserDir, GetServiceFileDropInDir(config.Title, config.SubTitle)),
+	}
+	for _, dropInDir := range dropInDirs {
+		if err = u.fs.MkdirAll(dropInDir, 0o755); err != nil {
+			return err
+		}
+		if config.UnitType == UserUnit && u.user.Sudo {
+			// we need to change the owner to the original account
+			_ = u.fs.Chown(dropInDir, u.user.Uid, u.user.Gid)
+		}
+	}
+
+	for _, file := range files {
+		if file.Content == nil {
+			clog.Debugf("deleting orphaned file %v", file.Path)
+			if err = u.fs.Remove(file.Path); err != nil {
+				return err
+			}
+			continue
+		}
+		clog.Debugf("writing %v", file.Path)
+		if err = afero.WriteFile(u.fs, file.Path, file.Content, defaultPermission); err != nil {
+			return err
+		}
+		if config.UnitType == UserUnit && u.user.Sudo {
+			// we need to change the owner to the original account
+			_ = u.fs.Chown(file.Path, u.user.Uid, u.user.Gid)
+		}
+	}
+	return nil
+}
+
+// Render returns the content of the unit, timer and drop-in files of the job, without writing them
+func (u Unit) Render(config Config) ([]File, error) {
+	systemdProfile := GetServiceFile(config.Title, config.SubTitle)
+	timerProfile := GetTimerFile(config.Title, config.SubTitle)
+
+	systemdUserDir := systemdSystemDir
+	if config.UnitType == UserUnit {
+		systemdUserDir = u.getUserDirPath()
+	}
+
 	environment := slices.Clone(config.Environment)
 
 	if config.UnitType == SystemUnit && config.User == "" {
@@ -158,11 +270,30 @@ func (u Unit) Generate(config Config) error {
 		}
 	}
 
+	var readWritePaths []string
+	if config.Hardening {
+		// restic needs to write into its cache directory
+		if cacheDir := resticCacheDir(environment); cacheDir != "" {
+			readWritePaths = append(readWritePaths, cacheDir)
+		}
+		readWritePaths = append(readWritePaths, config.ReadWritePaths...)
+	}
+
+	credentials := make([]string, 0, len(config.Credentials))
+	for _, id := range slices.Sorted(maps.Keys(config.Credentials)) {
+		credentials = append(credentials, id+":"+config.Credentials[id])
+	}
+
 	policy := ""
 	if config.Priority == constants.SchedulePriorityBackground {
 		policy = "idle"
 	}
 
+	onFailure := ""
+	if config.OnFailureCommandLine != "" {
+		onFailure = GetFailureServiceFile(config.Title, config.SubTitle)
+	}
+
 	info := templateInfo{
 		DefaultData:          templates.NewDefaultData(nil),
 		JobDescription:       config.JobDescription,
@@ -178,53 +309,42 @@ func (u Unit) Generate(config Config) error {
 		IOSchedulingClass:    config.IOSchedulingClass,
 		IOSchedulingPriority: config.IOSchedulingPriority,
 		User:                 config.User,
+		RandomizedDelaySec:   int(config.RandomizedDelay.Seconds()),
+		FixedRandomDelay:     config.FixedRandomDelay && config.RandomizedDelay >= time.Second,
+		Hardening:            config.Hardening,
+		ReadWritePaths:       readWritePaths,
+		MemoryMax:            config.MemoryMax,
+		CPUQuota:             config.CPUQuota,
+		IOWeight:             config.IOWeight,
+		LoadCredentials:      credentials,
+		OnFailure:            onFailure,
+		OnFailureCommandLine: config.OnFailureCommandLine,
+		WatchdogSec:          int(config.Watchdog.Seconds()),
 	}
 
-	var data bytes.Buffer
-
-	systemdUnitTmpl, err := u.loadTemplate(config.UnitFile, systemdUnitDefaultTmpl)
-	if err != nil {
-		return err
-	}
-	unitTmpl, err := templates.New("systemd.unit").Parse(systemdUnitTmpl)
-	if err != nil {
-		return err
-	}
-	if err = unitTmpl.Execute(&data, info); err != nil {
-		return err
-	}
-	filePathName := filepath.Join(systemdUserDir, systemdProfile)
-	clog.Debugf("writing %v", filePathName)
-	if err = afero.WriteFile(u.fs, filePathName, data.Bytes(), defaultPermission); err != nil {
-		return err
-	}
-	data.Reset()
-
-	if config.UnitType == UserUnit && u.user.Sudo {
-		// we need to change the owner to the original account
-		_ = u.fs.Chown(filePathName, u.user.Uid, u.user.Gid)
-	}
-
-	systemdTimerTmpl, err := u.loadTemplate(config.TimerFile, systemdTimerDefaultTmpl)
+	unit, err := u.renderTemplate("systemd.unit", config.UnitFile, systemdUnitDefaultTmpl, info)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	timerTmpl, err := templates.New("timer.unit").Parse(systemdTimerTmpl)
+	timer, err := u.renderTemplate("timer.unit", config.TimerFile, systemdTimerDefaultTmpl, info)
 	if err != nil {
-		return err
-	}
-	if err = timerTmpl.Execute(&data, info); err != nil {
-		return err
+		return nil, err
 	}
-	filePathName = filepath.Join(systemdUserDir, timerProfile)
-	clog.Debugf("writing %v", filePathName)
-	if err = afero.WriteFile(u.fs, filePathName, data.Bytes(), defaultPermission); err != nil {
-		return err
+	files := []File{
+		{Path: filepath.Join(systemdUserDir, systemdProfile), Content: unit},
+		{Path: filepath.Join(systemdUserDir, timerProfile), Content: timer},
 	}
 
-	if config.UnitType == UserUnit && u.user.Sudo {
-		// we need to change the owner to the original account
-		_ = u.fs.Chown(filePathName, u.user.Uid, u.user.Gid)
+	failureFile := filepath.Join(systemdUserDir, GetFailureServiceFile(config.Title, config.SubTitle))
+	if onFailure != "" {
+		failure, err := u.renderTemplate("failure.unit", "", systemdFailureUnitTmpl, info)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: failureFile, Content: failure})
+	} else if exists, _ := afero.Exists(u.fs, failureFile); exists {
+		// the failure unit is no longer needed
+		files = append(files, File{Path: failureFile})
 	}
 
 	existingFiles := collect.All(config.DropInFiles, u.DropInFileExists)
@@ -233,35 +353,65 @@ func (u Unit) Generate(config Config) error {
 		GetTimerFileDropInDir(config.Title, config.SubTitle):   collect.All(existingFiles, u.IsTimerDropIn),
 		GetServiceFileDropInDir(config.Title, config.SubTitle): collect.All(existingFiles, collect.Not(u.IsTimerDropIn)),
 	}
-	for dropInDir, dropInFiles := range dropIns {
-		dropInDir = filepath.Join(systemdUserDir, dropInDir)
-		if err = u.createDropIns(dropInDir, dropInFiles); err != nil {
-			return err
+	for _, dropInDir := range slices.Sorted(maps.Keys(dropIns)) {
+		dropInFiles, err := u.renderDropIns(filepath.Join(systemdUserDir, dropInDir), dropIns[dropInDir])
+		if err != nil {
+			return nil, err
 		}
-		if config.UnitType == UserUnit && u.user.Sudo {
-			// we need to change the owner to the original account
-			_ = afero.Walk(u.fs, dropInDir, func(path string, info fs.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				_ = u.fs.Chown(path, u.user.Uid, u.user.Gid)
-				return nil
-			})
+		files = append(files, dropInFiles...)
+	}
+	return files, nil
+}
+
+// resticCacheDir returns the default cache directory of restic from the environment of the unit
+func resticCacheDir(environment []string) string {
+	values := make(map[string]string, len(environment))
+	for _, variable := range environment {
+		if name, value, found := strings.Cut(variable, "="); found {
+			values[name] = value
 		}
 	}
+	if dir := values["RESTIC_CACHE_DIR"]; dir != "" {
+		return dir
+	}
+	if dir := values["XDG_CACHE_HOME"]; dir != "" {
+		return filepath.Join(dir, "restic")
+	}
+	if home := values["HOME"]; home != "" {
+		return filepath.Join(home, ".cache", "restic")
+	}
+	return ""
+}
 
-	return nil
+func (u Unit) renderTemplate(name, filename, defaultTmpl string, info templateInfo) ([]byte, error) {
+	source, err := u.loadTemplate(filename, defaultTmpl)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := templates.New(name).Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	var data bytes.Buffer
+	if err = tmpl.Execute(&data, info); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
 }
 
-// GetUserDir returns the default directory where systemd stores user units
+// GetUserDir returns the default directory where systemd stores user units, and creates it if needed
 func (u Unit) GetUserDir() (string, error) {
-	systemdUserDir := filepath.Join(u.user.UserHomeDir, ".config", "systemd", "user")
+	systemdUserDir := u.getUserDirPath()
 	if err := u.fs.MkdirAll(systemdUserDir, 0o700); err != nil {
 		return "", err
 	}
 	return systemdUserDir, nil
 }
 
+func (u Unit) getUserDirPath() string {
+	return filepath.Join(u.user.UserHomeDir, ".config", "systemd", "user")
+}
+
 // GetSystemDir returns the path where the local systemd units are stored
 func GetSystemDir() string {
 	return systemdSystemDir
@@ -282,6 +432,11 @@ func GetTimerFileDropInDir(profileName, commandName string) string {
 	return fmt.Sprintf("resticprofile-%s@profile-%s.timer.d", commandName, profileName)
 }
 
+// GetFailureServiceFile returns the name of the service notifying the failure of the profile service
+func GetFailureServiceFile(profileName, commandName string) string {
+	return fmt.Sprintf("resticprofile-on-failure-%s-profile-%s.service", commandName, profileName)
+}
+
 // GetTimerFile returns the timer file name for the profile
 func GetTimerFile(profileName, commandName string) string {
 	return fmt.Sprintf("resticprofile-%s@profile-%s.timer", commandName, profileName)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/user"
 	"github.com/spf13/afero"
@@ -46,6 +47,214 @@ func TestGenerateSystemUnit(t *testing.T) {
 	requireFileExists(t, fs, timerFile)
 }
 
+func TestRenderUserUnit(t *testing.T) {
+	t.Parallel()
+	fs := afero.NewMemMapFs()
+	unit := Unit{fs: fs, user: testStandardUser}
+
+	dropIn := "/etc/resticprofile/timer.conf"
+	require.NoError(t, afero.WriteFile(fs, dropIn, []byte("[Timer]\nRandomizedDelaySec=60\n"), 0o644))
+	dropInDir := filepath.Join(unit.getUserDirPath(), "resticprofile-backup@profile-name.timer.d")
+	orphan := filepath.Join(dropInDir, "old.resticprofile.conf")
+	require.NoError(t, afero.WriteFile(fs, orphan, []byte("[Timer]\n"), 0o644))
+
+	files, err := unit.Render(Config{
+		CommandLine:      "commandLine",
+		WorkingDirectory: "workdir",
+		Title:            "name",
+		SubTitle:         "backup",
+		JobDescription:   "job description",
+		TimerDescription: "timer description",
+		Schedules:        []string{"daily"},
+		UnitType:         UserUnit,
+		DropInFiles:      []string{dropIn},
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+
+	assert.Equal(t, filepath.Join(unit.getUserDirPath(), "resticprofile-backup@profile-name.service"), files[0].Path)
+	assert.Contains(t, string(files[0].Content), "ExecStart=commandLine\n")
+	assert.Equal(t, filepath.Join(unit.getUserDirPath(), "resticprofile-backup@profile-name.timer"), files[1].Path)
+	assert.Contains(t, string(files[1].Content), "OnCalendar=daily\n")
+	assert.Equal(t, File{Path: orphan}, files[2])
+	assert.Equal(t, File{Path: filepath.Join(dropInDir, "timer.resticprofile.conf"), Content: []byte("[Timer]\nRandomizedDelaySec=60\n")}, files[3])
+
+	// nothing was written
+	assertNoFileExists(t, fs, files[0].Path)
+	assertNoFileExists(t, fs, files[1].Path)
+	assertNoFileExists(t, fs, files[3].Path)
+	requireFileExists(t, fs, orphan)
+}
+
+func TestRenderTimerWithRandomizedDelay(t *testing.T) {
+	t.Parallel()
+	unit := Unit{fs: afero.NewMemMapFs(), user: testStandardUser}
+
+	config := Config{
+		CommandLine:      "commandLine",
+		WorkingDirectory: "workdir",
+		Title:            "name",
+		SubTitle:         "backup",
+		Schedules:        []string{"daily"},
+		UnitType:         SystemUnit,
+		RandomizedDelay:  15 * time.Minute,
+	}
+	files, err := unit.Render(config)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Contains(t, string(files[1].Content), "Persistent=true\nRandomizedDelaySec=900\n\n[Install]")
+
+	config.FixedRandomDelay = true
+	files, err = unit.Render(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(files[1].Content), "RandomizedDelaySec=900\nFixedRandomDelay=true\n")
+
+	config.RandomizedDelay = 0
+	files, err = unit.Render(config)
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[1].Content), "RandomDelay")
+}
+
+func TestRenderHardenedUnit(t *testing.T) {
+	const expectedService = `[Unit]
+Description=job description
+
+[Service]
+Type=notify
+WorkingDirectory=workdir
+ExecStart=commandLine
+Environment="HOME=/home/testuser"
+LoadCredential=restic-password:/etc/restic/password
+MemoryMax=2G
+CPUQuota=50%
+IOWeight=10
+WatchdogSec=900
+ProtectSystem=full
+ProtectHome=read-only
+PrivateTmp=true
+NoNewPrivileges=true
+ReadWritePaths=-/home/testuser/.cache/restic
+ReadWritePaths=-/srv/backup
+`
+	t.Parallel()
+	unit := Unit{fs: afero.NewMemMapFs(), user: testStandardUser}
+
+	config := Config{
+		CommandLine:      "commandLine",
+		WorkingDirectory: "workdir",
+		Title:            "name",
+		SubTitle:         "backup",
+		JobDescription:   "job description",
+		Schedules:        []string{"daily"},
+		UnitType:         UserUnit,
+		Hardening:        true,
+		ReadWritePaths:   []string{"/srv/backup"},
+		MemoryMax:        "2G",
+		CPUQuota:         "50%",
+		IOWeight:         10,
+		Watchdog:         15 * time.Minute,
+		Credentials:      map[string]string{"restic-password": "/etc/restic/password"},
+	}
+	files, err := unit.Render(config)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, expectedService, string(files[0].Content))
+
+	// cache directory from the environment
+	config.Environment = []string{"XDG_CACHE_HOME=/var/cache/user"}
+	files, err = unit.Render(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(files[0].Content), "ReadWritePaths=-/var/cache/user/restic\n")
+
+	config.Environment = []string{"RESTIC_CACHE_DIR=/var/cache/restic", "XDG_CACHE_HOME=/var/cache/user"}
+	files, err = unit.Render(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(files[0].Content), "ReadWritePaths=-/var/cache/restic\n")
+
+	// read-write paths only apply with hardening
+	config.Hardening = false
+	files, err = unit.Render(config)
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), "Protect")
+	assert.NotContains(t, string(files[0].Content), "ReadWritePaths")
+}
+
+func TestRenderOnFailureUnit(t *testing.T) {
+	const expectedFailure = `[Unit]
+Description=Notify the failure of job description
+
+[Service]
+Type=oneshot
+WorkingDirectory=workdir
+ExecStart=resticprofile notify-failure backup@name
+Environment="HOME=/home/testuser"
+`
+	t.Parallel()
+	fs := afero.NewMemMapFs()
+	unit := Unit{fs: fs, user: testStandardUser}
+
+	config := Config{
+		CommandLine:          "resticprofile run-schedule backup@name",
+		OnFailureCommandLine: "resticprofile notify-failure backup@name",
+		WorkingDirectory:     "workdir",
+		Title:                "name",
+		SubTitle:             "backup",
+		JobDescription:       "job description",
+		Schedules:            []string{"daily"},
+		UnitType:             UserUnit,
+		Environment:          []string{"HOME=/home/testuser"},
+	}
+	files, err := unit.Render(config)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Contains(t, string(files[0].Content), "\nOnFailure=resticprofile-on-failure-backup-profile-name.service\n")
+	assert.Equal(t, "resticprofile-on-failure-backup-profile-name.service", filepath.Base(files[2].Path))
+	assert.Equal(t, expectedFailure, string(files[2].Content))
+
+	require.NoError(t, fs.MkdirAll(filepath.Dir(files[2].Path), 0o700))
+	require.NoError(t, afero.WriteFile(fs, files[2].Path, files[2].Content, 0o600))
+
+	// the failure unit is removed when no longer needed
+	config.OnFailureCommandLine = ""
+	files, err = unit.Render(config)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.NotContains(t, string(files[0].Content), "OnFailure=")
+	assert.Equal(t, "resticprofile-on-failure-backup-profile-name.service", filepath.Base(files[2].Path))
+	assert.Nil(t, files[2].Content)
+}
+
+func TestRenderSystemOnFailureUnitWithUser(t *testing.T) {
+	const expectedFailure = `[Unit]
+Description=Notify the failure of job description
+
+[Service]
+Type=oneshot
+WorkingDirectory=workdir
+ExecStart=resticprofile notify-failure backup@name
+User=testuser
+Environment="HOME=%s"
+`
+	t.Parallel()
+	unit := Unit{fs: afero.NewMemMapFs(), user: testSudoUser}
+
+	files, err := unit.Render(Config{
+		CommandLine:          "resticprofile run-schedule backup@name",
+		OnFailureCommandLine: "resticprofile notify-failure backup@name",
+		WorkingDirectory:     "workdir",
+		Title:                "name",
+		SubTitle:             "backup",
+		JobDescription:       "job description",
+		Schedules:            []string{"daily"},
+		UnitType:             SystemUnit,
+		User:                 testStandardUser.Username,
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Contains(t, string(files[0].Content), "\nUser=testuser\n")
+	assert.Equal(t, fmt.Sprintf(expectedFailure, testSudoUser.UserHomeDir), string(files[2].Content))
+}
+
 func TestGenerateSystemUnitServiceAfterNetworkOnline(t *testing.T) {
 	const expectedService = `[Unit]
 Description=job description
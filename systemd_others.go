@@ -2,6 +2,20 @@
 
 package main
 
+import (
+	"io"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
 func notifyStart() {}
 
 func notifyStop() {}
+
+func notifyWait(string, time.Duration) {}
+
+func watchOutput(output io.Writer) io.Writer { return output }
+
+func newSystemdNotifier(*config.Profile) monitor.Receiver { return nil }
@@ -0,0 +1,190 @@
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notifications struct {
+	mu     sync.Mutex
+	states []string
+}
+
+func (n *notifications) notify(_ bool, state string) (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.states = append(n.states, state)
+	return true, nil
+}
+
+func (n *notifications) all() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return slices.Clone(n.states)
+}
+
+func mockSdNotify(t *testing.T) *notifications {
+	t.Helper()
+	n := &notifications{}
+	defaultSdNotify := sdNotify
+	sdNotify = n.notify
+	t.Cleanup(func() { sdNotify = defaultSdNotify })
+	return n
+}
+
+// resetWatchdog clears the state of the watchdog left by the other tests (waiting on a lock, etc.)
+func resetWatchdog(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		lastActivity.Store(0)
+		waitingUntil.Store(0)
+		activityFromProgress.Store(false)
+		progressFinished.Store(false)
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestNewSystemdNotifier(t *testing.T) {
+	profile := config.NewProfile(nil, "profile")
+
+	t.Setenv("NOTIFY_SOCKET", "")
+	assert.Nil(t, newSystemdNotifier(profile))
+
+	t.Setenv("NOTIFY_SOCKET", "/run/systemd/notify")
+	assert.NotNil(t, newSystemdNotifier(profile))
+}
+
+func TestSystemdNotifier(t *testing.T) {
+	n := mockSdNotify(t)
+	resetWatchdog(t)
+	notifier := &systemdNotifier{profile: "profile"}
+
+	notifier.Start("backup")
+	assert.False(t, activityFromProgress.Load())
+	assert.True(t, isAlive(0, 0))
+
+	lastTick := time.Now().UnixNano()
+	status := monitor.Status{PercentDone: 0.25, TotalFiles: 10, FilesDone: 2, TotalBytes: 4 * 1024 * 1024, BytesDone: 1024 * 1024}
+	notifier.Status(status)
+	assert.True(t, activityFromProgress.Load())
+	assert.True(t, isAlive(lastTick, lastTick))
+
+	// no progress and too early for another status
+	lastTick = time.Now().UnixNano()
+	notifier.Status(status)
+	assert.False(t, isAlive(lastTick, lastTick))
+
+	// restic is saving the snapshot
+	status.PercentDone = 1
+	notifier.Status(status)
+	assert.True(t, progressFinished.Load())
+	assert.True(t, isAlive(time.Now().UnixNano(), time.Now().UnixNano()))
+
+	notifier.Summary("backup", monitor.Summary{}, "", nil)
+	assert.False(t, activityFromProgress.Load())
+	assert.False(t, progressFinished.Load())
+	notifier.Summary("check", monitor.Summary{}, "", errors.New("failure"))
+
+	assert.Equal(t, []string{
+		"STATUS=profile profile: running backup",
+		"STATUS=profile profile: backup 25.0% done, 2/10 files, 1.0 MiB/4.0 MiB",
+		"STATUS=profile profile: backup finished",
+		"STATUS=profile profile: check failed",
+	}, n.all())
+}
+
+func TestActivityWriter(t *testing.T) {
+	resetWatchdog(t)
+	output := &bytes.Buffer{}
+	assert.Same(t, output, watchOutput(output))
+
+	stopWatchdog = make(chan struct{})
+	defer func() { stopWatchdog = nil }()
+	writer := watchOutput(output)
+	require.NotSame(t, output, writer)
+
+	activityFromProgress.Store(false)
+	lastTick := time.Now().UnixNano()
+	assert.False(t, isAlive(lastTick, lastTick))
+	_, err := writer.Write([]byte("output"))
+	require.NoError(t, err)
+	assert.True(t, isAlive(lastTick, lastTick))
+	assert.Equal(t, "output", output.String())
+
+	// the output of a backup reporting its progress is not a sign of activity
+	activityFromProgress.Store(true)
+	lastTick = time.Now().UnixNano()
+	_, err = writer.Write([]byte(" status"))
+	require.NoError(t, err)
+	assert.False(t, isAlive(lastTick, lastTick))
+	assert.Equal(t, "output status", output.String())
+}
+
+func TestNotifyWait(t *testing.T) {
+	n := mockSdNotify(t)
+	resetWatchdog(t)
+	notifyWait("waiting for lock", 5*time.Second)
+	assert.Equal(t, []string{"STATUS=waiting for lock\nEXTEND_TIMEOUT_USEC=35000000\nWATCHDOG=1"}, n.all())
+
+	// the run is alive while waiting
+	now := time.Now()
+	assert.True(t, isAlive(now.UnixNano(), now.Add(4*time.Second).UnixNano()))
+	assert.False(t, isAlive(now.UnixNano(), now.Add(6*time.Second).UnixNano()))
+}
+
+func TestWatchdog(t *testing.T) {
+	n := mockSdNotify(t)
+	resetWatchdog(t)
+	defaultSdWatchdogEnabled := sdWatchdogEnabled
+	sdWatchdogEnabled = func(bool) (time.Duration, error) { return 20 * time.Millisecond, nil }
+	defer func() { sdWatchdogEnabled = defaultSdWatchdogEnabled }()
+
+	pings := func() int {
+		return len(slices.DeleteFunc(n.all(), func(state string) bool { return state != "WATCHDOG=1" }))
+	}
+
+	notifyStart()
+	require.NotNil(t, stopWatchdog)
+	assert.Eventually(t, func() bool { return pings() == 1 }, time.Second, 5*time.Millisecond)
+
+	// no more pings without any activity
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, 1, pings())
+
+	notifyActivity()
+	assert.Eventually(t, func() bool { return pings() == 2 }, time.Second, 5*time.Millisecond)
+	notifyStop()
+	assert.Nil(t, stopWatchdog)
+
+	states := n.all()
+	assert.Equal(t, "READY=1", states[0])
+	assert.Equal(t, "STOPPING=1", states[len(states)-1])
+}
+
+func TestFormatBytes(t *testing.T) {
+	testData := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536 * 1024, "1.5 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	}
+	for _, testItem := range testData {
+		assert.Equal(t, testItem.expected, formatBytes(testItem.size))
+	}
+}
@@ -0,0 +1,6 @@
+//go:build windows
+
+package main
+
+// Windows doesn't provide the IANA timezone database used by the schedules in another timezone
+import _ "time/tzdata"
@@ -15,6 +15,8 @@ var (
 	Gray      = gray.SprintFunc()
 	green     = color.New(color.FgGreen)
 	Green     = green.SprintFunc()
+	red       = color.New(color.FgRed)
+	Red       = red.SprintFunc()
 	yellow    = color.New(color.FgYellow)
 	Yellow    = yellow.SprintFunc()
 	underline = color.New(color.Underline)
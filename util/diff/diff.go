@@ -0,0 +1,145 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const contextLines = 3
+
+type operation byte
+
+const (
+	opEqual  operation = ' '
+	opDelete operation = '-'
+	opInsert operation = '+'
+)
+
+type line struct {
+	op   operation
+	text string
+}
+
+// Unified returns the differences between the from and to contents in the unified diff format,
+// or an empty string when both contents are the same
+func Unified(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+	lines := compare(splitLines(from), splitLines(to))
+
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(lines); {
+		// find the next change
+		first := start
+		for first < len(lines) && lines[first].op == opEqual {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		// extend the hunk until there are more than 2 x contextLines unchanged lines
+		last := first
+		for index := first; index < len(lines); index++ {
+			if lines[index].op != opEqual {
+				last = index
+			} else if index-last > 2*contextLines {
+				break
+			}
+		}
+		begin := max(first-contextLines, start)
+		end := min(last+contextLines+1, len(lines))
+		writeHunk(builder, lines, begin, end)
+		start = end
+	}
+	return builder.String()
+}
+
+func writeHunk(builder *strings.Builder, lines []line, begin, end int) {
+	fromLine, toLine := 1, 1
+	for _, l := range lines[:begin] {
+		if l.op != opInsert {
+			fromLine++
+		}
+		if l.op != opDelete {
+			toLine++
+		}
+	}
+	fromCount, toCount := 0, 0
+	for _, l := range lines[begin:end] {
+		if l.op != opInsert {
+			fromCount++
+		}
+		if l.op != opDelete {
+			toCount++
+		}
+	}
+	if fromCount == 0 {
+		fromLine--
+	}
+	if toCount == 0 {
+		toLine--
+	}
+	fmt.Fprintf(builder, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+	for _, l := range lines[begin:end] {
+		builder.WriteByte(byte(l.op))
+		builder.WriteString(l.text)
+		builder.WriteByte('\n')
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// compare returns the edit script from the longest common subsequence of the two lists of lines
+func compare(from, to []string) []line {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]line, 0, len(from)+len(to))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			lines = append(lines, line{opEqual, from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, line{opDelete, from[i]})
+			i++
+		default:
+			lines = append(lines, line{opInsert, to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		lines = append(lines, line{opDelete, from[i]})
+	}
+	for ; j < len(to); j++ {
+		lines = append(lines, line{opInsert, to[j]})
+	}
+	return lines
+}
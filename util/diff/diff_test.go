@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	testCases := []struct {
+		name     string
+		from, to string
+		expected string
+	}{
+		{
+			name:     "same content",
+			from:     "a\nb\n",
+			to:       "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "new file",
+			from:     "",
+			to:       "a\nb\n",
+			expected: "--- from\n+++ to\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "removed file",
+			from:     "a\n",
+			to:       "",
+			expected: "--- from\n+++ to\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name:     "changed line",
+			from:     "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			to:       "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expected: "--- from\n+++ to\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "two hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			to:   "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			expected: "--- from\n+++ to\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n",
+		},
+		{
+			name:     "close changes in one hunk",
+			from:     "1\n2\n3\n4\n5\n",
+			to:       "one\n2\n3\n4\nfive\n",
+			expected: "--- from\n+++ to\n@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Unified("from", "to", tc.from, tc.to))
+		})
+	}
+}
@@ -22,6 +22,10 @@ type File struct {
 	mutex           sync.Mutex
 	timer           *time.Timer
 	timerMutex      sync.Mutex
+	rotation        Rotation
+	size            int64      // current size of the file when rotation is enabled
+	archiveMutex    sync.Mutex // held while an archive is compressed and cleaned up in the background
+	archiveErr      error      // errors of the rotations, returned by Close
 	// stats
 	fileOpenCount  atomic.Int32
 	fileCloseCount atomic.Int32
@@ -59,10 +63,28 @@ func (f *File) open() error {
 	var err error
 	f.fileOpenCount.Add(1)
 	f.handle, err = os.OpenFile(f.filename, f.flag, f.perm)
+	if err == nil && f.rotation.Enabled() {
+		var info os.FileInfo
+		if info, err = f.handle.Stat(); err == nil {
+			f.size = info.Size()
+		}
+	}
 	return err
 }
 
+// Close closes the file and waits for the archives to be compressed and cleaned up
 func (f *File) Close() error {
+	err := f.closeHandle()
+
+	// wait for the archive in progress
+	f.archiveMutex.Lock()
+	defer f.archiveMutex.Unlock()
+	err = errors.Join(err, f.archiveErr)
+	f.archiveErr = nil
+	return err
+}
+
+func (f *File) closeHandle() error {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
@@ -95,14 +117,52 @@ func (f *File) Write(data []byte) (n int, err error) {
 		defer f.resetCloseTimer()
 	}
 
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.rotation.Enabled() {
+		f.rotateIfNeeded(len(data))
+	}
+
 	if f.handle == nil {
 		return 0, ErrAttemptToWriteOnClosedFile
 	}
 
 	n, err = f.handle.Write(data)
+	f.size += int64(n)
 	return
 }
 
+// rotateIfNeeded moves the file to an archive when writing size bytes would exceed the maximum size.
+// The archive is compressed and cleaned up in the background. The caller must hold the mutex.
+func (f *File) rotateIfNeeded(size int) {
+	if f.handle == nil || f.size == 0 || f.size+int64(size) <= f.rotation.MaxSize {
+		return
+	}
+	f.fileCloseCount.Add(1)
+	err := f.handle.Close()
+	f.handle = nil
+	archiveFile, rotateErr := rotate(f.filename)
+
+	// the file is opened again even when the rotation failed: the next writes are never lost
+	f.fileOpenCount.Add(1)
+	var openErr error
+	f.handle, openErr = os.OpenFile(f.filename, f.flag, f.perm)
+	// when the rotation failed, it's attempted again after another MaxSize bytes
+	f.size = 0
+	err = errors.Join(err, rotateErr, openErr)
+
+	// locked before starting the goroutine, so Close always waits for this archive
+	f.archiveMutex.Lock()
+	go func() {
+		defer f.archiveMutex.Unlock()
+		if rotateErr == nil {
+			err = errors.Join(err, archive(archiveFile, f.filename, f.rotation, f.perm))
+		}
+		f.archiveErr = errors.Join(f.archiveErr, err)
+	}()
+}
+
 func (f *File) stopCloseTimer() {
 	f.timerMutex.Lock()
 	defer f.timerMutex.Unlock()
@@ -32,3 +32,8 @@ func WithFileFlag(flag int) FileOption {
 func WithFileTruncate() FileOption {
 	return func(f *File) { f.flag |= os.O_TRUNC }
 }
+
+// WithFileRotation rotates the file when it reaches a maximum size
+func WithFileRotation(rotation Rotation) FileOption {
+	return func(f *File) { f.rotation = rotation }
+}
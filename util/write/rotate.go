@@ -0,0 +1,148 @@
+package write
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	archiveTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix    = ".gz"
+)
+
+// can be replaced in tests
+var renameFile = os.Rename
+
+// Rotation configures how a log file is rotated and how many archives are kept
+type Rotation struct {
+	MaxSize  int64         // rotate the file before it grows over MaxSize bytes (0 disables rotation)
+	MaxAge   time.Duration // remove archives older than MaxAge (0 keeps them regardless of their age)
+	MaxFiles int           // number of archives to keep (0 keeps all)
+	Compress bool          // compress archives with gzip
+}
+
+// Enabled returns true when the file is rotated
+func (r Rotation) Enabled() bool {
+	return r.MaxSize > 0
+}
+
+// archiveName returns the name of the archive for filename: "name-<time>.ext"
+func archiveName(filename string, now time.Time) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "-" + now.Format(archiveTimeFormat) + ext
+}
+
+// archivePatterns returns the glob patterns matching the (compressed or not) archives of filename
+func archivePatterns(filename string) []string {
+	ext := filepath.Ext(filename)
+	pattern := EscapeGlob(strings.TrimSuffix(filename, ext)) + "-????-??-??T??-??-??.???" + EscapeGlob(ext)
+	return []string{pattern, pattern + compressSuffix}
+}
+
+// rotate moves filename to a new archive and returns the name of the archive
+func rotate(filename string) (string, error) {
+	now := time.Now()
+	archive := archiveName(filename, now)
+	// never overwrite an archive from the same millisecond (compressed or not)
+	for fileExists(archive) || fileExists(archive+compressSuffix) {
+		now = now.Add(time.Millisecond)
+		archive = archiveName(filename, now)
+	}
+	if err := renameFile(filename, archive); err != nil {
+		return "", err
+	}
+	return archive, nil
+}
+
+// archive compresses the archive (when enabled), then removes the archives of filename exceeding the limits
+func archive(archive, filename string, rotation Rotation, perm os.FileMode) error {
+	var err error
+	if rotation.Compress {
+		err = compress(archive, perm)
+	}
+	var files []string
+	for _, pattern := range archivePatterns(filename) {
+		matches, _ := filepath.Glob(pattern)
+		files = append(files, matches...)
+	}
+	return errors.Join(err, removeOldFiles(files, rotation.MaxFiles, rotation.MaxAge))
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Lstat(filename)
+	return err == nil
+}
+
+func compress(filename string, perm os.FileMode) error {
+	source, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(filename+compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(target)
+	_, err = io.Copy(writer, source)
+	err = errors.Join(err, writer.Close(), target.Close())
+	if err != nil {
+		_ = os.Remove(filename + compressSuffix)
+		return err
+	}
+	_ = source.Close()
+	return os.Remove(filename)
+}
+
+// CleanupFiles removes the files matching the glob pattern exceeding maxFiles (the most recent are kept) or older than maxAge.
+// A limit of zero is disabled.
+func CleanupFiles(pattern string, maxFiles int, maxAge time.Duration) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	return removeOldFiles(files, maxFiles, maxAge)
+}
+
+func removeOldFiles(files []string, maxFiles int, maxAge time.Duration) error {
+	type fileTime struct {
+		name    string
+		modTime time.Time
+	}
+	list := make([]fileTime, 0, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+			list = append(list, fileTime{name: file, modTime: info.ModTime()})
+		}
+	}
+	// most recent first
+	slices.SortFunc(list, func(a, b fileTime) int {
+		return b.modTime.Compare(a.modTime)
+	})
+
+	var errs []error
+	for index, file := range list {
+		if (maxFiles > 0 && index >= maxFiles) || (maxAge > 0 && time.Since(file.modTime) > maxAge) {
+			if err := os.Remove(file.name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EscapeGlob escapes the characters having a special meaning in a glob pattern
+func EscapeGlob(path string) string {
+	if filepath.Separator == '\\' {
+		// backslash cannot be used to escape on Windows: use character classes instead
+		return strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]").Replace(path)
+	}
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(path)
+}
@@ -0,0 +1,185 @@
+package write
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func archives(t *testing.T, filename string) []string {
+	t.Helper()
+	var files []string
+	for _, pattern := range archivePatterns(filename) {
+		matches, err := filepath.Glob(pattern)
+		require.NoError(t, err)
+		files = append(files, matches...)
+	}
+	return files
+}
+
+func TestFileRotation(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.log")
+	require.NoError(t, os.WriteFile(filename, []byte("existing\n"), 0o600))
+
+	w, err := NewFile(filename, WithFileKeepOpen(true), WithFileRotation(Rotation{MaxSize: 20}))
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("0123456789\n")) // fits in 20 bytes
+	require.NoError(t, err)
+	assert.Empty(t, archives(t, filename))
+
+	_, err = w.Write([]byte("second\n"))
+	require.NoError(t, err)
+	files := archives(t, filename)
+	require.Len(t, files, 1)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, "existing\n0123456789\n", string(content))
+	content, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(content))
+	assert.True(t, strings.HasSuffix(files[0], ".log"))
+}
+
+func TestFileRotationLargerThanMaxSize(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.log")
+	w, err := NewFile(filename, WithFileKeepOpen(false), WithFileRotation(Rotation{MaxSize: 5}))
+	require.NoError(t, err)
+	defer w.Close()
+
+	// an empty file is never rotated
+	_, err = w.Write([]byte("longer than max size\n"))
+	require.NoError(t, err)
+	assert.Empty(t, archives(t, filename))
+
+	_, err = w.Write([]byte("next\n"))
+	require.NoError(t, err)
+	assert.Len(t, archives(t, filename), 1)
+}
+
+func TestFileRotationCompressAndMaxFiles(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.log")
+	w, err := NewFile(filename, WithFileRotation(Rotation{MaxSize: 1, MaxFiles: 2, Compress: true}))
+	require.NoError(t, err)
+	defer w.Close()
+
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		_, err = w.Write([]byte(line))
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond) // distinct archive names and modification times
+	}
+	// the archives are compressed in the background
+	require.NoError(t, w.Close())
+
+	files := archives(t, filename)
+	require.Len(t, files, 2)
+	contents := make([]string, 0, len(files))
+	for _, file := range files {
+		require.True(t, strings.HasSuffix(file, ".log.gz"), file)
+		source, err := os.Open(file)
+		require.NoError(t, err)
+		reader, err := gzip.NewReader(source)
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		source.Close()
+		contents = append(contents, string(content))
+	}
+	assert.ElementsMatch(t, []string{"b\n", "c\n"}, contents)
+}
+
+func TestFileRotationFailure(t *testing.T) {
+	defaultRenameFile := renameFile
+	renameFile = func(string, string) error { return errors.New("rename failed") }
+	defer func() { renameFile = defaultRenameFile }()
+
+	filename := filepath.Join(t.TempDir(), "file.log")
+	w, err := NewFile(filename, WithFileKeepOpen(true), WithFileRotation(Rotation{MaxSize: 10, Compress: true}))
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err = w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	assert.ErrorContains(t, w.Close(), "rename failed")
+	assert.NoError(t, w.Close())
+
+	// nothing is lost
+	assert.Empty(t, archives(t, filename))
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\nthird\n", string(content))
+}
+
+func TestFileRotationConcurrentWrites(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.log")
+	w, err := NewFile(filename, WithFileKeepOpen(true), WithFileRotation(Rotation{MaxSize: 100}))
+	require.NoError(t, err)
+
+	const writers, lines = 4, 100
+	wg := sync.WaitGroup{}
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range lines {
+				_, err := w.Write([]byte("0123456789\n"))
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, w.Close())
+
+	total := 0
+	for _, file := range append(archives(t, filename), filename) {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(content), 100)
+		total += strings.Count(string(content), "0123456789\n")
+	}
+	assert.Equal(t, writers*lines, total)
+}
+
+func TestCleanupFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"run-1.log", "run-2.log", "run-3.log", "run-4.log", "other.log"} {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(file, nil, 0o600))
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+
+	require.NoError(t, CleanupFiles(filepath.Join(dir, "run-*.log"), 3, 0))
+	assert.NoFileExists(t, filepath.Join(dir, "run-4.log"))
+	assert.FileExists(t, filepath.Join(dir, "run-3.log"))
+
+	require.NoError(t, CleanupFiles(filepath.Join(dir, "run-*.log"), 0, 90*time.Minute))
+	assert.FileExists(t, filepath.Join(dir, "run-1.log"))
+	assert.FileExists(t, filepath.Join(dir, "run-2.log"))
+	assert.NoFileExists(t, filepath.Join(dir, "run-3.log"))
+	assert.FileExists(t, filepath.Join(dir, "other.log"))
+}
+
+func TestEscapeGlob(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dir[1]*")
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	filename := filepath.Join(dir, "file.log")
+	require.NoError(t, os.WriteFile(filename, nil, 0o600))
+
+	matches, err := filepath.Glob(EscapeGlob(filename))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filename}, matches)
+}
@@ -109,6 +109,15 @@ func (r *resticWrapper) start(command string) {
 	}
 }
 
+func (r *resticWrapper) status(status monitor.Status) {
+	if r.dryRun {
+		return
+	}
+	for _, p := range r.progress {
+		p.Status(status)
+	}
+}
+
 func (r *resticWrapper) summary(command string, summary monitor.Summary, stderr string, result error) {
 	if r.dryRun {
 		return
@@ -118,6 +127,55 @@ func (r *resticWrapper) summary(command string, summary monitor.Summary, stderr
 	}
 }
 
+func (r *resticWrapper) hookResult(result monitor.HookResult) {
+	if r.dryRun {
+		return
+	}
+	for _, p := range r.progress {
+		if receiver, ok := p.(monitor.HookReceiver); ok {
+			receiver.Hook(result)
+		}
+	}
+}
+
+func (r *resticWrapper) waited(wait monitor.Wait) {
+	if r.dryRun {
+		return
+	}
+	for _, p := range r.progress {
+		if receiver, ok := p.(monitor.WaitReceiver); ok {
+			receiver.Wait(wait)
+		}
+	}
+}
+
+func (r *resticWrapper) failure(command string, err error) {
+	if r.dryRun {
+		return
+	}
+	ctx := r.getErrorContext(err)
+	failure := monitor.Failure{
+		Message:     ctx.Message,
+		CommandLine: ctx.CommandLine,
+		ExitCode:    ctx.ExitCode,
+		Stderr:      ctx.Stderr,
+	}
+	for _, p := range r.progress {
+		if receiver, ok := p.(monitor.FailureReceiver); ok {
+			receiver.Failure(command, failure)
+		}
+	}
+}
+
+// closeProgress releases the progress receivers holding resources
+func (r *resticWrapper) closeProgress() {
+	for _, p := range r.progress {
+		if closer, ok := p.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
 func (r *resticWrapper) runnerWithBeforeAndAfter(commands config.RunShellCommandsSection, command string, action func() error) func() error {
 	return func() (err error) {
 		err = r.runBeforeCommands(commands, command)
@@ -196,6 +254,65 @@ func (r *resticWrapper) getBackupAction() func() error {
 	}
 }
 
+// lockRepository acquires the lock shared by all the profiles using the same repository.
+// The time already spent waiting on the profile lock counts in the lock wait duration.
+func (r *resticWrapper) lockRepository(lockStart time.Time) (*lock.Lock, error) {
+	if r.noLock || r.dryRun {
+		return nil, nil
+	}
+	lockFile := r.profile.GetRepositoryLockFile(r.global.GetRepositoryLockDir())
+	if lockFile == "" {
+		return nil, nil
+	}
+	if r.global.RepositoryLockDir == "" {
+		if err := os.MkdirAll(r.global.GetRepositoryLockDir(), 0o755); err != nil {
+			return nil, fmt.Errorf("cannot create the directory of the repository locks: %w", err)
+		}
+	}
+	var lockWait *time.Duration
+	if r.lockWait != nil {
+		if remaining := *r.lockWait - time.Since(lockStart); remaining > 0 {
+			lockWait = &remaining
+		}
+	}
+	start := time.Now()
+	repositoryLock, err := acquireLock("repository", lockFile, r.profile.Name, r.command, !r.profile.IsExclusiveRepositoryLockCommand(r.command), r.profile.ForceLock, lockWait, r.sigChan)
+	if repositoryLock != nil {
+		r.waited(monitor.Wait{Reason: monitor.WaitLock, Command: r.command, Lock: lockFile, Start: start, Duration: time.Since(start)})
+	}
+	return repositoryLock, err
+}
+
+// joinSetPID returns a SetPID writing the PID in all the locks
+func joinSetPID(setPIDs ...lock.SetPID) lock.SetPID {
+	return func(pid int32) {
+		for _, setPID := range setPIDs {
+			if setPID != nil {
+				setPID(pid)
+			}
+		}
+	}
+}
+
+// acquireSlot waits for a free slot when the number of concurrent runs on the host is limited
+func (r *resticWrapper) acquireSlot() (release func(), err error) {
+	if r.dryRun || r.global.MaxConcurrentRuns <= 0 {
+		return func() {}, nil
+	}
+	start := time.Now()
+	slotDir := r.global.GetConcurrentRunsDir()
+	if r.global.ConcurrentRunsDir == "" {
+		if err = os.MkdirAll(slotDir, 0o755); err != nil {
+			return func() {}, fmt.Errorf("cannot create the directory of the concurrent runs: %w", err)
+		}
+	}
+	release, err = acquireSlot(slotDir, r.global.MaxConcurrentRuns, r.profile.Name, r.command, r.global.ConcurrentRunsWait, r.sigChan)
+	if err == nil {
+		r.waited(monitor.Wait{Reason: monitor.WaitSlot, Command: r.command, Lock: slotDir, Start: start, Duration: time.Since(start)})
+	}
+	return
+}
+
 func (r *resticWrapper) runProfile() error {
 	lockFile := r.profile.Lock
 	if r.noLock || r.dryRun {
@@ -205,9 +322,27 @@ func (r *resticWrapper) runProfile() error {
 	r.startTime = time.Now()
 	profileShellCommands, shellCommands := r.profile.GetRunShellCommandsSections(r.command)
 	sendMonitoring := r.profile.GetMonitoringSections(r.command)
+	defer r.closeProgress()
 
-	err := lockRun(lockFile, r.profile.ForceLock, r.lockWait, r.sigChan, func(setPID lock.SetPID) error {
+	lockStart := time.Now()
+	err := lockRun(lockFile, r.profile.Name, r.command, r.profile.IsSharedLockCommand(r.command), r.profile.ForceLock, r.lockWait, r.sigChan, func(setPID lock.SetPID) error {
 		r.setPID = setPID
+		if lockFile != "" {
+			r.waited(monitor.Wait{Reason: monitor.WaitLock, Command: r.command, Lock: lockFile, Start: lockStart, Duration: time.Since(lockStart)})
+		}
+		repositoryLock, err := r.lockRepository(lockStart)
+		if err != nil {
+			return err
+		}
+		if repositoryLock != nil {
+			defer repositoryLock.Release()
+			r.setPID = joinSetPID(setPID, repositoryLock.SetPID)
+		}
+		release, err := r.acquireSlot()
+		if err != nil {
+			return err
+		}
+		defer release()
 		return runOnFailure(
 			r.runnerWithBeforeAndAfter(profileShellCommands, "", func() (err error) {
 				// breaking change from 0.7.0 and 0.7.1:
@@ -245,6 +380,7 @@ func (r *resticWrapper) runProfile() error {
 			}),
 			// on failure
 			func(err error) {
+				r.failure(r.command, err)
 				r.sendAfterFail(sendMonitoring, r.command, err)
 				// "run-after-fail" in section (returns nil when no-error or not defined)
 				if r.runAfterFailCommands(shellCommands, err, r.command) == nil {
@@ -524,10 +660,19 @@ func (r *resticWrapper) runRetention() error {
 	}
 }
 
+// getCommandFlags returns the flags of the command, including the flags of the schedule when it's the scheduled command
+func (r *resticWrapper) getCommandFlags(command string) *shell.Args {
+	args := r.profile.GetCommandFlags(command)
+	if command == r.command && r.ctx != nil && r.ctx.scheduleEntry != nil {
+		r.ctx.scheduleEntry.AddCommandFlags(args)
+	}
+	return args
+}
+
 func (r *resticWrapper) runCommand(command string) error {
 	clog.Infof("profile '%s': starting '%s'", r.profile.Name, command)
 	r.start(command)
-	args := r.profile.GetCommandFlags(command)
+	args := r.getCommandFlags(command)
 
 	streamSource := io.NopCloser(strings.NewReader(""))
 	defer func() { streamSource.Close() }()
@@ -543,7 +688,7 @@ func (r *resticWrapper) runCommand(command string) error {
 			// Add output scanners
 			if len(r.progress) > 0 {
 				if r.profile.Backup.ExtendedStatus {
-					rCommand.scanOutput = shell.ScanBackupJson
+					rCommand.scanOutput = shell.NewScanBackupJson(r.status)
 				} else if !r.ctx.terminal.StdoutIsTerminal() {
 					// restic detects its output is not a terminal and no longer displays the monitor.
 					// Scan plain output only if resticprofile is not run from a terminal (e.g. schedule)
@@ -615,6 +760,7 @@ func (r *resticWrapper) runAfterFailCommands(commands config.RunShellCommandsSec
 // commandsType and command is used for logging and in error messages but has no other influence.
 // set failure to a non-nil value to initialize a fail environment (e.g. run-after-fail).
 func (r *resticWrapper) runShellCommands(commands []string, commandsType, command string, failure error) error {
+	hookType := commandsType
 	if len(command) > 0 {
 		commandsType = commandsType + " " + command
 	}
@@ -631,10 +777,14 @@ func (r *resticWrapper) runShellCommands(commands []string, commandsType, comman
 		rCommand.stdout = r.ctx.terminal.Stdout()
 		rCommand.stderr = r.ctx.terminal.Stderr()
 		r.ctx.terminal.FlushAllOutput()
+		start := time.Now()
 		_, stderr, err := runShellCommand(rCommand)
 		if err != nil {
-			err = fmt.Errorf("%s on profile '%s': %w", commandsType, r.profile.Name, err)
-			return newCommandError(rCommand, stderr, err)
+			err = newCommandError(rCommand, stderr, fmt.Errorf("%s on profile '%s': %w", commandsType, r.profile.Name, err))
+		}
+		r.hookResult(monitor.HookResult{Type: hookType, Command: command, Index: i + 1, Total: len(commands), Duration: time.Since(start), Error: err})
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -661,11 +811,14 @@ func (r *resticWrapper) runFinalShellCommands(command string, fail error) {
 			rCommand.stdout = r.ctx.terminal.Stdout()
 			rCommand.stderr = r.ctx.terminal.Stderr()
 			r.ctx.terminal.FlushAllOutput()
-			_, _, err := runShellCommand(rCommand)
+			start := time.Now()
+			_, stderr, err := runShellCommand(rCommand)
 			if err != nil {
 				clog.Errorf("run-finally command %d/%d failed ('%s' on profile '%s'): %s",
 					index+1, len(commands), command, r.profile.Name, err.Error())
+				err = newCommandError(rCommand, stderr, err)
 			}
+			r.hookResult(monitor.HookResult{Type: "run-finally", Command: command, Index: index + 1, Total: len(commands), Duration: time.Since(start), Error: err})
 		}(i, commands[i])
 	}
 }
@@ -682,6 +835,10 @@ func (r *resticWrapper) sendAfter(monitoring config.SendMonitoringSections, comm
 
 // sendAfterFail a command
 func (r *resticWrapper) sendAfterFail(monitoring config.SendMonitoringSections, command string, err error) {
+	if r.ctx != nil && r.ctx.failureUnit {
+		clog.Debug("send-after-fail is left to the systemd OnFailure unit")
+		return
+	}
 	r.sendMonitoring(monitoring.SendAfterFail, command, "send-after-fail", err)
 }
 
@@ -694,10 +851,12 @@ func (r *resticWrapper) sendMonitoring(sections []config.SendMonitoringSection,
 	for i, section := range sections {
 		clog.Debugf("starting %q from %s %d/%d", sendType, command, i+1, len(sections))
 		r.ctx.terminal.FlushAllOutput()
+		start := time.Now()
 		err := r.sender.Send(section, r.getContextWithError(err), r.profile.GetEnvironment(true))
 		if err != nil {
 			clog.Warningf("%q returned an error: %s", sendType, err.Error())
 		}
+		r.hookResult(monitor.HookResult{Type: sendType, Command: command, Index: i + 1, Total: len(sections), Duration: time.Since(start), Error: err})
 	}
 }
 
@@ -842,7 +1001,10 @@ func (r *resticWrapper) canRetryAfterError(command string, summary monitor.Summa
 	retry, sleep := r.canRetryAfterRemoteLockFailure(output)
 
 	if retry && sleep > 0 {
+		start := time.Now()
+		notifyWait(fmt.Sprintf("waiting %s for the lock of the repository", sleep.Truncate(time.Second)), sleep)
 		err := interruptibleSleep(sleep, r.sigChan)
+		r.waited(monitor.Wait{Reason: monitor.WaitRetry, Command: command, Lock: r.profile.Repository.String(), Start: start, Duration: time.Since(start)})
 		if err != nil {
 			return false, err
 		}
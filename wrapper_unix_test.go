@@ -4,10 +4,14 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
 	"github.com/creativeprojects/resticprofile/shell"
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/stretchr/testify/assert"
@@ -82,3 +86,44 @@ func TestPrepareCommandShouldEscapeBinary(t *testing.T) {
 	cmd := wrapper.prepareCommand("backup", args, false)
 	assert.Equal(t, `/full\ path\ to/restic`, cmd.command)
 }
+
+type statusRecorder struct {
+	hookRecorder
+	statuses []monitor.Status
+	summary  monitor.Summary
+}
+
+func (r *statusRecorder) Status(status monitor.Status) { r.statuses = append(r.statuses, status) }
+func (r *statusRecorder) Summary(_ string, summary monitor.Summary, _ string, _ error) {
+	r.summary = summary
+}
+
+func TestStatusEvents(t *testing.T) {
+	t.Parallel()
+
+	binary := filepath.Join(t.TempDir(), "restic")
+	script := `#!/bin/sh
+echo '{"message_type":"status","percent_done":0,"total_files":1,"total_bytes":100}'
+echo '{"message_type":"status","percent_done":0.5,"total_files":2,"files_done":1,"total_bytes":100,"bytes_done":50}'
+echo '{"message_type":"summary","files_new":2,"total_files_processed":2,"total_bytes_processed":100,"snapshot_id":"abcdef"}'
+`
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o700))
+
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{ExtendedStatus: true}
+	ctx := &Context{
+		binary:   binary,
+		profile:  profile,
+		command:  constants.CommandBackup,
+		terminal: term.NewTerminal(),
+	}
+	recorder := &statusRecorder{}
+	wrapper := newResticWrapper(ctx)
+	wrapper.addProgress(recorder)
+	require.NoError(t, wrapper.runProfile())
+
+	require.Len(t, recorder.statuses, 2)
+	assert.Equal(t, monitor.Status{TotalFiles: 1, TotalBytes: 100}, recorder.statuses[0])
+	assert.Equal(t, monitor.Status{PercentDone: 0.5, TotalFiles: 2, FilesDone: 1, TotalBytes: 100, BytesDone: 50}, recorder.statuses[1])
+	assert.Equal(t, 2, recorder.summary.FilesNew)
+}